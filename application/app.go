@@ -10,6 +10,7 @@ type (
 	//Application struct
 	Application struct {
 		postgresql *DBConfig
+		modules    []Module
 	}
 
 	//Logger struct
@@ -33,12 +34,20 @@ func SetupApp() *Application {
 		Host:     os.Getenv("DB_HOST"),
 		Port:     os.Getenv("DB_PORT"),
 		Charset:  "utf8",
+		Replicas: replicaHosts(os.Getenv("DB_REPLICA_HOSTS")),
+		Replica:  replicaConfig(),
 	}
 
 	logger := Logger{Stdout: true, Level: "DEBUG"}
 	util.Log = logger.NewLogger()
 
-	return &Application{
-		&dbConfig,
+	app := &Application{
+		postgresql: &dbConfig,
 	}
+
+	// the book module is set up before the modules using its service
+	book := NewBookModule()
+	app.Register(NewTenantModule(), book, NewAuthorModule(book), NewAuditModule(), NewWebhookModule())
+
+	return app
 }
@@ -0,0 +1,37 @@
+package application
+
+import (
+	auditController "github.com/go-rest-api-boilerplate/server/audit/controller"
+	auditRepository "github.com/go-rest-api-boilerplate/server/audit/repository"
+	auditService "github.com/go-rest-api-boilerplate/server/audit/service"
+	"github.com/go-rest-api-boilerplate/util/apiversion"
+)
+
+//AuditModule struct serve the audit trail of the tenant
+type AuditModule struct {
+	service auditService.AuditService
+}
+
+//NewAuditModule func
+func NewAuditModule() *AuditModule {
+	return &AuditModule{}
+}
+
+//Name func
+func (m *AuditModule) Name() string {
+	return "audit"
+}
+
+//Setup func
+func (m *AuditModule) Setup(deps *Deps) error {
+	m.service = auditService.NewAuditService(auditRepository.NewAuditRepository(deps.Conn))
+	return nil
+}
+
+//Routes func
+func (m *AuditModule) Routes(version string) []apiversion.Routes {
+	if version != "v1" {
+		return nil
+	}
+	return []apiversion.Routes{auditController.NewAuditRoutes(m.service)}
+}
@@ -0,0 +1,41 @@
+package application
+
+import (
+	authorController "github.com/go-rest-api-boilerplate/server/author/controller"
+	authorRepository "github.com/go-rest-api-boilerplate/server/author/repository"
+	authorService "github.com/go-rest-api-boilerplate/server/author/service"
+	"github.com/go-rest-api-boilerplate/util/apiversion"
+)
+
+//AuthorModule struct serve the authors and their books
+type AuthorModule struct {
+	book    *BookModule
+	service authorService.AuthorService
+}
+
+//NewAuthorModule func, book must be registered before the author module
+func NewAuthorModule(book *BookModule) *AuthorModule {
+	return &AuthorModule{
+		book: book,
+	}
+}
+
+//Name func
+func (m *AuthorModule) Name() string {
+	return "author"
+}
+
+//Setup func
+func (m *AuthorModule) Setup(deps *Deps) error {
+	// the renamed bylines are published and invalidated by the book service
+	m.service = authorService.NewAuthorService(authorRepository.NewAuthorRepository(deps.Conn), m.book.Service())
+	return nil
+}
+
+//Routes func
+func (m *AuthorModule) Routes(version string) []apiversion.Routes {
+	if version != "v1" {
+		return nil
+	}
+	return []apiversion.Routes{authorController.NewAuthorRoutes(m.service, m.book.Service())}
+}
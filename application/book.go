@@ -1,63 +1,248 @@
 package application
 
 import (
+	"context"
+	"net"
+	"os"
 	"time"
 
-	"github.com/go-rest-api-boilerplate/broker"
 	bookController "github.com/go-rest-api-boilerplate/server/book/controller"
+	"github.com/go-rest-api-boilerplate/server/book/cover"
 	bookRepository "github.com/go-rest-api-boilerplate/server/book/repository"
+	bookRPC "github.com/go-rest-api-boilerplate/server/book/rpc"
 	bookService "github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/go-rest-api-boilerplate/server/book/stream"
+	graphQLController "github.com/go-rest-api-boilerplate/server/graphql/controller"
+	graphQLSchema "github.com/go-rest-api-boilerplate/server/graphql/schema"
 	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/apiversion"
+	"github.com/go-rest-api-boilerplate/util/audit"
+	"github.com/go-rest-api-boilerplate/util/blob"
+	"github.com/go-rest-api-boilerplate/util/cache"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/invalidation"
+	"github.com/go-rest-api-boilerplate/util/outbox"
+	"github.com/lib/pq"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
 )
 
+// rpcStopTimeout bound the graceful stop of the gRPC server, watch streams never end by themselves
+const rpcStopTimeout = 10 * time.Second
+
 type (
-	bookApp struct {
-		broker   broker.BookBroker
-		stopChan chan bool
+	//BookModule struct serve the books over REST, GraphQL and gRPC and own the migrations shipped before the
+	//modules had their own, the tables of the other modules up to idempotency_keys are migrated from it
+	BookModule struct {
+		service       bookService.BookService
+		hub           *stream.Hub
+		streamConfig  stream.Config
+		graphQLRoutes apiversion.Routes
+		notifications *pq.Listener
+		workers       []util.Daemon
+	}
+
+	// rpcWorker serve gRPC on its own port next to the REST API
+	rpcWorker struct {
+		server *grpc.Server
+		tls    bool
 	}
 )
 
-//NewBookDaemon func
-func (app *Application) NewBookDaemon() util.Daemon {
-	broker := NewDBBroker(app.postgresql)
+//NewBookModule func
+func NewBookModule() *BookModule {
+	return &BookModule{}
+}
 
-	return &bookApp{
-		broker,
-		make(chan bool),
-	}
+//Name func
+func (m *BookModule) Name() string {
+	return "book"
+}
+
+//Migrations func return DB_MIGRATION_BOOK_SRC, or the scripts/migration/book directory
+func (m *BookModule) Migrations() string {
+	return migrationSource(m.Name())
+}
+
+//Service func return the book service, available once set up
+func (m *BookModule) Service() bookService.BookService {
+	return m.service
 }
 
-func (d *bookApp) Start() error {
-	conn, err := d.broker.Start()
+//Setup func
+func (m *BookModule) Setup(deps *Deps) error {
+	blobStore, err := blob.NewStore(blob.ConfigFromEnv())
+	if err != nil {
+		return err
+	}
+
+	// reads go to the healthy replicas, writes and transactions to the primary
+	repository, err := newBookRepository(deps.Router)
 	if err != nil {
 		return err
 	}
+	// the caches of the other instances are invalidated by the committed writes of this one
+	if handler, ok := repository.(invalidation.Handler); ok {
+		bus := invalidation.NewBus(deps.Broker.Listener("invalidation"))
+		bus.Subscribe(handler)
+		m.workers = append(m.workers, bus)
+	}
 
-	repository := bookRepository.NewBookRepository(conn)
-	usecase := bookService.NewBookService(repository)
-	bookController.NewBookServer(usecase)
+	m.service = bookService.NewBookService(repository, audit.NewWriter(deps.Conn), outbox.NewWriter(deps.Conn),
+		blobStore, cover.OptionsFromEnv())
 
-	go d.runLoop()
+	// changes are notified by the books triggers, whichever instance or client made them
+	m.streamConfig = stream.ConfigFromEnv()
+	m.hub = stream.NewHub(m.streamConfig.BufferSize)
+	m.notifications = deps.Broker.Listener("stream")
+	m.workers = append(m.workers, stream.NewListener(m.notifications, m.hub))
 
-	return nil
+	// the calls are served over TLS with the certificates of the API
+	m.workers = append(m.workers, &rpcWorker{
+		server: bookRPC.NewServer(m.service, m.hub, deps.Resolver, deps.Authenticator, deps.TLS,
+			deps.Router.Config()),
+		tls: deps.TLS != nil,
+	})
+
+	m.graphQLRoutes, err = graphQLController.NewGraphQLRoutes(m.service, graphQLSchema.ConfigFromEnv())
+	return err
 }
 
-func (d *bookApp) runLoop() {
-	logger := util.Log.WithField("contex", "bookApp")
-	for {
-		select {
-		default:
-			logger.Debug("bookApp started")
-			time.Sleep(time.Second * 1)
-		case stop := <-d.stopChan:
-			if stop {
-				return
-			}
-		}
+//Routes func
+func (m *BookModule) Routes(version string) []apiversion.Routes {
+	if version != "v1" {
+		return nil
+	}
+	return []apiversion.Routes{
+		bookController.NewBookRoutes(m.service),
+		bookController.NewStreamRoutes(m.hub, m.streamConfig),
+		m.graphQLRoutes,
 	}
 }
 
-func (d *bookApp) Stop() error {
-	d.stopChan <- true
-	return d.broker.Stop()
+//Workers func
+func (m *BookModule) Workers() []util.Daemon {
+	return m.workers
+}
+
+//Health func check the connection notifying the book changes
+func (m *BookModule) Health(ctx context.Context) error {
+	return m.notifications.Ping()
+}
+
+//Commands func
+func (m *BookModule) Commands(app *Application) []cli.Command {
+	return []cli.Command{
+		{
+			Name:        "db-seed",
+			Usage:       "start book seed",
+			Description: "load book fixtures of the environment, optionally with generated books",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "env",
+					Usage: "fixture environment directory",
+					Value: "development",
+				},
+				cli.BoolFlag{
+					Name:  "truncate",
+					Usage: "empty the books table before loading",
+				},
+				cli.IntFlag{
+					Name:  "generate",
+					Usage: "generate N fake books",
+				},
+				cli.Int64Flag{
+					Name:  "seed",
+					Usage: "seed of the fake book generator",
+					Value: 1,
+				},
+				cli.StringFlag{
+					Name:  "tenant",
+					Usage: "tenant owning the books, TENANT_DEFAULT when not set",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				env := c.String("env")
+				if !c.IsSet("env") && os.Getenv("APP_ENV") != "" {
+					env = os.Getenv("APP_ENV")
+				}
+
+				return app.SeedBook(SeedOptions{
+					Source:   os.Getenv("DB_SEED_BOOK_SRC"),
+					Env:      env,
+					Truncate: c.Bool("truncate"),
+					Generate: c.Int("generate"),
+					Seed:     c.Int64("seed"),
+					Tenant:   tenantFlag(c),
+				})
+			},
+		},
+		{
+			Name:        "outbox-relay",
+			Usage:       "start book outbox relay",
+			Description: "publish the book events of the outbox table and deliver them to webhooks",
+			Action: func(c *cli.Context) error {
+				return AppRunner(app.NewOutboxDaemon())
+			},
+		},
+	}
+}
+
+// newBookRepository return the book repository, read through the configured cache
+func newBookRepository(router *dbrouter.Router) (bookRepository.BookRepository, error) {
+	repository := bookRepository.NewBookRepository(router)
+
+	cacheConfig := cache.ConfigFromEnv()
+	bookCache, err := cache.New(cacheConfig)
+	if err != nil || bookCache == nil {
+		return repository, err
+	}
+
+	return bookRepository.NewCachedBookRepository(repository, bookCache, cacheConfig.TTL,
+		invalidation.NewPublisher(router.Primary())), nil
+}
+
+// tenantFlag return the --tenant flag, TENANT_DEFAULT or the default tenant of the migrations
+func tenantFlag(c *cli.Context) string {
+	if c.IsSet("tenant") {
+		return c.String("tenant")
+	}
+	if id := os.Getenv("TENANT_DEFAULT"); id != "" {
+		return id
+	}
+	return "default"
+}
+
+func (w *rpcWorker) Start() error {
+	listener, err := net.Listen("tcp", ":"+bookRPC.Port())
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if w.tls {
+			util.Log.Infof("gRPC Service listening on port %v over TLS", bookRPC.Port())
+		} else {
+			util.Log.Infof("gRPC Service listening on port %v", bookRPC.Port())
+		}
+		if err := w.server.Serve(listener); err != nil {
+			util.Log.WithField("context", "rpc").Error(err)
+		}
+	}()
+	return nil
+}
+
+func (w *rpcWorker) Stop() error {
+	stopped := make(chan struct{})
+	go func() {
+		w.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(rpcStopTimeout):
+		w.server.Stop()
+	}
+	return nil
 }
@@ -0,0 +1,271 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/scaffold"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/lib/pq"
+	"github.com/urfave/cli"
+
+	// drivers of the migrations
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// legacyMigrationsModule own the migrations versioned in legacyMigrationsTable, the single version table of
+// the releases before the modules had their own
+const (
+	legacyMigrationsModule = "book"
+	legacyMigrationsTable  = "schema_migrations"
+)
+
+//Commands func return the serve command and a command per registered module
+func (app *Application) Commands() []cli.Command {
+	commands := []cli.Command{
+		{
+			Name:        "serve",
+			Usage:       "start the API",
+			Description: "serve the routes and run the workers of every module",
+			Action: func(c *cli.Context) error {
+				return AppRunner(app.NewAPIDaemon())
+			},
+		},
+		{
+			Name:        "db-migrate",
+			Usage:       "migrate every module",
+			Description: "apply the migrations of every module in their registration order",
+			Action: func(c *cli.Context) error {
+				return app.MigrateAll(true)
+			},
+		},
+		{
+			Name:        "db-rollback",
+			Usage:       "roll back every module",
+			Description: "roll back the migrations of every module in the reverse registration order",
+			Action: func(c *cli.Context) error {
+				return app.MigrateAll(false)
+			},
+		},
+		{
+			Name:        "generate",
+			Usage:       "generate code",
+			Description: "generate the code of a new part of the API",
+			Subcommands: []cli.Command{
+				{
+					Name:      "resource",
+					Usage:     "generate a resource",
+					ArgsUsage: "NAME",
+					Description: "write the models, repository, service and controller of a resource with their tests, " +
+						"its module registered in application/app.go and its migration in scripts/migration/NAME",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "fields",
+							Usage: "NAME:TYPE[:required] separated by commas, TYPE is string, text, int, int64, float, decimal, bool or time",
+						},
+						cli.StringFlag{
+							Name:  "migrations",
+							Usage: "migration directory of the module, scripts/migration/NAME when not set",
+						},
+						cli.BoolFlag{
+							Name:  "force",
+							Usage: "overwrite the existing files",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return app.GenerateResource(c.Args().First(), c.String("fields"), c.String("migrations"),
+							c.Bool("force"))
+					},
+				},
+			},
+		},
+	}
+
+	for _, module := range app.modules {
+		command := cli.Command{
+			Name:        module.Name(),
+			Usage:       module.Name() + " commands",
+			Description: "commands of the " + module.Name() + " module",
+		}
+
+		if m, ok := module.(MigrationModule); ok {
+			name, source := m.Name(), m.Migrations()
+			command.Subcommands = append(command.Subcommands,
+				cli.Command{
+					Name:        "db-migrate",
+					Usage:       "start " + m.Name() + " migration",
+					Description: "start " + m.Name() + " migration",
+					Action: func(c *cli.Context) error {
+						return app.Migrate(name, source, true)
+					},
+				},
+				cli.Command{
+					Name:        "db-rollback",
+					Usage:       "start " + m.Name() + " rollback",
+					Description: "start " + m.Name() + " rollback",
+					Action: func(c *cli.Context) error {
+						return app.Migrate(name, source, false)
+					},
+				},
+			)
+		}
+		if m, ok := module.(CommandModule); ok {
+			command.Subcommands = append(command.Subcommands, m.Commands(app)...)
+		}
+
+		// "book" started the API before the modules, it still does
+		if module.Name() == "book" {
+			command.Description = "start the API"
+			command.Action = func(c *cli.Context) error {
+				return AppRunner(app.NewAPIDaemon())
+			}
+		}
+
+		// the modules serving routes only have no command
+		if command.Action != nil || len(command.Subcommands) > 0 {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
+
+//MigrateAll func apply the migrations of every module in their registration order, or roll them back in the
+//reverse order when up is false
+func (app *Application) MigrateAll(up bool) error {
+	var modules []MigrationModule
+	for _, module := range app.modules {
+		if m, ok := module.(MigrationModule); ok {
+			modules = append(modules, m)
+		}
+	}
+
+	for i := range modules {
+		m := modules[i]
+		if !up {
+			m = modules[len(modules)-1-i]
+		}
+		if err := app.Migrate(m.Name(), m.Migrations(), up); err != nil {
+			return fmt.Errorf("%s: %w", m.Name(), err)
+		}
+	}
+	return nil
+}
+
+//Migrate func apply the migrations of source owned by the module name, or roll them back when up is false.
+//The version of the module is kept in its own schema_migrations_<name> table
+func (app *Application) Migrate(name, source string, up bool) error {
+	if up {
+		util.Log.Infof("Migrate database from source '%s'", source)
+	} else {
+		util.Log.Infof("Rollback database from source '%s'", source)
+	}
+
+	if name == legacyMigrationsModule {
+		if err := app.adoptLegacyMigrations(migrationsTable(name)); err != nil {
+			return err
+		}
+	}
+
+	config := app.postgresql
+	migration, err := migrate.New(source, fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable&x-migrations-table=%s",
+		config.Username, config.Password, config.Host, config.Port, config.Database, migrationsTable(name)))
+	if err != nil {
+		return err
+	}
+	defer migration.Close()
+
+	if up {
+		err = migration.Up()
+	} else {
+		err = migration.Down()
+	}
+	if err == migrate.ErrNoChange {
+		return nil
+	}
+	return err
+}
+
+// adoptLegacyMigrations seed the version table of the legacy migrations module from schema_migrations, the
+// table of every migration before the modules had their own. It is done once, schema_migrations is kept for
+// the releases migrating from it
+func (app *Application) adoptLegacyMigrations(table string) error {
+	// the bypass role is created by the migrations
+	config := *app.postgresql
+	config.Role = ""
+	conn, err := NewDBBroker(&config).connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var legacy bool
+	err = tx.QueryRow("SELECT to_regclass($1) IS NOT NULL", legacyMigrationsTable).Scan(&legacy)
+	if err != nil || !legacy {
+		return err
+	}
+	// the concurrent migrations wait for the adoption
+	if _, err := tx.Exec("LOCK TABLE " + pq.QuoteIdentifier(legacyMigrationsTable) + " IN EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+	var adopted bool
+	if err := tx.QueryRow("SELECT to_regclass($1) IS NOT NULL", table).Scan(&adopted); err != nil || adopted {
+		return err
+	}
+
+	util.Log.Infof("Adopt the versions of %s in %s", legacyMigrationsTable, table)
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING ALL)",
+			pq.QuoteIdentifier(table), pq.QuoteIdentifier(legacyMigrationsTable)),
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s",
+			pq.QuoteIdentifier(table), pq.QuoteIdentifier(legacyMigrationsTable)),
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// migrationsTable return the version table of the migrations of a module
+func migrationsTable(name string) string {
+	return "schema_migrations_" + strings.ToLower(strings.Replace(name, "-", "_", -1))
+}
+
+//GenerateResource func generate the resource name in the working directory, its table is migrated from the
+//migrations directory
+func (app *Application) GenerateResource(name, fields, migrations string, force bool) error {
+	if name == "" {
+		return errors.New("generate resource: missing NAME")
+	}
+
+	module, err := scaffold.ModulePath(".")
+	if err != nil {
+		return err
+	}
+	resource, err := scaffold.NewResource(module, name, fields)
+	if err != nil {
+		return err
+	}
+
+	written, err := scaffold.Generate(resource, scaffold.Options{Root: ".", MigrationDir: migrations, Force: force})
+	for _, path := range written {
+		fmt.Println(path)
+	}
+	if err != nil {
+		return err
+	}
+	util.Log.Infof("Resource %s generated, run db-migrate and regenerate the docs", resource.Name)
+	return nil
+}
@@ -3,12 +3,22 @@ package application
 import (
 	"database/sql"
 	"fmt"
+	"net"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-rest-api-boilerplate/util"
-	_ "github.com/lib/pq"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/lib/pq"
+)
+
+// Reconnection intervals of the listeners
+const (
+	minListenerReconnect = time.Second
+	maxListenerReconnect = time.Minute
 )
 
 type (
@@ -21,14 +31,22 @@ type (
 		Host     string
 		Port     string
 		Charset  string
+		// Role is set on every connection, tenant.BypassRole see the rows of every tenant
+		Role string
+		// Replicas are the host:port of the read replicas, sharing the credentials of the primary
+		Replicas []string
+		Replica  dbrouter.Config
 	}
 
 	//DBBroker struct
 	DBBroker struct {
 		sync.Mutex
 		Conn      *sql.DB
+		config    *DBConfig
+		router    *dbrouter.Router
 		watchStop chan bool
 		errors    chan error
+		listeners []*pq.Listener
 	}
 )
 
@@ -41,16 +59,50 @@ func getDBConnectionString() *DBConfig {
 		Host:     os.Getenv("DB_HOST"),
 		Port:     os.Getenv("DB_PORT"),
 		Charset:  "utf8",
+		// the commands and the daemons are shared by the tenants, the API is scoped to them with TENANT_RLS
+		Role:     tenant.BypassRole,
+		Replicas: replicaHosts(os.Getenv("DB_REPLICA_HOSTS")),
+		Replica:  replicaConfig(),
+	}
+}
+
+// replicaHosts split the comma separated replica hosts
+func replicaHosts(value string) []string {
+	hosts := make([]string, 0)
+	for _, host := range strings.Split(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// replicaConfig return the replica checks of the environment
+func replicaConfig() dbrouter.Config {
+	config := dbrouter.DefaultConfig
+	if d, err := time.ParseDuration(os.Getenv("DB_REPLICA_MAX_LAG")); err == nil && d > 0 {
+		config.MaxLag = d
 	}
+	if d, err := time.ParseDuration(os.Getenv("DB_REPLICA_CHECK_INTERVAL")); err == nil && d > 0 {
+		config.CheckInterval = d
+	}
+	return config
 }
 
 //NewDBBroker func
 func NewDBBroker(dbConfig *DBConfig) *DBBroker {
+	if dbConfig == nil {
+		dbConfig = getDBConnectionString()
+	}
+
 	broker := DBBroker{
 		sync.Mutex{},
 		nil,
+		dbConfig,
+		nil,
 		make(chan bool),
 		nil,
+		nil,
 	}
 
 	return &broker
@@ -59,6 +111,17 @@ func NewDBBroker(dbConfig *DBConfig) *DBBroker {
 //Start func
 func (b *DBBroker) Start() (*sql.DB, error) {
 	conn, err := b.setup()
+	if err != nil {
+		return conn, err
+	}
+
+	replicas, err := b.replicas()
+	if err != nil {
+		return conn, err
+	}
+	b.router = dbrouter.New(conn, replicas, b.config.Replica)
+	b.router.Start()
+
 	go b.watch()
 	return conn, err
 }
@@ -67,9 +130,45 @@ func (b *DBBroker) Start() (*sql.DB, error) {
 func (b *DBBroker) Stop() error {
 	b.stopWatch()
 	defer close(b.watchStop)
+
+	b.Lock()
+	for _, listener := range b.listeners {
+		listener.Close()
+	}
+	b.listeners = nil
+	b.Unlock()
+
+	if b.router != nil {
+		b.router.Stop()
+	}
+
 	return b.Conn.Close()
 }
 
+//Router func return the router of the reads to the replicas, available once started
+func (b *DBBroker) Router() *dbrouter.Router {
+	return b.router
+}
+
+//Listener func return a LISTEN connection reconnecting by itself, it is closed with the broker.
+//The listened channels are listened again after a reconnection
+func (b *DBBroker) Listener(name string) *pq.Listener {
+	logger := util.Log.WithField("context", name)
+
+	listener := pq.NewListener(dataSourceName(b.config), minListenerReconnect, maxListenerReconnect,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				logger.Warnf("listener event %d: %v", event, err)
+			}
+		})
+
+	b.Lock()
+	b.listeners = append(b.listeners, listener)
+	b.Unlock()
+
+	return listener
+}
+
 func (b *DBBroker) setup() (*sql.DB, error) {
 	util.Log.Info("Setup PostgreSql Connection")
 	if b.Conn != nil {
@@ -86,30 +185,54 @@ func (b *DBBroker) setup() (*sql.DB, error) {
 	b.Conn = conn
 	b.Unlock()
 
-	errors := make(chan error, 1)
-	go func() {
-		errors <- err
-	}()
-
-	b.errors = errors
+	b.errors = make(chan error, 1)
 
 	return conn, nil
 }
 
 func (b *DBBroker) connect() (*sql.DB, error) {
-	configDB := getDBConnectionString()
+	conn, err := sql.Open(b.config.Dialect, dataSourceName(b.config))
 
-	cfg := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
+	return conn, err
+}
+
+// replicas open a connection to every replica, they serve reads once checked
+func (b *DBBroker) replicas() ([]*dbrouter.Replica, error) {
+	replicas := make([]*dbrouter.Replica, 0, len(b.config.Replicas))
+	for _, address := range b.config.Replicas {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			host, port = address, b.config.Port
+		}
+
+		configDB := *b.config
+		configDB.Host, configDB.Port = host, port
+		conn, err := sql.Open(configDB.Dialect, dataSourceName(&configDB))
+		if err != nil {
+			for _, replica := range replicas {
+				replica.DB.Close()
+			}
+			return nil, err
+		}
+
+		replicas = append(replicas, &dbrouter.Replica{Name: address, DB: conn})
+	}
+	return replicas, nil
+}
+
+// dataSourceName return the lib/pq connection string of the config
+func dataSourceName(configDB *DBConfig) string {
+	name := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
 		configDB.Username,
 		configDB.Password,
 		configDB.Host,
 		configDB.Port,
 		configDB.Database,
 	)
-
-	conn, err := sql.Open(configDB.Dialect, cfg)
-
-	return conn, err
+	if configDB.Role != "" {
+		name += fmt.Sprintf(" options='-c role=%s'", configDB.Role)
+	}
+	return name
 }
 
 func (b *DBBroker) watch() {
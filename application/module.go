@@ -0,0 +1,96 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"os"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/broker"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/apiversion"
+	"github.com/go-rest-api-boilerplate/util/auth"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/scaffold"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/urfave/cli"
+)
+
+type (
+	// Module is a resource plugged into the Application. Its capabilities are declared by implementing
+	// MigrationModule, RouteModule, WorkerModule, HealthModule and CommandModule
+	Module interface {
+		// Name of the module, it is the CLI command of the module
+		Name() string
+		// Setup wire the module to the shared dependencies, the modules are set up in their registration order
+		// so a module can use the services of the modules registered before it
+		Setup(deps *Deps) error
+	}
+
+	// MigrationModule own a migration source, it get db-migrate and db-rollback commands. The version of
+	// every source is kept in its own table, the sources are migrated in the registration order
+	MigrationModule interface {
+		Module
+		Migrations() string
+	}
+
+	// RouteModule serve routes on the shared API server
+	RouteModule interface {
+		Module
+		// Routes of the API version, none when the module is not part of the version
+		Routes(version string) []apiversion.Routes
+	}
+
+	// WorkerModule run background workers, they are started after every module is set up and stopped
+	// in reverse order
+	WorkerModule interface {
+		Module
+		Workers() []util.Daemon
+	}
+
+	// HealthModule report its health on /health
+	HealthModule interface {
+		Module
+		Health(ctx context.Context) error
+	}
+
+	// CommandModule add subcommands to the command of the module
+	CommandModule interface {
+		Module
+		Commands(app *Application) []cli.Command
+	}
+
+	// Deps are the dependencies shared by the modules
+	Deps struct {
+		Conn   *sql.DB
+		Broker broker.Broker
+		// Router route the reads to the healthy replicas
+		Router *dbrouter.Router
+		// Resolver scope the requests and calls to their tenant
+		Resolver *tenant.Resolver
+		// Authenticator find the principal of the requests and calls
+		Authenticator *auth.Authenticator
+		// TLS serve the certificates of the reloader, nil when TLS is off
+		TLS *tls.Config
+	}
+)
+
+// migrationSource return DB_MIGRATION_<NAME>_SRC, or the scripts/migration/<name> directory of the module
+func migrationSource(name string) string {
+	env := "DB_MIGRATION_" + strings.ToUpper(strings.Replace(name, "-", "_", -1)) + "_SRC"
+	if source := os.Getenv(env); source != "" {
+		return source
+	}
+	return "file://" + scaffold.DefaultMigrationDir(name)
+}
+
+//Register func add modules to the application, their order is their setup order
+func (app *Application) Register(modules ...Module) {
+	app.modules = append(app.modules, modules...)
+}
+
+//Modules func return the registered modules
+func (app *Application) Modules() []Module {
+	return app.modules
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"io"
+
+	"github.com/go-rest-api-boilerplate/broker"
+	"github.com/go-rest-api-boilerplate/server/webhook/dispatcher"
+	webhookRepository "github.com/go-rest-api-boilerplate/server/webhook/repository"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/outbox"
+)
+
+type (
+	outboxApp struct {
+		broker     broker.Broker
+		relay      *outbox.Relay
+		publisher  outbox.Publisher
+		dispatcher *dispatcher.Dispatcher
+	}
+)
+
+//NewOutboxDaemon func
+func (app *Application) NewOutboxDaemon() util.Daemon {
+	return &outboxApp{
+		broker: NewDBBroker(app.postgresql),
+	}
+}
+
+func (d *outboxApp) Start() error {
+	conn, err := d.broker.Start()
+	if err != nil {
+		return err
+	}
+
+	publisher, err := outbox.NewPublisher(outbox.PublisherConfigFromEnv())
+	if err != nil {
+		return err
+	}
+
+	// every event is also queued for the subscribed webhooks
+	webhooks := webhookRepository.NewWebhookRepository(conn)
+	d.publisher = outbox.MultiPublisher{publisher, dispatcher.NewPublisher(webhooks)}
+
+	d.relay = outbox.NewRelay(conn, d.publisher, outbox.RelayConfigFromEnv())
+	d.dispatcher = dispatcher.NewDispatcher(webhooks, dispatcher.ConfigFromEnv())
+
+	util.Log.Info("Outbox relay and webhook dispatcher started")
+
+	if err := d.relay.Start(); err != nil {
+		return err
+	}
+	return d.dispatcher.Start()
+}
+
+func (d *outboxApp) Stop() error {
+	if d.relay != nil {
+		d.relay.Stop()
+	}
+	if d.dispatcher != nil {
+		d.dispatcher.Stop()
+	}
+	if closer, ok := d.publisher.(io.Closer); ok {
+		closer.Close()
+	}
+	return d.broker.Stop()
+}
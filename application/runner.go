@@ -14,7 +14,7 @@ func AppRunner(daemon util.Daemon) error {
 		return err
 	}
 
-	osSignals := make(chan os.Signal)
+	osSignals := make(chan os.Signal, 1)
 	signal.Notify(osSignals, os.Interrupt)
 
 	select {
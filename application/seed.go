@@ -0,0 +1,98 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-rest-api-boilerplate/server/book/cover"
+	"github.com/go-rest-api-boilerplate/server/book/fixture"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	bookService "github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/audit"
+	"github.com/go-rest-api-boilerplate/util/blob"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/outbox"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+)
+
+type (
+	//SeedOptions struct
+	SeedOptions struct {
+		// Source is the fixture root directory, containing one directory per environment
+		Source string
+		// Env is the environment directory to load
+		Env string
+		// Truncate empty the table before loading
+		Truncate bool
+		// Generate add N fake books when greater than zero
+		Generate int
+		// Seed of the fake book generator
+		Seed int64
+		// Tenant owning the loaded books
+		Tenant string
+	}
+)
+
+//SeedBook func
+func (app *Application) SeedBook(opts SeedOptions) error {
+	logger := util.Log.WithField("context", "bookSeed")
+
+	books := make([]*models.Book, 0)
+	if opts.Source != "" {
+		dir := fixture.Dir(opts.Source, opts.Env)
+		logger.Infof("Load fixtures from '%s'", dir)
+
+		loaded, err := fixture.Load(dir)
+		if err != nil {
+			return err
+		}
+		books = append(books, loaded...)
+	}
+
+	if opts.Generate > 0 {
+		logger.Infof("Generate %d books with seed %d", opts.Generate, opts.Seed)
+		books = append(books, fixture.Generate(opts.Generate, opts.Seed)...)
+	}
+
+	for i, book := range books {
+		book.Normalize()
+		if err := book.Validate(); err != nil {
+			return fmt.Errorf("seed: book #%d %q: %w", i+1, book.Title, err)
+		}
+	}
+
+	conn, err := NewDBBroker(app.postgresql).connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the shared cache is flushed by the seed commit, the seed only reads its own writes
+	repository, err := newBookRepository(dbrouter.New(conn, nil, dbrouter.DefaultConfig))
+	if err != nil {
+		return err
+	}
+	// the covers of the truncated books are stored out of the database
+	store, err := blob.NewStore(blob.ConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	usecase := bookService.NewBookService(repository, audit.NewWriter(conn), outbox.NewWriter(conn), store,
+		cover.OptionsFromEnv())
+
+	if !tenant.Valid(opts.Tenant) {
+		return fmt.Errorf("seed: tenant %q: %w", opts.Tenant, tenant.ErrInvalid)
+	}
+	logger.Infof("Seed books of tenant '%s'", opts.Tenant)
+	if opts.Truncate {
+		logger.Info("Truncate books")
+	}
+
+	if err := usecase.SeedBook(tenant.With(context.Background(), opts.Tenant), books, opts.Truncate); err != nil {
+		return err
+	}
+
+	logger.Infof("Seeded %d books", len(books))
+	return nil
+}
@@ -0,0 +1,326 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"expvar"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/broker"
+	tenantRepository "github.com/go-rest-api-boilerplate/server/tenant/repository"
+	tenantService "github.com/go-rest-api-boilerplate/server/tenant/service"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/apiversion"
+	"github.com/go-rest-api-boilerplate/util/auth"
+	"github.com/go-rest-api-boilerplate/util/compress"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/httpserver"
+	"github.com/go-rest-api-boilerplate/util/idempotency"
+	"github.com/go-rest-api-boilerplate/util/servertls"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	echoSwagger "github.com/swaggo/echo-swagger"
+
+	_ "github.com/go-rest-api-boilerplate/docs"
+)
+
+const (
+	// serverStopTimeout bound the graceful shutdown of the API server
+	serverStopTimeout = 10 * time.Second
+
+	// healthTimeout bound the checks of /health
+	healthTimeout = 5 * time.Second
+)
+
+// apiVersions are served with the routes the modules declare for them
+var apiVersions = []string{"v1"}
+
+// sharedPaths are served without version nor tenant
+var sharedPaths = []string{"/swagger", "/health"}
+
+type (
+	// apiApp serve the routes of every module on a single server and run their workers
+	apiApp struct {
+		modules []Module
+		broker  broker.Broker
+		conn    *sql.DB
+		// shared see the rows of every tenant, the purges run on it
+		shared     *DBBroker
+		sharedConn *sql.DB
+		// workers are stopped in reverse order
+		workers        []util.Daemon
+		server         *http.Server
+		redirectServer *http.Server
+		debugServer    *http.Server
+	}
+
+	// HealthResponse is the body of /health
+	HealthResponse struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+)
+
+//NewAPIDaemon func return the daemon serving every registered module. With TENANT_RLS its connections
+//see the rows of the tenant of each call only
+func (app *Application) NewAPIDaemon() util.Daemon {
+	d := &apiApp{
+		modules: app.modules,
+		broker:  NewDBBroker(app.postgresql),
+	}
+	if tenant.ConfigFromEnv().RowLevelSecurity {
+		config := *app.postgresql
+		config.Dialect, config.Role = tenant.DriverName, ""
+		d.broker = NewDBBroker(&config)
+		d.shared = NewDBBroker(app.postgresql)
+	}
+	return d
+}
+
+func (d *apiApp) Start() error {
+	conn, err := d.broker.Start()
+	if err != nil {
+		return err
+	}
+	d.conn = conn
+	d.sharedConn = conn
+	if d.shared != nil {
+		if d.sharedConn, err = d.shared.connect(); err != nil {
+			return err
+		}
+	}
+
+	// the tenant of every call is resolved from its token, header or host and checked against the tenants table
+	tenantConfig := tenant.ConfigFromEnv()
+	resolver := tenant.NewResolver(tenantConfig,
+		tenantService.NewTenantService(tenantRepository.NewTenantRepository(conn)))
+
+	// the certificates are reloaded once rotated, the client certificates are verified with mutual TLS
+	tlsConfig, err := servertls.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	var serverTLS *tls.Config
+	if tlsConfig.Enabled() {
+		reloader, err := servertls.NewReloader(tlsConfig)
+		if err != nil {
+			return err
+		}
+		if err := d.startWorker(reloader); err != nil {
+			return err
+		}
+		serverTLS = reloader.TLSConfig()
+	}
+
+	deps := &Deps{
+		Conn:          conn,
+		Broker:        d.broker,
+		Router:        d.broker.Router(),
+		Resolver:      resolver,
+		Authenticator: auth.NewAuthenticator(auth.ConfigFromEnv()),
+		TLS:           serverTLS,
+	}
+	for _, module := range d.modules {
+		if err := module.Setup(deps); err != nil {
+			return err
+		}
+	}
+
+	// the retried creations and imports of the clients are replayed from the keys of their first attempt
+	idempotencyConfig := idempotency.ConfigFromEnv()
+	idempotencyStore := idempotency.NewStore(conn, idempotencyConfig)
+	purger := idempotency.NewPurger(idempotency.NewStore(d.sharedConn, idempotencyConfig), idempotencyConfig.PurgeInterval)
+	if err := d.startWorker(purger); err != nil {
+		return err
+	}
+	for _, module := range d.modules {
+		if m, ok := module.(WorkerModule); ok {
+			for _, worker := range m.Workers() {
+				if err := d.startWorker(worker); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	serverConfig, err := httpserver.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	corsConfig, err := httpserver.CORSConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	s := echo.New()
+	s.Use(middleware.Logger())
+	s.Use(middleware.Recover())
+	s.Use(util.SessionMiddleware())
+	// the preflight requests carry no tenant
+	s.Use(httpserver.CORS(corsConfig))
+	s.Use(httpserver.Secure(httpserver.SecureConfigFromEnv(), "/swagger"))
+	s.Use(httpserver.BodyLimit(serverConfig))
+	// the replayed and the conditional responses are compressed like the others
+	s.Use(compress.Middleware(compress.ConfigFromEnv()))
+	// the actor of every API call is the principal of its client certificate or bearer token
+	s.Use(auth.Middleware(deps.Authenticator, sharedPaths...))
+	// every API call is scoped to a tenant, the docs, metrics and health are shared
+	s.Use(tenant.Middleware(resolver, sharedPaths...))
+	s.Use(idempotency.Middleware(idempotencyStore, idempotencyConfig))
+	s.Use(dbrouter.Middleware(deps.Router.Config()))
+
+	// the unversioned paths serve API_DEFAULT_VERSION, or the version of the API-Version header
+	versions := make([]*apiversion.Version, 0, len(apiVersions))
+	for _, name := range apiVersions {
+		var routes []apiversion.Routes
+		for _, module := range d.modules {
+			if m, ok := module.(RouteModule); ok {
+				routes = append(routes, m.Routes(name)...)
+			}
+		}
+		versions = append(versions, apiversion.NewVersion(name, routes...).FromEnv())
+	}
+	apiversion.NewRegistry(apiversion.DefaultFromEnv(), versions...).Register(s, sharedPaths...)
+
+	s.GET("/swagger/*", echoSwagger.WrapHandler)
+	s.GET("/health", d.health)
+
+	return d.serve(s, serverConfig, tlsConfig, serverTLS)
+}
+
+// serve the API on API_PORT, over TLS when TLS_CERT_FILE is set
+func (d *apiApp) serve(s *echo.Echo, serverConfig httpserver.Config, tlsConfig servertls.Config,
+	serverTLS *tls.Config) error {
+	port := os.Getenv("API_PORT")
+	d.server = serverConfig.Server(":" + port)
+	d.server.Handler = s
+	d.server.TLSConfig = serverTLS
+
+	go func() {
+		var err error
+		if serverTLS != nil {
+			util.Log.Infof("API Service listening on port %v over TLS", port)
+			// the certificates are served by the reloader
+			err = d.server.ListenAndServeTLS("", "")
+		} else {
+			util.Log.Infof("API Service listening on port %v", port)
+			err = d.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			util.Log.WithField("context", "api").Error(err)
+		}
+	}()
+
+	// the metrics, memory statistics and command line are served to the operators only
+	if addr := os.Getenv("DEBUG_ADDR"); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/vars", expvar.Handler())
+		d.debugServer = &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		}
+		go func() {
+			util.Log.Infof("Debug listening on %v", addr)
+			err := d.debugServer.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				util.Log.WithField("context", "api").Error(err)
+			}
+		}()
+	}
+
+	if serverTLS != nil && tlsConfig.RedirectPort != "" {
+		d.redirectServer = &http.Server{
+			Addr:              ":" + tlsConfig.RedirectPort,
+			Handler:           servertls.RedirectHandler(port),
+			ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		}
+		go func() {
+			util.Log.Infof("HTTPS redirect listening on port %v", tlsConfig.RedirectPort)
+			err := d.redirectServer.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				util.Log.WithField("context", "api").Error(err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// startWorker start a worker, it is stopped with the daemon
+func (d *apiApp) startWorker(worker util.Daemon) error {
+	if err := worker.Start(); err != nil {
+		return err
+	}
+	d.workers = append(d.workers, worker)
+	return nil
+}
+
+func (d *apiApp) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), serverStopTimeout)
+	defer cancel()
+
+	logger := util.Log.WithField("context", "api")
+	if d.redirectServer != nil {
+		d.redirectServer.Shutdown(ctx)
+	}
+	if d.debugServer != nil {
+		d.debugServer.Shutdown(ctx)
+	}
+	// the event streams never end by themselves, they are cut after the timeout
+	if d.server != nil {
+		if err := d.server.Shutdown(ctx); err != nil {
+			logger.Warnf("shutdown: %v", err)
+			d.server.Close()
+		}
+	}
+
+	for i := len(d.workers) - 1; i >= 0; i-- {
+		if err := d.workers[i].Stop(); err != nil {
+			logger.Warnf("stop worker: %v", err)
+		}
+	}
+	if d.shared != nil && d.sharedConn != nil {
+		d.sharedConn.Close()
+	}
+	return d.broker.Stop()
+}
+
+// health answer 200 when the database and the modules are healthy, 503 otherwise. It is served outside of
+// the API versions so it is not part of the docs. The errors may name hosts and users, they are logged only
+func (d *apiApp) health(ctx echo.Context) error {
+	c, cancel := context.WithTimeout(ctx.Request().Context(), healthTimeout)
+	defer cancel()
+
+	logger := util.Log.WithField("context", "health")
+	data := &HealthResponse{
+		Status: "ok",
+		Checks: map[string]string{"database": "ok"},
+	}
+	if err := d.conn.PingContext(c); err != nil {
+		logger.Warnf("database: %v", err)
+		data.Status = "unavailable"
+		data.Checks["database"] = "unavailable"
+	}
+	for _, module := range d.modules {
+		m, ok := module.(HealthModule)
+		if !ok {
+			continue
+		}
+		data.Checks[m.Name()] = "ok"
+		if err := m.Health(c); err != nil {
+			logger.Warnf("%s: %v", m.Name(), err)
+			data.Status = "unavailable"
+			data.Checks[m.Name()] = "unavailable"
+		}
+	}
+
+	if data.Status != "ok" {
+		return ctx.JSON(http.StatusServiceUnavailable, data)
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
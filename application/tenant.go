@@ -0,0 +1,201 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/tenant/models"
+	tenantRepository "github.com/go-rest-api-boilerplate/server/tenant/repository"
+	tenantService "github.com/go-rest-api-boilerplate/server/tenant/service"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/auth"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/urfave/cli"
+)
+
+//TenantModule struct provision the tenants from the CLI, the requests are resolved by the app
+type TenantModule struct{}
+
+//NewTenantModule func
+func NewTenantModule() *TenantModule {
+	return &TenantModule{}
+}
+
+//Name func
+func (m *TenantModule) Name() string {
+	return "tenant"
+}
+
+//Setup func
+func (m *TenantModule) Setup(deps *Deps) error {
+	return nil
+}
+
+//Commands func
+func (m *TenantModule) Commands(app *Application) []cli.Command {
+	idFlag := cli.StringFlag{
+		Name:  "id",
+		Usage: "tenant id",
+	}
+
+	return []cli.Command{
+		{
+			Name:        "create",
+			Usage:       "create a tenant",
+			Description: "provision a tenant, its books are isolated from the other tenants",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "tenant id, a lowercase DNS label also used as subdomain",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "tenant display name",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return app.CreateTenant(c.String("id"), c.String("name"))
+			},
+		},
+		{
+			Name:        "list",
+			Usage:       "list the tenants",
+			Description: "list the tenants and their status",
+			Action: func(c *cli.Context) error {
+				return app.ListTenant(os.Stdout)
+			},
+		},
+		{
+			Name:        "enable",
+			Usage:       "enable a tenant",
+			Description: "let the requests of a disabled tenant through again",
+			Flags:       []cli.Flag{idFlag},
+			Action: func(c *cli.Context) error {
+				return app.SetTenantActive(c.String("id"), true)
+			},
+		},
+		{
+			Name:        "disable",
+			Usage:       "disable a tenant",
+			Description: "reject the requests of a tenant, its data are kept",
+			Flags:       []cli.Flag{idFlag},
+			Action: func(c *cli.Context) error {
+				return app.SetTenantActive(c.String("id"), false)
+			},
+		},
+		{
+			Name:        "token",
+			Usage:       "issue a tenant token",
+			Description: "sign a bearer token carrying the tenant and subject claims with TENANT_TOKEN_SECRET",
+			Flags: []cli.Flag{
+				idFlag,
+				cli.StringFlag{
+					Name:  "subject",
+					Usage: "authenticated principal of the token, the actor of the audited mutations",
+				},
+				cli.DurationFlag{
+					Name:  "ttl",
+					Usage: "token lifetime, 0 never expires",
+					Value: 24 * time.Hour,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return app.TenantToken(os.Stdout, c.String("id"), c.String("subject"), c.Duration("ttl"))
+			},
+		},
+	}
+}
+
+//CreateTenant func provision an active tenant
+func (app *Application) CreateTenant(id, name string) error {
+	return app.withTenantService(func(usecase tenantService.TenantService) error {
+		created, err := usecase.CreateTenant(context.Background(), &models.Tenant{ID: id, Name: name, Active: true})
+		if err != nil {
+			return err
+		}
+
+		util.Log.WithField("context", "tenant").Infof("Tenant '%s' created", created.ID)
+		return nil
+	})
+}
+
+//ListTenant func write the tenants as a table
+func (app *Application) ListTenant(w io.Writer) error {
+	return app.withTenantService(func(usecase tenantService.TenantService) error {
+		list, err := usecase.ListTenant(context.Background())
+		if err != nil {
+			return err
+		}
+
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tNAME\tACTIVE\tCREATED AT")
+		for _, t := range list {
+			fmt.Fprintf(table, "%s\t%s\t%t\t%s\n", t.ID, t.Name, t.Active, t.CreatedAt.Format(time.RFC3339))
+		}
+		return table.Flush()
+	})
+}
+
+//SetTenantActive func enable or disable a tenant, the running instances notice it once their cache expires
+func (app *Application) SetTenantActive(id string, active bool) error {
+	return app.withTenantService(func(usecase tenantService.TenantService) error {
+		if err := usecase.SetTenantActive(context.Background(), id, active); err != nil {
+			return fmt.Errorf("tenant %q: %w", id, err)
+		}
+
+		util.Log.WithField("context", "tenant").Infof("Tenant '%s' active: %t", id, active)
+		return nil
+	})
+}
+
+//TenantToken func write a bearer token of the tenant and subject signed with TENANT_TOKEN_SECRET, the token
+//authenticates the subject when AUTH_TOKEN_SECRET is empty or the same secret
+func (app *Application) TenantToken(w io.Writer, id, subject string, ttl time.Duration) error {
+	config := tenant.ConfigFromEnv()
+	if len(config.TokenSecret) == 0 {
+		return errors.New("tenant token: TENANT_TOKEN_SECRET is not set")
+	}
+	if !tenant.Valid(id) {
+		return fmt.Errorf("tenant token: %q: %w", id, tenant.ErrInvalid)
+	}
+	if ttl < 0 {
+		return fmt.Errorf("tenant token: negative ttl %s", ttl)
+	}
+
+	return app.withTenantService(func(usecase tenantService.TenantService) error {
+		t, err := usecase.GetTenant(context.Background(), id)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return fmt.Errorf("tenant %q: %w", id, tenantService.ErrTenantNotFound)
+		}
+
+		claims := map[string]string{config.TokenClaim: id}
+		if subject != "" {
+			claims[auth.ConfigFromEnv().SubjectClaim] = subject
+		}
+		token, err := tenant.SignClaims(config.TokenSecret, claims, ttl)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, token)
+		return err
+	})
+}
+
+// withTenantService call fn with a tenant service on a connection closed afterwards
+func (app *Application) withTenantService(fn func(usecase tenantService.TenantService) error) error {
+	conn, err := NewDBBroker(app.postgresql).connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return fn(tenantService.NewTenantService(tenantRepository.NewTenantRepository(conn)))
+}
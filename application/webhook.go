@@ -0,0 +1,37 @@
+package application
+
+import (
+	webhookController "github.com/go-rest-api-boilerplate/server/webhook/controller"
+	webhookRepository "github.com/go-rest-api-boilerplate/server/webhook/repository"
+	webhookService "github.com/go-rest-api-boilerplate/server/webhook/service"
+	"github.com/go-rest-api-boilerplate/util/apiversion"
+)
+
+//WebhookModule struct manage the webhook subscriptions, they are delivered by the outbox relay
+type WebhookModule struct {
+	service webhookService.WebhookService
+}
+
+//NewWebhookModule func
+func NewWebhookModule() *WebhookModule {
+	return &WebhookModule{}
+}
+
+//Name func
+func (m *WebhookModule) Name() string {
+	return "webhook"
+}
+
+//Setup func
+func (m *WebhookModule) Setup(deps *Deps) error {
+	m.service = webhookService.NewWebhookService(webhookRepository.NewWebhookRepository(deps.Conn))
+	return nil
+}
+
+//Routes func
+func (m *WebhookModule) Routes(version string) []apiversion.Routes {
+	if version != "v1" {
+		return nil
+	}
+	return []apiversion.Routes{webhookController.NewWebhookRoutes(m.service)}
+}
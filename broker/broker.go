@@ -0,0 +1,18 @@
+package broker
+
+import (
+	"database/sql"
+
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/lib/pq"
+)
+
+type (
+	//Broker interface of the database shared by the modules
+	Broker interface {
+		Start() (*sql.DB, error)
+		Stop() error
+		Listener(name string) *pq.Listener
+		Router() *dbrouter.Router
+	}
+)
@@ -0,0 +1,92 @@
+BEGIN;
+
+DROP POLICY IF EXISTS webhook_deliveries_tenant_isolation ON webhook_deliveries;
+ALTER TABLE webhook_deliveries NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS book_covers_tenant_isolation ON book_covers;
+ALTER TABLE book_covers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE book_covers DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS book_authors_tenant_isolation ON book_authors;
+ALTER TABLE book_authors NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE book_authors DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS outbox_tenant_isolation ON outbox;
+ALTER TABLE outbox NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS audit_log_tenant_isolation ON audit_log;
+ALTER TABLE audit_log NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_log DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhooks_tenant_isolation ON webhooks;
+ALTER TABLE webhooks NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhooks DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS authors_tenant_isolation ON authors;
+ALTER TABLE authors NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE authors DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS books_tenant_isolation ON books;
+ALTER TABLE books NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE books DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS tenant_visible(VARCHAR);
+
+CREATE OR REPLACE FUNCTION notify_book_change() RETURNS trigger AS $$
+DECLARE
+  event_type TEXT;
+  book_id INTEGER;
+BEGIN
+  IF TG_OP = 'TRUNCATE' THEN
+    event_type := 'reset';
+  ELSIF TG_OP = 'DELETE' THEN
+    event_type := 'book.deleted';
+    book_id := OLD.id;
+  ELSIF TG_OP = 'INSERT' THEN
+    event_type := 'book.created';
+    book_id := NEW.id;
+  ELSE
+    event_type := 'book.updated';
+    book_id := NEW.id;
+  END IF;
+
+  -- the payload stays far below the 8000 bytes limit of NOTIFY
+  PERFORM pg_notify('book_changes', json_build_object(
+    'id', nextval('book_change_seq'),
+    'type', event_type,
+    'book_id', book_id)::text);
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS audit_log_tenant_id_idx;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS webhooks_tenant_id_idx;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS tenant_id;
+
+-- the names shared by several tenants no longer fit the global keys
+DELETE FROM book_authors WHERE author_id IN (SELECT id FROM authors WHERE tenant_id <> 'default');
+DELETE FROM authors WHERE tenant_id <> 'default';
+ALTER TABLE authors
+  DROP CONSTRAINT authors_name_key_key,
+  ADD CONSTRAINT authors_name_key_key UNIQUE (name_key);
+ALTER TABLE authors DROP COLUMN IF EXISTS tenant_id;
+
+DELETE FROM books WHERE tenant_id <> 'default';
+DROP INDEX IF EXISTS books_tenant_id_idx;
+ALTER TABLE books
+  DROP CONSTRAINT books_isbn13_key,
+  ADD CONSTRAINT books_isbn13_key UNIQUE (isbn13);
+DROP INDEX books_seed_key_key;
+CREATE UNIQUE INDEX books_seed_key_key ON books (seed_key);
+ALTER TABLE books DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
+
+COMMIT;
@@ -0,0 +1,125 @@
+BEGIN;
+
+CREATE TABLE tenants (
+ id VARCHAR (63) PRIMARY KEY CHECK (id ~ '^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$'),
+ name VARCHAR (255) NOT NULL,
+ active BOOLEAN NOT NULL DEFAULT TRUE,
+ updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the existing catalog becomes the catalog of the default tenant
+INSERT INTO tenants (id, name) VALUES ('default', 'Default');
+
+ALTER TABLE books ADD COLUMN tenant_id VARCHAR (63) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE books ALTER COLUMN tenant_id DROP DEFAULT;
+
+DROP INDEX books_seed_key_key;
+CREATE UNIQUE INDEX books_seed_key_key ON books (tenant_id, seed_key);
+ALTER TABLE books
+  DROP CONSTRAINT books_isbn13_key,
+  ADD CONSTRAINT books_isbn13_key UNIQUE (tenant_id, isbn13);
+CREATE INDEX books_tenant_id_idx ON books (tenant_id, id);
+
+ALTER TABLE authors ADD COLUMN tenant_id VARCHAR (63) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE authors ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE authors
+  DROP CONSTRAINT authors_name_key_key,
+  ADD CONSTRAINT authors_name_key_key UNIQUE (tenant_id, name_key);
+
+ALTER TABLE webhooks ADD COLUMN tenant_id VARCHAR (63) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE webhooks ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX webhooks_tenant_id_idx ON webhooks (tenant_id, id);
+
+-- system entries and events have no tenant
+ALTER TABLE audit_log ADD COLUMN tenant_id VARCHAR (63) REFERENCES tenants (id);
+UPDATE audit_log SET tenant_id = 'default';
+CREATE INDEX audit_log_tenant_id_idx ON audit_log (tenant_id, id DESC);
+
+ALTER TABLE outbox ADD COLUMN tenant_id VARCHAR (63) REFERENCES tenants (id);
+UPDATE outbox SET tenant_id = 'default';
+
+-- the stream subscribers only receive the changes of their tenant
+CREATE OR REPLACE FUNCTION notify_book_change() RETURNS trigger AS $$
+DECLARE
+  event_type TEXT;
+  book_id INTEGER;
+  tenant_id VARCHAR;
+BEGIN
+  IF TG_OP = 'TRUNCATE' THEN
+    event_type := 'reset';
+  ELSIF TG_OP = 'DELETE' THEN
+    event_type := 'book.deleted';
+    book_id := OLD.id;
+    tenant_id := OLD.tenant_id;
+  ELSIF TG_OP = 'INSERT' THEN
+    event_type := 'book.created';
+    book_id := NEW.id;
+    tenant_id := NEW.tenant_id;
+  ELSE
+    event_type := 'book.updated';
+    book_id := NEW.id;
+    tenant_id := NEW.tenant_id;
+  END IF;
+
+  -- the payload stays far below the 8000 bytes limit of NOTIFY
+  PERFORM pg_notify('book_changes', json_build_object(
+    'id', nextval('book_change_seq'),
+    'type', event_type,
+    'book_id', book_id,
+    'tenant_id', tenant_id)::text);
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Row-level security back the scoping of the repositories. The application sets app.tenant_id
+-- in its transactions when TENANT_RLS is on, a session without tenant (migrations, the outbox
+-- relay, the tenant commands) sees every row
+CREATE FUNCTION tenant_visible(row_tenant_id VARCHAR) RETURNS BOOLEAN AS $$
+  SELECT COALESCE(current_setting('app.tenant_id', true), '') IN ('', row_tenant_id);
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE books ENABLE ROW LEVEL SECURITY;
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+CREATE POLICY books_tenant_isolation ON books
+  USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+ALTER TABLE authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE authors FORCE ROW LEVEL SECURITY;
+CREATE POLICY authors_tenant_isolation ON authors
+  USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhooks_tenant_isolation ON webhooks
+  USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log FORCE ROW LEVEL SECURITY;
+CREATE POLICY audit_log_tenant_isolation ON audit_log
+  USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+ALTER TABLE outbox ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox FORCE ROW LEVEL SECURITY;
+CREATE POLICY outbox_tenant_isolation ON outbox
+  USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+-- the rows of the child tables follow their parent, whose policy applies in the subquery
+ALTER TABLE book_authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_authors FORCE ROW LEVEL SECURITY;
+CREATE POLICY book_authors_tenant_isolation ON book_authors
+  USING (EXISTS (SELECT 1 FROM books WHERE books.id = book_id));
+
+ALTER TABLE book_covers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_covers FORCE ROW LEVEL SECURITY;
+CREATE POLICY book_covers_tenant_isolation ON book_covers
+  USING (EXISTS (SELECT 1 FROM books WHERE books.id = book_id));
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhook_deliveries_tenant_isolation ON webhook_deliveries
+  USING (EXISTS (SELECT 1 FROM webhooks WHERE webhooks.id = webhook_id));
+
+COMMIT;
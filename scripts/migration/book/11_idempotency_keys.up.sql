@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE idempotency_keys (
+ tenant_id VARCHAR (63) NOT NULL REFERENCES tenants (id),
+ idempotency_key VARCHAR (255) NOT NULL,
+ fingerprint CHAR (64) NOT NULL,
+ status VARCHAR (16) NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'completed')),
+ response_status INTEGER,
+ response_header JSONB,
+ response_body BYTEA,
+ locked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ expires_at TIMESTAMP NOT NULL,
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ PRIMARY KEY (tenant_id, idempotency_key)
+);
+
+-- the expired keys are purged periodically
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE idempotency_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY idempotency_keys_tenant_isolation ON idempotency_keys
+  USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION tenant_visible(row_tenant_id VARCHAR) RETURNS BOOLEAN AS $$
+  SELECT COALESCE(current_setting('app.tenant_id', true), '') IN ('', row_tenant_id);
+$$ LANGUAGE sql STABLE;
+
+-- the role may be used by the other databases of the cluster, only its privileges here are revoked
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE ALL ON SEQUENCES FROM tenant_bypass;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE ALL ON TABLES FROM tenant_bypass;
+REVOKE ALL ON ALL SEQUENCES IN SCHEMA public FROM tenant_bypass;
+REVOKE ALL ON ALL TABLES IN SCHEMA public FROM tenant_bypass;
+REVOKE USAGE ON SCHEMA public FROM tenant_bypass;
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- The shared jobs (seed, tenant commands, outbox relay, webhook dispatcher, purges) and the API without
+-- TENANT_RLS connect with this role and see the rows of every tenant. Roles belong to the cluster, the
+-- migrating user needs CREATEROLE the first time
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'tenant_bypass') THEN
+    CREATE ROLE tenant_bypass NOLOGIN;
+  END IF;
+END
+$$;
+GRANT tenant_bypass TO CURRENT_USER;
+
+GRANT USAGE ON SCHEMA public TO tenant_bypass;
+GRANT SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER ON ALL TABLES IN SCHEMA public TO tenant_bypass;
+GRANT USAGE, SELECT, UPDATE ON ALL SEQUENCES IN SCHEMA public TO tenant_bypass;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+  GRANT SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER ON TABLES TO tenant_bypass;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT, UPDATE ON SEQUENCES TO tenant_bypass;
+
+-- a session without app.tenant_id sees no row, the data migrations SET LOCAL ROLE tenant_bypass
+CREATE OR REPLACE FUNCTION tenant_visible(row_tenant_id VARCHAR) RETURNS BOOLEAN AS $$
+  SELECT COALESCE(current_setting('app.tenant_id', true) = row_tenant_id, false)
+    OR current_user = 'tenant_bypass';
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
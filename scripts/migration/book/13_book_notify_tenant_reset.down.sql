@@ -0,0 +1,37 @@
+BEGIN;
+
+-- a truncation is notified once without tenant
+CREATE OR REPLACE FUNCTION notify_book_change() RETURNS trigger AS $$
+DECLARE
+  event_type TEXT;
+  book_id INTEGER;
+  tenant_id VARCHAR;
+BEGIN
+  IF TG_OP = 'TRUNCATE' THEN
+    event_type := 'reset';
+  ELSIF TG_OP = 'DELETE' THEN
+    event_type := 'book.deleted';
+    book_id := OLD.id;
+    tenant_id := OLD.tenant_id;
+  ELSIF TG_OP = 'INSERT' THEN
+    event_type := 'book.created';
+    book_id := NEW.id;
+    tenant_id := NEW.tenant_id;
+  ELSE
+    event_type := 'book.updated';
+    book_id := NEW.id;
+    tenant_id := NEW.tenant_id;
+  END IF;
+
+  -- the payload stays far below the 8000 bytes limit of NOTIFY
+  PERFORM pg_notify('book_changes', json_build_object(
+    'id', nextval('book_change_seq'),
+    'type', event_type,
+    'book_id', book_id,
+    'tenant_id', tenant_id)::text);
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
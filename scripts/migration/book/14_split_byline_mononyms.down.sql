@@ -0,0 +1,13 @@
+BEGIN;
+
+-- the books credited again are kept, the bylines are only read as before from now on
+CREATE OR REPLACE FUNCTION split_byline(byline TEXT) RETURNS TABLE (author_name TEXT, author_position BIGINT) AS $$
+  SELECT btrim(n.name), row_number() OVER (ORDER BY p.ordinality, n.ordinality) - 1
+    FROM regexp_split_to_table(btrim(byline), '\s*(?:;|&|\s+and\s+)\s*') WITH ORDINALITY AS p (part, ordinality)
+   CROSS JOIN LATERAL regexp_split_to_table(
+           regexp_replace(btrim(p.part), '^([^[:space:],]+)\s*,\s*([^,]+)$', '\2 \1'),
+           '\s*,\s*') WITH ORDINALITY AS n (name, ordinality)
+   WHERE regexp_replace(n.name, '[^[:alnum:]]', '', 'g') <> ''
+$$ LANGUAGE sql IMMUTABLE;
+
+COMMIT;
@@ -0,0 +1,77 @@
+BEGIN;
+
+-- split_byline return one author per name of a byline, it must match models.SplitByline: ";", "&" and
+-- "and" separate people, and the commas unless the part reads "Surname, Given" with several words before
+-- its only comma and one after it. A single word on each side like "Plato, Aristotle" is two people
+CREATE OR REPLACE FUNCTION split_byline(byline TEXT) RETURNS TABLE (author_name TEXT, author_position BIGINT) AS $$
+  SELECT btrim(n.name), row_number() OVER (ORDER BY p.ordinality, n.ordinality) - 1
+    FROM regexp_split_to_table(btrim(byline), '\s*(?:;|&|\s+and\s+)\s*') WITH ORDINALITY AS p (part, ordinality)
+   CROSS JOIN LATERAL regexp_split_to_table(
+           regexp_replace(btrim(p.part), '^([^,]+\s[^,]*[^[:space:],])\s*,\s*([^[:space:],]+)$', '\2 \1'),
+           '\s*,\s*') WITH ORDINALITY AS n (name, ordinality)
+   WHERE regexp_replace(n.name, '[^[:alnum:]]', '', 'g') <> ''
+$$ LANGUAGE sql IMMUTABLE;
+
+-- split_byline_previous is the split of 4_authors, it read a single word before the only comma as a surname
+CREATE FUNCTION split_byline_previous(byline TEXT) RETURNS TABLE (author_name TEXT, author_position BIGINT) AS $$
+  SELECT btrim(n.name), row_number() OVER (ORDER BY p.ordinality, n.ordinality) - 1
+    FROM regexp_split_to_table(btrim(byline), '\s*(?:;|&|\s+and\s+)\s*') WITH ORDINALITY AS p (part, ordinality)
+   CROSS JOIN LATERAL regexp_split_to_table(
+           regexp_replace(btrim(p.part), '^([^[:space:],]+)\s*,\s*([^,]+)$', '\2 \1'),
+           '\s*,\s*') WITH ORDINALITY AS n (name, ordinality)
+   WHERE regexp_replace(n.name, '[^[:alnum:]]', '', 'g') <> ''
+$$ LANGUAGE sql IMMUTABLE;
+
+-- the rows of every tenant are credited again
+SET LOCAL ROLE tenant_bypass;
+
+-- the books read differently, still credited with the authors of the previous split. The books credited
+-- through their authors since are kept
+CREATE TEMPORARY TABLE byline_resplit ON COMMIT DROP AS
+SELECT b.id AS book_id, b.tenant_id, b.author
+  FROM books b
+ WHERE ARRAY(SELECT lower(regexp_replace(s.author_name, '[^[:alnum:]]', '', 'g'))
+               FROM split_byline(b.author) AS s ORDER BY s.author_position)
+    <> ARRAY(SELECT lower(regexp_replace(s.author_name, '[^[:alnum:]]', '', 'g'))
+               FROM split_byline_previous(b.author) AS s ORDER BY s.author_position)
+   AND ARRAY(SELECT DISTINCT a.name_key
+               FROM book_authors ba
+               JOIN authors a ON a.id = ba.author_id
+              WHERE ba.book_id = b.id AND ba.role = 'author' ORDER BY 1)
+     = ARRAY(SELECT DISTINCT lower(regexp_replace(s.author_name, '[^[:alnum:]]', '', 'g'))
+               FROM split_byline_previous(b.author) AS s ORDER BY 1);
+
+-- the authors of the previous split, removed once no book credit them
+CREATE TEMPORARY TABLE byline_previous_authors ON COMMIT DROP AS
+SELECT DISTINCT ba.author_id
+  FROM book_authors ba
+  JOIN byline_resplit r ON r.book_id = ba.book_id
+ WHERE ba.role = 'author';
+
+DELETE FROM book_authors ba
+ USING byline_resplit r
+ WHERE ba.book_id = r.book_id AND ba.role = 'author';
+
+INSERT INTO authors (name, tenant_id)
+SELECT s.author_name, r.tenant_id
+  FROM byline_resplit r, split_byline(r.author) AS s
+ON CONFLICT (tenant_id, name_key) DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT r.book_id, a.id, 'author', min(s.author_position)
+  FROM byline_resplit r
+ CROSS JOIN LATERAL split_byline(r.author) AS s
+  JOIN authors a ON a.tenant_id = r.tenant_id
+   AND a.name_key = lower(regexp_replace(s.author_name, '[^[:alnum:]]', '', 'g'))
+ GROUP BY r.book_id, a.id;
+
+DELETE FROM authors a
+ USING byline_previous_authors p
+ WHERE a.id = p.author_id
+   AND NOT EXISTS (SELECT 1 FROM book_authors ba WHERE ba.author_id = a.id);
+
+RESET ROLE;
+
+DROP FUNCTION split_byline_previous(TEXT);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS books_seed_key_key;
+ALTER TABLE books DROP COLUMN IF EXISTS seed_key;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- seed_key identify the books loaded by db-seed, the books created through the API have none
+ALTER TABLE books ADD COLUMN seed_key VARCHAR (64);
+
+CREATE UNIQUE INDEX books_seed_key_key ON books (seed_key);
+
+COMMIT;
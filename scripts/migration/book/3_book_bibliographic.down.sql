@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE books
+  DROP CONSTRAINT IF EXISTS books_isbn13_key,
+  DROP COLUMN IF EXISTS isbn10,
+  DROP COLUMN IF EXISTS isbn13,
+  DROP COLUMN IF EXISTS subtitle,
+  DROP COLUMN IF EXISTS publisher,
+  DROP COLUMN IF EXISTS published_date,
+  DROP COLUMN IF EXISTS language,
+  DROP COLUMN IF EXISTS page_count,
+  DROP COLUMN IF EXISTS edition,
+  DROP COLUMN IF EXISTS description,
+  DROP COLUMN IF EXISTS format;
+
+COMMIT;
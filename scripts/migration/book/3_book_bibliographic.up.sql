@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE books
+  ADD COLUMN isbn10 CHAR (10),
+  ADD COLUMN isbn13 CHAR (13),
+  ADD COLUMN subtitle VARCHAR (255) NOT NULL DEFAULT '',
+  ADD COLUMN publisher VARCHAR (255) NOT NULL DEFAULT '',
+  ADD COLUMN published_date DATE,
+  ADD COLUMN language VARCHAR (35) NOT NULL DEFAULT '',
+  ADD COLUMN page_count INTEGER CHECK (page_count > 0),
+  ADD COLUMN edition VARCHAR (64) NOT NULL DEFAULT '',
+  ADD COLUMN description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN format VARCHAR (32) NOT NULL DEFAULT '',
+  ADD CONSTRAINT books_isbn13_key UNIQUE (isbn13);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS authors;
+DROP FUNCTION IF EXISTS split_byline(TEXT);
+
+COMMIT;
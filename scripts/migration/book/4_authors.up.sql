@@ -0,0 +1,46 @@
+BEGIN;
+
+CREATE TABLE authors (
+ id serial PRIMARY KEY,
+ name VARCHAR (255) NOT NULL,
+ name_key VARCHAR (255) GENERATED ALWAYS AS (lower(regexp_replace(name, '[^[:alnum:]]', '', 'g'))) STORED,
+ biography TEXT NOT NULL DEFAULT '',
+ updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ CONSTRAINT authors_name_key_key UNIQUE (name_key)
+);
+
+CREATE TABLE book_authors (
+ book_id INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+ author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+ role VARCHAR (16) NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+ position INTEGER NOT NULL DEFAULT 0,
+ PRIMARY KEY (book_id, author_id, role)
+);
+
+CREATE INDEX book_authors_author_id_idx ON book_authors (author_id);
+
+-- split_byline return one author per name of a byline, it must match models.SplitByline: ";", "&" and
+-- "and" separate people, and the commas unless the part reads "Surname, Given"
+CREATE FUNCTION split_byline(byline TEXT) RETURNS TABLE (author_name TEXT, author_position BIGINT) AS $$
+  SELECT btrim(n.name), row_number() OVER (ORDER BY p.ordinality, n.ordinality) - 1
+    FROM regexp_split_to_table(btrim(byline), '\s*(?:;|&|\s+and\s+)\s*') WITH ORDINALITY AS p (part, ordinality)
+   CROSS JOIN LATERAL regexp_split_to_table(
+           regexp_replace(btrim(p.part), '^([^[:space:],]+)\s*,\s*([^,]+)$', '\2 \1'),
+           '\s*,\s*') WITH ORDINALITY AS n (name, ordinality)
+   WHERE regexp_replace(n.name, '[^[:alnum:]]', '', 'g') <> ''
+$$ LANGUAGE sql IMMUTABLE;
+
+INSERT INTO authors (name)
+SELECT s.author_name
+  FROM books, split_byline(books.author) AS s
+ON CONFLICT (name_key) DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT b.id, a.id, 'author', min(s.author_position)
+  FROM books b
+ CROSS JOIN LATERAL split_byline(b.author) AS s
+  JOIN authors a ON a.name_key = lower(regexp_replace(s.author_name, '[^[:alnum:]]', '', 'g'))
+ GROUP BY b.id, a.id;
+
+COMMIT;
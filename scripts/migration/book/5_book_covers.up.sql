@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE book_covers (
+ book_id INTEGER PRIMARY KEY REFERENCES books (id) ON DELETE CASCADE,
+ content_type VARCHAR (32) NOT NULL,
+ width INTEGER NOT NULL,
+ height INTEGER NOT NULL,
+ size BIGINT NOT NULL,
+ etag VARCHAR (64) NOT NULL,
+ updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
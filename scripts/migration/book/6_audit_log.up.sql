@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE audit_log (
+ id BIGSERIAL PRIMARY KEY,
+ actor VARCHAR (255) NOT NULL,
+ claimed_actor VARCHAR (255) NOT NULL DEFAULT '',
+ request_id VARCHAR (64) NOT NULL DEFAULT '',
+ action VARCHAR (16) NOT NULL,
+ entity VARCHAR (64) NOT NULL,
+ entity_id VARCHAR (64) NOT NULL DEFAULT '',
+ diff JSONB NOT NULL DEFAULT '{}',
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, id DESC);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, id DESC);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+COMMIT;
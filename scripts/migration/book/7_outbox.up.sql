@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TABLE outbox (
+ id BIGSERIAL PRIMARY KEY,
+ event_type VARCHAR (64) NOT NULL,
+ aggregate_type VARCHAR (64) NOT NULL,
+ aggregate_id VARCHAR (64) NOT NULL,
+ schema_version INTEGER NOT NULL,
+ request_id VARCHAR (64) NOT NULL DEFAULT '',
+ payload JSONB NOT NULL,
+ status VARCHAR (16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+ attempts INTEGER NOT NULL DEFAULT 0,
+ -- bitmask of the publishers of a MultiPublisher having the event, a retry skips them
+ published INTEGER NOT NULL DEFAULT 0,
+ last_error TEXT,
+ next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ sent_at TIMESTAMP
+);
+
+-- the relay only reads pending events
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX outbox_pending_aggregate_idx ON outbox (aggregate_type, aggregate_id, id) WHERE status = 'pending';
+CREATE INDEX outbox_dead_idx ON outbox (id) WHERE status = 'dead';
+
+COMMIT;
@@ -0,0 +1,39 @@
+BEGIN;
+
+CREATE TABLE webhooks (
+ id SERIAL PRIMARY KEY,
+ url VARCHAR (2048) NOT NULL,
+ events TEXT[] NOT NULL,
+ secret VARCHAR (128) NOT NULL,
+ active BOOLEAN NOT NULL DEFAULT TRUE,
+ max_concurrency INTEGER NOT NULL DEFAULT 4 CHECK (max_concurrency > 0),
+ consecutive_failures INTEGER NOT NULL DEFAULT 0,
+ disabled_at TIMESTAMP,
+ updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_deliveries (
+ id BIGSERIAL PRIMARY KEY,
+ webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+ event_id BIGINT NOT NULL,
+ event_type VARCHAR (64) NOT NULL,
+ payload JSONB NOT NULL,
+ status VARCHAR (16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+ attempts INTEGER NOT NULL DEFAULT 0,
+ response_code INTEGER,
+ response_body TEXT,
+ error TEXT,
+ duration_ms INTEGER,
+ next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ delivered_at TIMESTAMP,
+ updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ -- an event published twice by the outbox relay is delivered once
+ CONSTRAINT webhook_deliveries_event_key UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, id DESC);
+
+COMMIT;
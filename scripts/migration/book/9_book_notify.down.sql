@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS books_notify_truncate ON books;
+DROP TRIGGER IF EXISTS books_notify_change ON books;
+DROP FUNCTION IF EXISTS notify_book_change();
+DROP SEQUENCE IF EXISTS book_change_seq;
+
+COMMIT;
@@ -0,0 +1,42 @@
+BEGIN;
+
+-- a global id lets a client resume its stream on any instance
+CREATE SEQUENCE book_change_seq;
+
+CREATE FUNCTION notify_book_change() RETURNS trigger AS $$
+DECLARE
+  event_type TEXT;
+  book_id INTEGER;
+BEGIN
+  IF TG_OP = 'TRUNCATE' THEN
+    event_type := 'reset';
+  ELSIF TG_OP = 'DELETE' THEN
+    event_type := 'book.deleted';
+    book_id := OLD.id;
+  ELSIF TG_OP = 'INSERT' THEN
+    event_type := 'book.created';
+    book_id := NEW.id;
+  ELSE
+    event_type := 'book.updated';
+    book_id := NEW.id;
+  END IF;
+
+  -- the payload stays far below the 8000 bytes limit of NOTIFY
+  PERFORM pg_notify('book_changes', json_build_object(
+    'id', nextval('book_change_seq'),
+    'type', event_type,
+    'book_id', book_id)::text);
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER books_notify_change
+  AFTER INSERT OR UPDATE OR DELETE ON books
+  FOR EACH ROW EXECUTE PROCEDURE notify_book_change();
+
+CREATE TRIGGER books_notify_truncate
+  AFTER TRUNCATE ON books
+  FOR EACH STATEMENT EXECUTE PROCEDURE notify_book_change();
+
+COMMIT;
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/go-rest-api-boilerplate/server/audit/models"
+	"github.com/go-rest-api-boilerplate/server/audit/service"
+	"github.com/labstack/echo/v4"
+)
+
+//InitAuditController struct
+type InitAuditController struct {
+	Service *InitAuditServiceInterface
+}
+
+//InitAuditServiceInterface struct
+type InitAuditServiceInterface struct {
+	Audit service.AuditService
+}
+
+//NewAuditRoutes func
+func NewAuditRoutes(auditService service.AuditService) func(g *echo.Group) {
+	auditServer := &InitAuditController{
+		Service: &InitAuditServiceInterface{
+			Audit: auditService,
+		},
+	}
+
+	return func(g *echo.Group) {
+		g.GET("/audit", auditServer.GetListAudit)
+	}
+}
+
+// GetListAudit godoc
+// @Summary Get the audit log
+// @Description Get recorded mutations, newest first
+// @Tags audit
+// @Accept */*
+// @Produce json
+// @Param entity query string false "Entity name" example(book)
+// @Param id query string false "Entity ID"
+// @Param actor query string false "Actor"
+// @Param action query string false "Action" Enums(create, update, delete, import, seed)
+// @Param request_id query string false "Request ID"
+// @Param from query string false "Recorded at or after, RFC 3339" format(date-time)
+// @Param to query string false "Recorded before, RFC 3339" format(date-time)
+// @Param page query integer false "Page, starting at 1"
+// @Param per_page query integer false "Entries per page, at most 200"
+// @Success 200 {object} models.SuccessResponseList
+// @Failure 400 {object} models.SuccessResponseList
+// @Failure 500 {object} models.SuccessResponseList
+// @Router /audit [get]
+// GetListAudit func
+func (init *InitAuditController) GetListAudit(ctx echo.Context) error {
+	filter := new(models.EntryFilter)
+	err := (&echo.DefaultBinder{}).BindQueryParams(ctx, filter)
+	if err != nil {
+		data := &models.SuccessResponseList{
+			Status:  400,
+			Message: err.Error(),
+			Data:    make([]*models.Entry, 0),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	entries, pagination, err := init.Service.Audit.ListAudit(ctx.Request().Context(), filter)
+	if err != nil {
+		data := &models.SuccessResponseList{
+			Status:  500,
+			Message: "failed",
+			Data:    make([]*models.Entry, 0),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseList{
+		Status:     200,
+		Message:    "success",
+		Data:       entries,
+		Pagination: pagination,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
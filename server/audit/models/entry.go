@@ -0,0 +1,86 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Pagination defaults of the audit log
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 200
+)
+
+// Entry is a recorded mutation of an entity
+type Entry struct {
+	ID    int64  `json:"id"`
+	Actor string `json:"actor"`
+	// ClaimedActor is the X-Actor header of the request, it is not verified
+	ClaimedActor string          `json:"claimed_actor"`
+	RequestID    string          `json:"request_id"`
+	Action       string          `json:"action" enums:"create,update,delete,import,seed"`
+	Entity       string          `json:"entity" example:"book"`
+	EntityID     string          `json:"entity_id" example:"1"`
+	Diff         json.RawMessage `json:"diff" swaggertype:"object"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// EntryFilter is the list query of the audit log
+type EntryFilter struct {
+	Entity    string    `query:"entity"`
+	EntityID  string    `query:"id"`
+	Actor     string    `query:"actor"`
+	Action    string    `query:"action"`
+	RequestID string    `query:"request_id"`
+	From      time.Time `query:"from"`
+	To        time.Time `query:"to"`
+	Page      uint64    `query:"page"`
+	PerPage   uint64    `query:"per_page"`
+}
+
+// Pagination of a list response
+type Pagination struct {
+	Page    uint64 `json:"page"`
+	PerPage uint64 `json:"per_page"`
+	Total   int64  `json:"total"`
+}
+
+// SuccessResponseList struct
+type SuccessResponseList struct {
+	Status     int64       `json:"status"`
+	Message    string      `json:"message"`
+	Data       []*Entry    `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+//ScanEntry func
+func ScanEntry(rows *sql.Rows) (*Entry, error) {
+	var entry Entry
+	var diff []byte
+	err := rows.Scan(&entry.ID, &entry.Actor, &entry.ClaimedActor, &entry.RequestID, &entry.Action, &entry.Entity,
+		&entry.EntityID, &diff, &entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	entry.Diff = diff
+	return &entry, nil
+}
+
+// Normalize default and clamp the pagination
+func (f *EntryFilter) Normalize() {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.PerPage == 0 {
+		f.PerPage = DefaultPerPage
+	}
+	if f.PerPage > MaxPerPage {
+		f.PerPage = MaxPerPage
+	}
+}
+
+// Offset of the first entry of the page
+func (f *EntryFilter) Offset() uint64 {
+	return (f.Page - 1) * f.PerPage
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/server/audit/models"
+	"github.com/go-rest-api-boilerplate/util/audit"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+)
+
+// AuditRepository to get audit entries from database
+type AuditRepository interface {
+	List(ctx context.Context, filter *models.EntryFilter) ([]*models.Entry, int64, error)
+}
+
+//InitAuditRepository struct
+type InitAuditRepository struct {
+	connection *sql.DB
+}
+
+// NewAuditRepository return new instance of AuditRepository
+func NewAuditRepository(connection *sql.DB) AuditRepository {
+	return &InitAuditRepository{
+		connection: connection,
+	}
+}
+
+func filterWhere(builder sq.SelectBuilder, tenantID string, filter *models.EntryFilter) sq.SelectBuilder {
+	builder = builder.Where(sq.Eq{audit.TenantIDColumn: tenantID})
+	if filter.Entity != "" {
+		builder = builder.Where(sq.Eq{audit.EntityColumn: filter.Entity})
+	}
+	if filter.EntityID != "" {
+		builder = builder.Where(sq.Eq{audit.EntityIDColumn: filter.EntityID})
+	}
+	if filter.Actor != "" {
+		builder = builder.Where(sq.Eq{audit.ActorColumn: filter.Actor})
+	}
+	if filter.Action != "" {
+		builder = builder.Where(sq.Eq{audit.ActionColumn: filter.Action})
+	}
+	if filter.RequestID != "" {
+		builder = builder.Where(sq.Eq{audit.RequestIDColumn: filter.RequestID})
+	}
+	if !filter.From.IsZero() {
+		builder = builder.Where(sq.GtOrEq{audit.CreatedAtColumn: filter.From})
+	}
+	if !filter.To.IsZero() {
+		builder = builder.Where(sq.Lt{audit.CreatedAtColumn: filter.To})
+	}
+	return builder
+}
+
+//List func
+func (init *InitAuditRepository) List(ctx context.Context, filter *models.EntryFilter) (list []*models.Entry, total int64, err error) {
+	list = make([]*models.Entry, 0)
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return list, total, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	err = filterWhere(psql.Select("count(*)").From(audit.Table), tenantID, filter).
+		RunWith(init.connection).
+		QueryRowContext(ctx).
+		Scan(&total)
+	if err != nil || total == 0 {
+		return list, total, err
+	}
+
+	builder := filterWhere(psql.Select(audit.Columns...).From(audit.Table), tenantID, filter).
+		OrderBy(audit.IDColumn + " DESC").
+		Limit(filter.PerPage).
+		Offset(filter.Offset())
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return list, total, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry *models.Entry
+		entry, err = models.ScanEntry(rows)
+		if err != nil {
+			return
+		}
+		list = append(list, entry)
+	}
+
+	return list, total, rows.Err()
+}
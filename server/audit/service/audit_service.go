@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-rest-api-boilerplate/server/audit/models"
+	"github.com/go-rest-api-boilerplate/server/audit/repository"
+)
+
+//AuditService interface
+type AuditService interface {
+	ListAudit(ctx context.Context, filter *models.EntryFilter) ([]*models.Entry, *models.Pagination, error)
+}
+
+//InitAuditService struct
+type InitAuditService struct {
+	Repository *InitAuditRepositoryInterface
+}
+
+//InitAuditRepositoryInterface struct
+type InitAuditRepositoryInterface struct {
+	Audit repository.AuditRepository
+}
+
+// NewAuditService return new instance of AuditService
+func NewAuditService(auditRepository repository.AuditRepository) AuditService {
+	return &InitAuditService{
+		Repository: &InitAuditRepositoryInterface{
+			Audit: auditRepository,
+		},
+	}
+}
+
+//ListAudit func
+func (init *InitAuditService) ListAudit(ctx context.Context, filter *models.EntryFilter) ([]*models.Entry, *models.Pagination, error) {
+	filter.Normalize()
+
+	entries, total, err := init.Repository.Audit.List(ctx, filter)
+
+	pagination := &models.Pagination{
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+		Total:   total,
+	}
+
+	return entries, pagination, err
+}
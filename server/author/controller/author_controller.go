@@ -0,0 +1,348 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-rest-api-boilerplate/server/author/models"
+	"github.com/go-rest-api-boilerplate/server/author/service"
+	bookModels "github.com/go-rest-api-boilerplate/server/book/models"
+	bookService "github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/go-rest-api-boilerplate/util/validation"
+	"github.com/labstack/echo/v4"
+)
+
+//InitAuthorController struct
+type InitAuthorController struct {
+	Service *InitAuthorServiceInterface
+}
+
+//InitAuthorServiceInterface struct
+type InitAuthorServiceInterface struct {
+	Author service.AuthorService
+	Book   bookService.BookService
+}
+
+//NewAuthorRoutes func
+func NewAuthorRoutes(authorService service.AuthorService, bookService bookService.BookService) func(g *echo.Group) {
+	authorServer := &InitAuthorController{
+		Service: &InitAuthorServiceInterface{
+			Author: authorService,
+			Book:   bookService,
+		},
+	}
+
+	return func(g *echo.Group) {
+		g.GET("/author", authorServer.GetListAuthor)
+		g.GET("/author/:id", authorServer.GetAuthor)
+		g.GET("/author/:id/books", authorServer.GetAuthorBooks)
+		g.POST("/author", authorServer.CreateAuthor)
+		g.PUT("/author", authorServer.UpdateAuthor)
+		g.DELETE("/author/:id", authorServer.DeleteAuthor)
+	}
+}
+
+// GetListAuthor godoc
+// @Summary Get list of author
+// @Description Get list of author item
+// @Tags author
+// @Accept */*
+// @Produce json
+// @Param name query string false "Name contains"
+// @Success 200 {object} models.SuccessResponseList
+// @Failure 500 {object} models.SuccessResponseList
+// @Router /author [get]
+// GetListAuthor func
+func (init *InitAuthorController) GetListAuthor(ctx echo.Context) error {
+	filter := new(models.AuthorFilter)
+	err := (&echo.DefaultBinder{}).BindQueryParams(ctx, filter)
+	if err != nil {
+		data := &models.SuccessResponseList{
+			Status:  400,
+			Message: err.Error(),
+			Data:    make([]*models.Author, 0),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	authors, err := init.Service.Author.ListAuthor(ctx.Request().Context(), filter)
+	if err != nil {
+		data := &models.SuccessResponseList{
+			Status:  500,
+			Message: "failed",
+			Data:    make([]*models.Author, 0),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseList{
+		Status:  200,
+		Message: "success",
+		Data:    authors,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// GetAuthor godoc
+// @Summary Get an author
+// @Description Get an author item
+// @Tags author
+// @Accept */*
+// @Produce json
+// @Param id path integer true "Author ID"
+// @Success 200 {object} models.SuccessResponseObject
+// @Failure 500 {object} models.SuccessResponseObject
+// @Router /author/{id} [get]
+// GetAuthor func
+func (init *InitAuthorController) GetAuthor(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	author, err := init.Service.Author.GetAuthor(ctx.Request().Context(), id)
+	if err != nil {
+		data := &models.SuccessResponseObject{
+			Status:  500,
+			Message: "failed",
+			Data:    new(models.Author),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseObject{
+		Status:  200,
+		Message: "success",
+		Data:    author,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// GetAuthorBooks godoc
+// @Summary Get books of an author
+// @Description Get every book crediting the author, in any role
+// @Tags author
+// @Accept */*
+// @Produce json
+// @Param id path integer true "Author ID"
+// @Success 200 {object} bookModels.SuccessResponseList
+// @Failure 404 {object} bookModels.SuccessResponseList
+// @Failure 500 {object} bookModels.SuccessResponseList
+// @Router /author/{id}/books [get]
+// GetAuthorBooks func
+func (init *InitAuthorController) GetAuthorBooks(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	author, err := init.Service.Author.GetAuthor(ctx.Request().Context(), id)
+	if err == nil && author == nil {
+		data := &bookModels.SuccessResponseList{
+			Status:  404,
+			Message: "author not found",
+			Data:    make([]*bookModels.Book, 0),
+		}
+
+		return ctx.JSON(http.StatusNotFound, data)
+	}
+
+	var books []*bookModels.Book
+	if err == nil {
+		books, err = init.Service.Book.ListBook(ctx.Request().Context(), &bookModels.BookFilter{AuthorID: id})
+	}
+	if err != nil {
+		data := &bookModels.SuccessResponseList{
+			Status:  500,
+			Message: "failed",
+			Data:    make([]*bookModels.Book, 0),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &bookModels.SuccessResponseList{
+		Status:  200,
+		Message: "success",
+		Data:    books,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// CreateAuthor godoc
+// @Summary Create an author
+// @Description Create a new author item
+// @Tags author
+// @Accept json
+// @Produce json
+// @Param author body models.Author true "Param Author"
+// @Param Idempotency-Key header string false "Key replaying the response of a retried request"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
+// @Router /author [post]
+// CreateAuthor func
+func (init *InitAuthorController) CreateAuthor(ctx echo.Context) error {
+	var author *models.Author
+	err := ctx.Bind(&author)
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	author.Normalize()
+	err = author.Validate()
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	author, err = init.Service.Author.CreateAuthor(ctx.Request().Context(), author)
+	if errors.Is(err, service.ErrAuthorConflict) {
+		data := &models.SuccessResponse{
+			Status:  409,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusConflict, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  201,
+		Message: fmt.Sprintf("Create author success #%d", author.ID),
+	}
+
+	return ctx.JSON(http.StatusCreated, data)
+}
+
+// UpdateAuthor godoc
+// @Summary Update an author
+// @Description Update an author item, the byline of the author books follow the new name
+// @Tags author
+// @Accept json
+// @Produce json
+// @Param author body models.Author true "Param Author"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
+// @Failure 500 {object} models.SuccessResponse
+// @Router /author [put]
+// UpdateAuthor func
+func (init *InitAuthorController) UpdateAuthor(ctx echo.Context) error {
+	var author *models.Author
+	err := ctx.Bind(&author)
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	author.Normalize()
+	err = author.Validate()
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	author, err = init.Service.Author.UpdateAuthor(ctx.Request().Context(), author)
+	if errors.Is(err, service.ErrAuthorConflict) {
+		data := &models.SuccessResponse{
+			Status:  409,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusConflict, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  200,
+		Message: "success",
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// DeleteAuthor godoc
+// @Summary Delete an author
+// @Description Delete an author item, an author credited on books cannot be deleted
+// @Tags author
+// @Accept */*
+// @Produce json
+// @Param id path integer true "Author ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
+// @Failure 500 {object} models.SuccessResponse
+// @Router /author/{id} [delete]
+// DeleteAuthor func
+func (init *InitAuthorController) DeleteAuthor(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	err := init.Service.Author.DeleteAuthor(ctx.Request().Context(), id)
+	if errors.Is(err, service.ErrAuthorInUse) {
+		data := &models.SuccessResponse{
+			Status:  409,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusConflict, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: "failed",
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  200,
+		Message: "success",
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util/validation"
+)
+
+// validate is shared by every model, validator.Validate caches struct metadata
+var validate = validation.New()
+
+// Author represented database model
+type Author struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name" validate:"required,max=255"`
+	Biography string    `json:"biography"`
+	UpdatedAt time.Time `json:"-"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// AuthorFilter is the list query of author
+type AuthorFilter struct {
+	Name string `query:"name"`
+}
+
+// SuccessResponseList struct
+type SuccessResponseList struct {
+	Status  int64     `json:"status"`
+	Message string    `json:"message"`
+	Data    []*Author `json:"data"`
+}
+
+// SuccessResponseObject struct
+type SuccessResponseObject struct {
+	Status  int64   `json:"status"`
+	Message string  `json:"message"`
+	Data    *Author `json:"data"`
+}
+
+// SuccessResponse struct
+type SuccessResponse struct {
+	Status  int64             `json:"status"`
+	Message string            `json:"message"`
+	Errors  validation.Errors `json:"errors,omitempty"`
+}
+
+//ScanAuthor func
+func ScanAuthor(rows *sql.Rows) (*Author, error) {
+	var author Author
+	err := rows.Scan(&author.ID, &author.Name, &author.Biography, &author.UpdatedAt, &author.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// Validate author
+func (a *Author) Validate() error {
+	return validate.Struct(a)
+}
+
+// Normalize trim the author name
+func (a *Author) Normalize() {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Biography = strings.TrimSpace(a.Biography)
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/server/author/models"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/lib/pq"
+)
+
+// AuthorRepository to get author data from database
+type AuthorRepository interface {
+	List(ctx context.Context, filter *models.AuthorFilter) ([]*models.Author, error)
+	Find(ctx context.Context, id int64) (*models.Author, error)
+	Insert(ctx context.Context, author *models.Author) (*models.Author, error)
+	Update(ctx context.Context, author *models.Author) (*models.Author, error)
+	RefreshByline(ctx context.Context, id int64) ([]int64, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+var (
+	// ErrAuthorConflict is returned when an author with the same name exists
+	ErrAuthorConflict = errors.New("author already exists")
+	// ErrAuthorInUse is returned when deleting an author still credited on a book
+	ErrAuthorInUse = errors.New("author is credited on books")
+)
+
+// refreshBylineQuery rebuild the byline of every book of the tenant $2 crediting the author $1, joined like
+// models.Book.Byline, and return the ids of the changed books
+const refreshBylineQuery = `UPDATE books b
+   SET author = c.byline, updated_at = now()
+  FROM (SELECT ba.book_id,
+               COALESCE(string_agg(a.name, '; ' ORDER BY ba.position) FILTER (WHERE ba.role = 'author'),
+                        string_agg(a.name, '; ' ORDER BY ba.position)) AS byline
+          FROM book_authors ba
+          JOIN authors a ON a.id = ba.author_id
+         WHERE ba.book_id IN (SELECT book_id FROM book_authors WHERE author_id = $1)
+         GROUP BY ba.book_id) c
+ WHERE b.id = c.book_id
+   AND b.tenant_id = $2
+   AND b.author <> c.byline
+RETURNING b.id`
+
+//InitAuthorRepository struct
+type InitAuthorRepository struct {
+	connection *sql.DB
+}
+
+// NewAuthorRepository return new instance of AuthorRepository
+func NewAuthorRepository(connection *sql.DB) AuthorRepository {
+	return &InitAuthorRepository{
+		connection: connection,
+	}
+}
+
+//List func
+func (init *InitAuthorRepository) List(ctx context.Context, filter *models.AuthorFilter) (list []*models.Author, err error) {
+	list = make([]*models.Author, 0)
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return list, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(AuthorColumns...).
+		From(authorTable).
+		Where(sq.Eq{tenantIDColumn: tenantID}).
+		OrderBy("id ASC")
+
+	if filter != nil && filter.Name != "" {
+		builder = builder.Where(sq.ILike{authorNameColumn: "%" + filter.Name + "%"})
+	}
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var author *models.Author
+		author, err = models.ScanAuthor(rows)
+		if err != nil {
+			return
+		}
+		list = append(list, author)
+	}
+
+	return list, err
+}
+
+//Find func
+func (init *InitAuthorRepository) Find(ctx context.Context, id int64) (author *models.Author, err error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return author, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(AuthorColumns...).
+		From(authorTable).
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return author, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		author, err = models.ScanAuthor(rows)
+	}
+
+	return author, err
+}
+
+//Insert func
+func (init *InitAuthorRepository) Insert(ctx context.Context, author *models.Author) (*models.Author, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return author, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return author, err
+	}
+
+	query := sq.Insert(authorTable).
+		Columns(authorNameColumn, authorBiographyColumn, tenantIDColumn).
+		Values(author.Name, author.Biography, tenantID).
+		Suffix("RETURNING \"id\"").
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	err = query.QueryRow().Scan(&author.ID)
+	if err != nil {
+		trxn.SetError(err)
+		return author, translateError(err)
+	}
+
+	return author, err
+}
+
+//Update func
+func (init *InitAuthorRepository) Update(ctx context.Context, author *models.Author) (*models.Author, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return author, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return author, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Update(authorTable).
+		Set(authorNameColumn, author.Name).
+		Set(authorBiographyColumn, author.Biography).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{idColumn: author.ID, tenantIDColumn: tenantID})
+
+	_, err = builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return author, translateError(err)
+	}
+
+	return author, err
+}
+
+//RefreshByline func rebuild the byline of the books of the tenant crediting the author, it return the ids of
+//the changed books
+func (init *InitAuthorRepository) RefreshByline(ctx context.Context, id int64) (ids []int64, err error) {
+	ids = make([]int64, 0)
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return ids, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return ids, err
+	}
+
+	rows, err := trxn.DB.Query(refreshBylineQuery, id, tenantID)
+	if err != nil {
+		trxn.SetError(err)
+		return ids, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int64
+		if err = rows.Scan(&bookID); err != nil {
+			trxn.SetError(err)
+			return ids, err
+		}
+		ids = append(ids, bookID)
+	}
+	if err = rows.Err(); err != nil {
+		trxn.SetError(err)
+	}
+
+	return ids, err
+}
+
+//Delete func
+func (init *InitAuthorRepository) Delete(ctx context.Context, id int64) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Delete(authorTable).
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
+
+	_, err = builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return translateError(err)
+	}
+
+	return err
+}
+
+// translateError map constraint violations to repository errors
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code {
+	case uniqueViolation:
+		if pqErr.Table == authorTable {
+			return fmt.Errorf("%w: the name matches an existing author", ErrAuthorConflict)
+		}
+		return fmt.Errorf("%w: %s", ErrAuthorConflict, pqErr.Detail)
+	case foreignKeyViolation:
+		return ErrAuthorInUse
+	}
+	return err
+}
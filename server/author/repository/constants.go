@@ -0,0 +1,29 @@
+package repository
+
+// Table Name
+const (
+	authorTable = "authors"
+)
+
+// Table Column Names
+const (
+	idColumn        = "id"
+	tenantIDColumn  = "tenant_id"
+	updatedAtColumn = "updated_at"
+	createdAtColumn = "created_at"
+
+	// Author Table Column Names
+	authorNameColumn      = "name"
+	authorBiographyColumn = "biography"
+)
+
+// postgres error codes
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Table Columns
+var (
+	AuthorColumns = []string{idColumn, authorNameColumn, authorBiographyColumn, updatedAtColumn, createdAtColumn}
+)
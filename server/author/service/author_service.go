@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-rest-api-boilerplate/server/author/models"
+	"github.com/go-rest-api-boilerplate/server/author/repository"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+)
+
+var (
+	// ErrAuthorConflict is returned when an author with the same name exists
+	ErrAuthorConflict = repository.ErrAuthorConflict
+	// ErrAuthorInUse is returned when deleting an author still credited on a book
+	ErrAuthorInUse = repository.ErrAuthorInUse
+)
+
+//AuthorService interface
+type AuthorService interface {
+	ListAuthor(ctx context.Context, filter *models.AuthorFilter) ([]*models.Author, error)
+	GetAuthor(ctx context.Context, id int64) (*models.Author, error)
+	CreateAuthor(ctx context.Context, author *models.Author) (*models.Author, error)
+	UpdateAuthor(ctx context.Context, author *models.Author) (*models.Author, error)
+	DeleteAuthor(ctx context.Context, id int64) error
+}
+
+// BookRefresher publish the changes of the books rewritten by an author change, in its transaction
+type BookRefresher interface {
+	RefreshBook(ctx context.Context, ids []int64) error
+}
+
+//InitAuthorService struct
+type InitAuthorService struct {
+	Repository *InitAuthorRepositoryInterface
+	Books      BookRefresher
+}
+
+//InitAuthorRepositoryInterface struct
+type InitAuthorRepositoryInterface struct {
+	Author repository.AuthorRepository
+}
+
+// NewAuthorService return new instance of AuthorService, the books renamed with their author are published
+// by books
+func NewAuthorService(authorRepository repository.AuthorRepository, books BookRefresher) AuthorService {
+	return &InitAuthorService{
+		Repository: &InitAuthorRepositoryInterface{
+			Author: authorRepository,
+		},
+		Books: books,
+	}
+}
+
+//ListAuthor func
+func (init *InitAuthorService) ListAuthor(ctx context.Context, filter *models.AuthorFilter) ([]*models.Author, error) {
+	return init.Repository.Author.List(ctx, filter)
+}
+
+//GetAuthor func
+func (init *InitAuthorService) GetAuthor(ctx context.Context, id int64) (*models.Author, error) {
+	return init.Repository.Author.Find(ctx, id)
+}
+
+//CreateAuthor func
+func (init *InitAuthorService) CreateAuthor(ctx context.Context, author *models.Author) (*models.Author, error) {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	author, err := init.Repository.Author.Insert(ctx, author)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return author, err
+}
+
+//UpdateAuthor func
+func (init *InitAuthorService) UpdateAuthor(ctx context.Context, author *models.Author) (*models.Author, error) {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	author, err := init.Repository.Author.Update(ctx, author)
+
+	// the byline of the books crediting the author follow its name
+	var ids []int64
+	if err == nil {
+		ids, err = init.Repository.Author.RefreshByline(ctx, author.ID)
+	}
+	if err == nil {
+		err = init.Books.RefreshBook(ctx, ids)
+	}
+	if err != nil {
+		dbtrxn.Retrieve(ctx).Err = err
+	}
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return author, err
+}
+
+//DeleteAuthor func
+func (init *InitAuthorService) DeleteAuthor(ctx context.Context, id int64) error {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	err := init.Repository.Author.Delete(ctx, id)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return err
+}
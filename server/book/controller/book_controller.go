@@ -1,20 +1,33 @@
 package controller
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
-	"time"
+	"strings"
 
+	"github.com/go-rest-api-boilerplate/server/book/cover"
 	"github.com/go-rest-api-boilerplate/server/book/models"
+	v1 "github.com/go-rest-api-boilerplate/server/book/models/v1"
 	"github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/go-rest-api-boilerplate/server/book/transfer"
+	"github.com/go-rest-api-boilerplate/util/httpcache"
+	"github.com/go-rest-api-boilerplate/util/negotiate"
+	"github.com/go-rest-api-boilerplate/util/validation"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
-	echoSwagger "github.com/swaggo/echo-swagger"
+)
+
+const (
+	// exportFlushRows is the number of rows sent to the client at once
+	exportFlushRows = 500
 
-	_ "github.com/go-rest-api-boilerplate/docs"
+	// coverCacheControl let the clients keep a cover for a day, the shared caches must not serve the cover of
+	// a tenant to another one
+	coverCacheControl = "private, max-age=86400"
 )
 
 //InitBookController struct
@@ -27,45 +40,26 @@ type InitBookServiceInterface struct {
 	Book service.BookService
 }
 
-//NewBookServer func
-func NewBookServer(bookService service.BookService) {
-
+//NewBookRoutes func
+func NewBookRoutes(bookService service.BookService) func(g *echo.Group) {
 	bookServer := &InitBookController{
 		Service: &InitBookServiceInterface{
 			Book: bookService,
 		},
 	}
 
-	s := echo.New()
-
-	s.Use(middleware.Logger())
-	s.Use(middleware.Recover())
-
-	http.Handle("/", s)
-
-	// router
-	s.GET("/book", bookServer.GetListBook)
-	s.GET("/book/:id", bookServer.GetBook)
-	s.POST("/book", bookServer.CreateBook)
-	s.PUT("/book", bookServer.UpdateBook)
-	s.DELETE("/book/:id", bookServer.DeleteBook)
-
-	s.GET("/swagger/*", echoSwagger.WrapHandler)
-
-	apiServicePort := os.Getenv("API_PORT")
-
-	log.Printf("API Service listening on port %v", apiServicePort)
-
-	apiServer := &http.Server{
-		Addr:         ":" + apiServicePort,
-		ReadTimeout:  20 * time.Minute,
-		WriteTimeout: 20 * time.Minute,
-	}
-
-	err := apiServer.ListenAndServe()
-
-	if err != nil && err != http.ErrServerClosed {
-		log.Println(err.Error())
+	return func(g *echo.Group) {
+		g.GET("/book", bookServer.GetListBook, negotiate.Middleware(negotiate.ListFormats...),
+			httpcache.Middleware(httpcache.CacheControl("book_list", httpcache.DefaultCacheControl)))
+		g.GET("/book/export", bookServer.ExportBook)
+		g.POST("/book/import", bookServer.ImportBook)
+		g.GET("/book/:id", bookServer.GetBook, negotiate.Middleware(negotiate.Formats...),
+			httpcache.Middleware(httpcache.CacheControl("book", httpcache.DefaultCacheControl)))
+		g.POST("/book", bookServer.CreateBook, negotiate.Middleware(negotiate.Formats...))
+		g.PUT("/book", bookServer.UpdateBook, negotiate.Middleware(negotiate.Formats...))
+		g.DELETE("/book/:id", bookServer.DeleteBook, negotiate.Middleware(negotiate.Formats...))
+		g.PUT("/book/:id/cover", bookServer.PutCover)
+		g.GET("/book/:id/cover", bookServer.GetCover)
 	}
 }
 
@@ -75,29 +69,59 @@ func NewBookServer(bookService service.BookService) {
 // @Tags book
 // @Accept */*
 // @Produce json
-// @Success 200 {object} models.SuccessResponseList
-// @Failure 500 {object} models.SuccessResponseObject
+// @Produce application/xml
+// @Produce text/csv
+// @Produce application/msgpack
+// @Produce application/yaml
+// @Param id query []integer false "Book IDs" collectionFormat(multi)
+// @Param title query string false "Title contains"
+// @Param author query string false "Author contains"
+// @Param page query integer false "Page, from 1"
+// @Param per_page query integer false "Books per page, every book when empty"
+// @Param format query string false "Response format, overrides the Accept header" Enums(json, xml, csv, msgpack, yaml)
+// @Param If-None-Match header string false "ETag of the cached list"
+// @Success 200 {object} v1.SuccessResponseList
+// @Header 200 {string} ETag "Strong tag of the list"
+// @Success 304 {string} string "Not Modified"
+// @Failure 500 {object} v1.SuccessResponseObject
+// @Failure 406 {object} models.SuccessResponse
 // @Router /book [get]
 // GetListBook func
 func (init *InitBookController) GetListBook(ctx echo.Context) error {
-	books, err := init.Service.Book.ListBook()
+	filter := new(models.BookFilter)
+	err := (&echo.DefaultBinder{}).BindQueryParams(ctx, filter)
+	if err != nil {
+		data := &v1.SuccessResponseList{
+			Status:  400,
+			Message: err.Error(),
+			Data:    make([]*v1.Book, 0),
+		}
+
+		return negotiate.Render(ctx, http.StatusBadRequest, data)
+	}
+
+	books, err := init.Service.Book.ListBook(ctx.Request().Context(), filter)
 	if err != nil {
-		data := &models.SuccessResponseList{
+		data := &v1.SuccessResponseList{
 			Status:  500,
 			Message: "failed",
-			Data:    make([]*models.Book, 0),
+			Data:    make([]*v1.Book, 0),
 		}
 
-		return ctx.JSON(http.StatusInternalServerError, data)
+		return negotiate.Render(ctx, http.StatusInternalServerError, data)
 	}
 
-	data := &models.SuccessResponseList{
+	if negotiate.Format(ctx) == negotiate.FormatCSV {
+		return negotiate.Render(ctx, http.StatusOK, transfer.Table(books))
+	}
+
+	data := &v1.SuccessResponseList{
 		Status:  200,
 		Message: "success",
-		Data:    books,
+		Data:    v1.NewBooks(books),
 	}
 
-	return ctx.JSON(http.StatusOK, data)
+	return negotiate.Render(ctx, http.StatusOK, data)
 }
 
 // GetBook godoc
@@ -106,33 +130,47 @@ func (init *InitBookController) GetListBook(ctx echo.Context) error {
 // @Tags book
 // @Accept */*
 // @Produce json
+// @Produce application/xml
+// @Produce application/msgpack
+// @Produce application/yaml
 // @Param id path integer true "Book ID"
-// @Success 200 {object} models.SuccessResponseObject
-// @Failure 500 {object} models.SuccessResponseObject
+// @Param format query string false "Response format, overrides the Accept header" Enums(json, xml, msgpack, yaml)
+// @Param If-None-Match header string false "ETag of the cached book"
+// @Param If-Modified-Since header string false "Last-Modified of the cached book"
+// @Success 200 {object} v1.SuccessResponseObject
+// @Header 200 {string} ETag "Strong tag of the book"
+// @Header 200 {string} Last-Modified "Last update of the book"
+// @Success 304 {string} string "Not Modified"
+// @Failure 500 {object} v1.SuccessResponseObject
+// @Failure 406 {object} models.SuccessResponse
 // @Router /book/{id} [get]
 // GetBook func
 func (init *InitBookController) GetBook(ctx echo.Context) error {
 	idParse := ctx.Param("id")
 	id, _ := strconv.ParseInt(idParse, 10, 64)
 
-	book, err := init.Service.Book.GetBook(id)
+	book, err := init.Service.Book.GetBook(ctx.Request().Context(), id)
 	if err != nil {
-		data := &models.SuccessResponseObject{
+		data := &v1.SuccessResponseObject{
 			Status:  500,
 			Message: "failed",
-			Data:    new(models.Book),
+			Data:    new(v1.Book),
 		}
 
-		return ctx.JSON(http.StatusInternalServerError, data)
+		return negotiate.Render(ctx, http.StatusInternalServerError, data)
 	}
 
-	data := &models.SuccessResponseObject{
+	if book != nil {
+		httpcache.SetLastModified(ctx, book.UpdatedAt)
+	}
+
+	data := &v1.SuccessResponseObject{
 		Status:  200,
 		Message: "success",
-		Data:    book,
+		Data:    v1.NewBook(book),
 	}
 
-	return ctx.JSON(http.StatusOK, data)
+	return negotiate.Render(ctx, http.StatusOK, data)
 }
 
 // CreateBook godoc
@@ -140,42 +178,75 @@ func (init *InitBookController) GetBook(ctx echo.Context) error {
 // @Description Create a new book item
 // @Tags book
 // @Accept json
+// @Accept application/xml
+// @Accept application/msgpack
+// @Accept application/yaml
 // @Produce json
-// @Param book body models.Book true "Param Book"
+// @Produce application/xml
+// @Produce application/msgpack
+// @Produce application/yaml
+// @Param book body v1.Book true "Param Book"
+// @Param Idempotency-Key header string false "Key replaying the response of a retried request"
+// @Param format query string false "Response format, overrides the Accept header" Enums(json, xml, msgpack, yaml)
 // @Success 201 {object} models.SuccessResponse
 // @Failure 400 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
+// @Failure 425 {object} models.SuccessResponse
+// @Failure 406 {object} models.SuccessResponse
 // @Router /book [post]
 // CreateBook func
 func (init *InitBookController) CreateBook(ctx echo.Context) error {
-	var book *models.Book
-	err := ctx.Bind(&book)
+	var input *v1.Book
+	err := negotiate.Bind(ctx, &input)
 	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
 		data := &models.SuccessResponse{
 			Status:  400,
-			Message: err.Error(),
+			Message: errs.Error(),
+			Errors:  errs,
 		}
 
-		return ctx.JSON(http.StatusBadRequest, data)
+		return negotiate.Render(ctx, http.StatusBadRequest, data)
 	}
 
+	book := input.Model()
+	book.Normalize()
 	err = book.Validate()
 	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return negotiate.Render(ctx, http.StatusBadRequest, data)
+	}
+
+	book, err = init.Service.Book.CreateBook(ctx.Request().Context(), book)
+	if errors.Is(err, service.ErrUnknownAuthor) {
 		data := &models.SuccessResponse{
 			Status:  400,
 			Message: err.Error(),
 		}
 
-		return ctx.JSON(http.StatusBadRequest, data)
+		return negotiate.Render(ctx, http.StatusBadRequest, data)
 	}
+	if errors.Is(err, service.ErrBookConflict) {
+		data := &models.SuccessResponse{
+			Status:  409,
+			Message: err.Error(),
+		}
 
-	book, err = init.Service.Book.CreateBook(book)
+		return negotiate.Render(ctx, http.StatusConflict, data)
+	}
 	if err != nil {
 		data := &models.SuccessResponse{
 			Status:  500,
 			Message: err.Error(),
 		}
 
-		return ctx.JSON(http.StatusInternalServerError, data)
+		return negotiate.Render(ctx, http.StatusInternalServerError, data)
 	}
 
 	data := &models.SuccessResponse{
@@ -183,7 +254,7 @@ func (init *InitBookController) CreateBook(ctx echo.Context) error {
 		Message: fmt.Sprintf("Create book success #%d", book.ID),
 	}
 
-	return ctx.JSON(http.StatusCreated, data)
+	return negotiate.Render(ctx, http.StatusCreated, data)
 }
 
 // UpdateBook godoc
@@ -191,33 +262,83 @@ func (init *InitBookController) CreateBook(ctx echo.Context) error {
 // @Description Update a book item
 // @Tags book
 // @Accept json
+// @Accept application/xml
+// @Accept application/msgpack
+// @Accept application/yaml
 // @Produce json
-// @Param book body models.Book true "Param Book"
+// @Produce application/xml
+// @Produce application/msgpack
+// @Produce application/yaml
+// @Param book body v1.Book true "Param Book"
+// @Param format query string false "Response format, overrides the Accept header" Enums(json, xml, msgpack, yaml)
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.SuccessResponse
+// @Failure 404 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
 // @Failure 500 {object} models.SuccessResponse
+// @Failure 406 {object} models.SuccessResponse
 // @Router /book [put]
 // UpdateBook func
 func (init *InitBookController) UpdateBook(ctx echo.Context) error {
-	var book *models.Book
-	err := ctx.Bind(&book)
+	var input *v1.Book
+	err := negotiate.Bind(ctx, &input)
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return negotiate.Render(ctx, http.StatusBadRequest, data)
+	}
+
+	book := input.Model()
+	book.Normalize()
+	err = book.Validate()
 	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return negotiate.Render(ctx, http.StatusBadRequest, data)
+	}
+
+	book, err = init.Service.Book.UpdateBook(ctx.Request().Context(), book)
+	if errors.Is(err, service.ErrBookNotFound) {
+		data := &models.SuccessResponse{
+			Status:  404,
+			Message: err.Error(),
+		}
+
+		return negotiate.Render(ctx, http.StatusNotFound, data)
+	}
+	if errors.Is(err, service.ErrUnknownAuthor) {
 		data := &models.SuccessResponse{
 			Status:  400,
 			Message: err.Error(),
 		}
 
-		return ctx.JSON(http.StatusBadRequest, data)
+		return negotiate.Render(ctx, http.StatusBadRequest, data)
 	}
+	if errors.Is(err, service.ErrBookConflict) {
+		data := &models.SuccessResponse{
+			Status:  409,
+			Message: err.Error(),
+		}
 
-	book, err = init.Service.Book.UpdateBook(book)
+		return negotiate.Render(ctx, http.StatusConflict, data)
+	}
 	if err != nil {
 		data := &models.SuccessResponse{
 			Status:  500,
 			Message: err.Error(),
 		}
 
-		return ctx.JSON(http.StatusInternalServerError, data)
+		return negotiate.Render(ctx, http.StatusInternalServerError, data)
 	}
 
 	data := &models.SuccessResponse{
@@ -225,7 +346,7 @@ func (init *InitBookController) UpdateBook(ctx echo.Context) error {
 		Message: "success",
 	}
 
-	return ctx.JSON(http.StatusOK, data)
+	return negotiate.Render(ctx, http.StatusOK, data)
 }
 
 // DeleteBook godoc
@@ -234,23 +355,37 @@ func (init *InitBookController) UpdateBook(ctx echo.Context) error {
 // @Tags book
 // @Accept */*
 // @Produce json
+// @Produce application/xml
+// @Produce application/msgpack
+// @Produce application/yaml
 // @Param id path integer true "Book ID"
+// @Param format query string false "Response format, overrides the Accept header" Enums(json, xml, msgpack, yaml)
 // @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.SuccessResponse
 // @Failure 500 {object} models.SuccessResponse
+// @Failure 406 {object} models.SuccessResponse
 // @Router /book/{id} [delete]
 // DeleteBook func
 func (init *InitBookController) DeleteBook(ctx echo.Context) error {
 	idParse := ctx.Param("id")
 	id, _ := strconv.ParseInt(idParse, 10, 64)
 
-	err := init.Service.Book.DeleteBook(id)
+	err := init.Service.Book.DeleteBook(ctx.Request().Context(), id)
+	if errors.Is(err, service.ErrBookNotFound) {
+		data := &models.SuccessResponse{
+			Status:  404,
+			Message: err.Error(),
+		}
+
+		return negotiate.Render(ctx, http.StatusNotFound, data)
+	}
 	if err != nil {
 		data := &models.SuccessResponse{
 			Status:  500,
 			Message: "failed",
 		}
 
-		return ctx.JSON(http.StatusInternalServerError, data)
+		return negotiate.Render(ctx, http.StatusInternalServerError, data)
 	}
 
 	data := &models.SuccessResponse{
@@ -258,5 +393,354 @@ func (init *InitBookController) DeleteBook(ctx echo.Context) error {
 		Message: "success",
 	}
 
+	return negotiate.Render(ctx, http.StatusOK, data)
+}
+
+// ExportBook godoc
+// @Summary Export books
+// @Description Stream every book matching the list filter as CSV, NDJSON or JSON
+// @Tags book
+// @Accept */*
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Export format" Enums(json, csv, ndjson)
+// @Param title query string false "Title contains"
+// @Param author query string false "Author contains"
+// @Success 200 {array} models.Book
+// @Failure 400 {object} models.SuccessResponse
+// @Router /book/export [get]
+// ExportBook func
+func (init *InitBookController) ExportBook(ctx echo.Context) error {
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+
+	filter := new(models.BookFilter)
+	err := (&echo.DefaultBinder{}).BindQueryParams(ctx, filter)
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	res := ctx.Response()
+	encoder, err := transfer.NewEncoder(format, res)
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	res.Header().Set(echo.HeaderContentType, transfer.ContentType(format))
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"books.%s\"", format))
+	res.WriteHeader(http.StatusOK)
+
+	count := 0
+	err = encoder.Begin()
+	if err == nil {
+		err = init.Service.Book.ExportBook(ctx.Request().Context(), filter, func(book *models.Book) error {
+			if err := encoder.Encode(book); err != nil {
+				return err
+			}
+
+			count++
+			if count%exportFlushRows == 0 {
+				if err := encoder.Flush(); err != nil {
+					return err
+				}
+				res.Flush()
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = encoder.End()
+	}
+
+	// the status is already sent, an error can only cut the stream short
+	if err != nil {
+		ctx.Logger().Errorf("export book: %v", err)
+	}
+
+	return nil
+}
+
+// ImportBook godoc
+// @Summary Import books
+// @Description Import books from a CSV or NDJSON upload, the whole file is rejected if any row is invalid
+// @Tags book
+// @Accept mpfd
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param file formData file false "CSV or NDJSON file"
+// @Param format query string false "Import format, detected from the content type or file name when empty" Enums(csv, ndjson)
+// @Param Idempotency-Key header string false "Key replaying the response of a retried request"
+// @Success 201 {object} models.ImportResponse
+// @Failure 400 {object} models.ImportResponse
+// @Failure 409 {object} models.ImportResponse
+// @Failure 422 {object} models.ImportResponse
+// @Failure 500 {object} models.ImportResponse
+// @Router /book/import [post]
+// ImportBook func
+func (init *InitBookController) ImportBook(ctx echo.Context) error {
+	req := ctx.Request()
+	body := io.Reader(req.Body)
+	filename := ""
+
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		file, err := ctx.FormFile("file")
+		if err != nil {
+			data := &models.ImportResponse{
+				Status:  400,
+				Message: err.Error(),
+				Errors:  make([]*models.RowError, 0),
+			}
+
+			return ctx.JSON(http.StatusBadRequest, data)
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			data := &models.ImportResponse{
+				Status:  500,
+				Message: err.Error(),
+				Errors:  make([]*models.RowError, 0),
+			}
+
+			return ctx.JSON(http.StatusInternalServerError, data)
+		}
+		defer src.Close()
+
+		body = src
+		filename = file.Filename
+	}
+
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = transfer.DetectFormat(req.Header.Get(echo.HeaderContentType), filename)
+	}
+
+	decoder, err := transfer.NewDecoder(format, body)
+	if err != nil {
+		data := &models.ImportResponse{
+			Status:  400,
+			Message: err.Error(),
+			Errors:  make([]*models.RowError, 0),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	result, err := init.Service.Book.ImportBook(ctx.Request().Context(), decoder)
+	if errors.Is(err, service.ErrMalformedImport) {
+		data := &models.ImportResponse{
+			Status:  400,
+			Message: err.Error(),
+			Errors:  result.Errors,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+	if errors.Is(err, service.ErrBookConflict) {
+		data := &models.ImportResponse{
+			Status:  409,
+			Message: err.Error(),
+			Errors:  result.Errors,
+		}
+
+		return ctx.JSON(http.StatusConflict, data)
+	}
+	if err != nil {
+		data := &models.ImportResponse{
+			Status:  500,
+			Message: err.Error(),
+			Errors:  result.Errors,
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	if len(result.Errors) > 0 {
+		data := &models.ImportResponse{
+			Status:  422,
+			Message: fmt.Sprintf("%d of %d rows are invalid, no book imported", len(result.Errors), result.Rows),
+			Errors:  result.Errors,
+		}
+
+		return ctx.JSON(http.StatusUnprocessableEntity, data)
+	}
+
+	data := &models.ImportResponse{
+		Status:   201,
+		Message:  fmt.Sprintf("Import book success, %d rows", result.Rows),
+		Imported: result.Imported,
+		Errors:   result.Errors,
+	}
+
+	return ctx.JSON(http.StatusCreated, data)
+}
+
+// PutCover godoc
+// @Summary Upload a book cover
+// @Description Upload a JPEG, PNG or WebP cover, the content type is sniffed and thumbnails are generated
+// @Tags book
+// @Accept mpfd
+// @Accept image/jpeg
+// @Accept image/png
+// @Accept image/webp
+// @Produce json
+// @Param id path integer true "Book ID"
+// @Param file formData file false "Cover image"
+// @Success 200 {object} models.CoverResponse
+// @Failure 404 {object} models.CoverResponse
+// @Failure 413 {object} models.CoverResponse
+// @Failure 415 {object} models.CoverResponse
+// @Failure 422 {object} models.CoverResponse
+// @Failure 500 {object} models.CoverResponse
+// @Router /book/{id}/cover [put]
+// PutCover func
+func (init *InitBookController) PutCover(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	req := ctx.Request()
+	body := io.Reader(req.Body)
+
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		file, err := ctx.FormFile("file")
+		if err != nil {
+			data := &models.CoverResponse{
+				Status:  400,
+				Message: err.Error(),
+			}
+
+			return ctx.JSON(http.StatusBadRequest, data)
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			data := &models.CoverResponse{
+				Status:  500,
+				Message: err.Error(),
+			}
+
+			return ctx.JSON(http.StatusInternalServerError, data)
+		}
+		defer src.Close()
+
+		body = src
+	}
+
+	bookCover, err := init.Service.Book.UploadCover(ctx.Request().Context(), id, body)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrBookNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, cover.ErrTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, cover.ErrUnsupportedType):
+			status = http.StatusUnsupportedMediaType
+		case errors.Is(err, cover.ErrDimension):
+			status = http.StatusUnprocessableEntity
+		}
+
+		data := &models.CoverResponse{
+			Status:  int64(status),
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(status, data)
+	}
+
+	data := &models.CoverResponse{
+		Status:  200,
+		Message: "success",
+		Data:    bookCover,
+	}
+
 	return ctx.JSON(http.StatusOK, data)
 }
+
+// GetCover godoc
+// @Summary Get a book cover
+// @Description Get the original cover or a JPEG thumbnail, with caching headers
+// @Tags book
+// @Accept */*
+// @Produce image/jpeg
+// @Produce image/png
+// @Produce image/webp
+// @Param id path integer true "Book ID"
+// @Param size query string false "Cover size" Enums(original, small, medium, large)
+// @Success 200 {file} file
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {object} models.SuccessResponse
+// @Failure 404 {object} models.SuccessResponse
+// @Failure 500 {object} models.SuccessResponse
+// @Router /book/{id}/cover [get]
+// GetCover func
+func (init *InitBookController) GetCover(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	size := ctx.QueryParam("size")
+	if size == "" {
+		size = cover.SizeOriginal
+	}
+	if !cover.ValidSize(size) {
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: fmt.Sprintf("unknown cover size %q", size),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	body, bookCover, err := init.Service.Book.GetCover(ctx.Request().Context(), id, size)
+	if errors.Is(err, service.ErrCoverNotFound) {
+		data := &models.SuccessResponse{
+			Status:  404,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusNotFound, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: "failed",
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: "failed",
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, bookCover.ContentType)
+	header.Set("Cache-Control", coverCacheControl)
+	header.Add(echo.HeaderVary, echo.HeaderAuthorization)
+	header.Set("ETag", fmt.Sprintf("\"%s-%s\"", bookCover.ETag, size))
+
+	// ServeContent answer conditional requests with 304
+	http.ServeContent(ctx.Response(), ctx.Request(), "", bookCover.UpdatedAt, bytes.NewReader(content))
+	return nil
+}
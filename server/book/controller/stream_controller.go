@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/stream"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// streamRetry is the reconnection delay advised to SSE clients, in milliseconds
+	streamRetry = 3000
+
+	// websocketWriteTimeout bound a write to a WebSocket client
+	websocketWriteTimeout = 10 * time.Second
+)
+
+var streamTypes = map[string]bool{
+	models.EventBookCreated: true,
+	models.EventBookUpdated: true,
+	models.EventBookDeleted: true,
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+//InitStreamController struct
+type InitStreamController struct {
+	Hub       *stream.Hub
+	Heartbeat time.Duration
+}
+
+//NewStreamRoutes func
+func NewStreamRoutes(hub *stream.Hub, config stream.Config) func(g *echo.Group) {
+	streamServer := &InitStreamController{
+		Hub:       hub,
+		Heartbeat: config.Heartbeat,
+	}
+
+	return func(g *echo.Group) {
+		g.GET("/book/stream", streamServer.StreamBook)
+		g.GET("/book/ws", streamServer.WebSocketBook)
+	}
+}
+
+// StreamBook godoc
+// @Summary Stream book changes
+// @Description Push book changes as server-sent events, a "reset" event asks to reload every book. Reconnecting with Last-Event-ID resume from the replay buffer
+// @Tags book
+// @Accept */*
+// @Produce text/event-stream
+// @Param types query string false "Comma separated event types" example(book.created,book.deleted)
+// @Param Last-Event-ID header integer false "Resume after this event"
+// @Success 200 {object} stream.Event
+// @Failure 400 {object} models.SuccessResponse
+// @Router /book/stream [get]
+// StreamBook func
+func (init *InitStreamController) StreamBook(ctx echo.Context) error {
+	types, lastEventID, err := streamQuery(ctx)
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	subscription, replay, ok := init.Hub.Subscribe(tenant.FromContext(ctx.Request().Context()), types, lastEventID)
+	defer subscription.Close()
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	// keep proxies from buffering the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(res, "retry: %d\n\n", streamRetry)
+	if !ok {
+		writeEvent(res, &stream.Event{Type: stream.EventReset, At: time.Now()})
+	}
+	for _, event := range replay {
+		writeEvent(res, event)
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(init.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+		case event, open := <-subscription.C:
+			if !open {
+				// too slow, the client reconnects and resumes
+				return nil
+			}
+			if err := writeEvent(res, event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+// WebSocketBook godoc
+// @Summary Stream book changes over WebSocket
+// @Description Push book changes as JSON text messages, a "reset" event asks to reload every book. Pings are sent as heartbeats
+// @Tags book
+// @Accept */*
+// @Produce json
+// @Param types query string false "Comma separated event types" example(book.created,book.deleted)
+// @Param last_event_id query integer false "Resume after this event"
+// @Success 101 {object} stream.Event
+// @Failure 400 {object} models.SuccessResponse
+// @Router /book/ws [get]
+// WebSocketBook func
+func (init *InitStreamController) WebSocketBook(ctx echo.Context) error {
+	types, lastEventID, err := streamQuery(ctx)
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	conn, err := upgrader.Upgrade(ctx.Response(), ctx.Request(), nil)
+	if err != nil {
+		// the upgrader replied with the error
+		return nil
+	}
+	defer conn.Close()
+
+	subscription, replay, ok := init.Hub.Subscribe(tenant.FromContext(ctx.Request().Context()), types, lastEventID)
+	defer subscription.Close()
+
+	// read to process pongs and close frames, a client missing two heartbeats is gone
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * init.Heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * init.Heartbeat))
+	})
+	closed := make(chan bool)
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event *stream.Event) error {
+		conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+		return conn.WriteJSON(event)
+	}
+
+	if !ok {
+		replay = append([]*stream.Event{{Type: stream.EventReset, At: time.Now()}}, replay...)
+	}
+	for _, event := range replay {
+		if err := send(event); err != nil {
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(init.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case event, open := <-subscription.C:
+			if !open {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"),
+					time.Now().Add(websocketWriteTimeout))
+				return nil
+			}
+			if err := send(event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteTimeout)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// streamQuery parse the event types and the event to resume after
+func streamQuery(ctx echo.Context) ([]string, int64, error) {
+	var types []string
+	if param := ctx.QueryParam("types"); param != "" {
+		for _, t := range strings.Split(param, ",") {
+			t = strings.TrimSpace(t)
+			if !streamTypes[t] {
+				return nil, 0, fmt.Errorf("unknown event type %q", t)
+			}
+			types = append(types, t)
+		}
+	}
+
+	lastEventID := ctx.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.QueryParam("last_event_id")
+	}
+	if lastEventID == "" {
+		return types, 0, nil
+	}
+
+	id, err := strconv.ParseInt(lastEventID, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid last event id %q", lastEventID)
+	}
+	return types, id, nil
+}
+
+// writeEvent write an event in the server-sent events format, the id line is
+// omitted for the resets of the listener so the client keeps its last event id
+func writeEvent(w io.Writer, event *stream.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
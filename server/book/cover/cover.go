@@ -0,0 +1,186 @@
+package cover
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"strconv"
+
+	// register the decoders of image.Decode
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Cover sizes
+const (
+	SizeOriginal = "original"
+	SizeSmall    = "small"
+	SizeMedium   = "medium"
+	SizeLarge    = "large"
+)
+
+// ThumbnailContentType is the content type of every thumbnail
+const ThumbnailContentType = "image/jpeg"
+
+var (
+	// ErrUnsupportedType is returned for content other than JPEG, PNG or WebP
+	ErrUnsupportedType = errors.New("cover: image must be JPEG, PNG or WebP")
+	// ErrTooLarge is returned when the upload exceeds the byte limit
+	ErrTooLarge = errors.New("cover: image is too large")
+	// ErrDimension is returned when the image dimensions are out of bounds
+	ErrDimension = errors.New("cover: image dimensions are out of bounds")
+)
+
+// ThumbnailWidths is the width in pixels of every thumbnail size
+var ThumbnailWidths = map[string]int{
+	SizeSmall:  160,
+	SizeMedium: 320,
+	SizeLarge:  640,
+}
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+type (
+	// Options limit the accepted uploads
+	Options struct {
+		MaxBytes     int64
+		MinDimension int
+		MaxDimension int
+	}
+
+	// Image is a processed upload
+	Image struct {
+		ContentType string
+		Width       int
+		Height      int
+		ETag        string
+		Original    []byte
+		Thumbnails  map[string][]byte
+	}
+)
+
+// DefaultOptions accept up to 5 MB and 6000x6000 pixels
+var DefaultOptions = Options{
+	MaxBytes:     5 << 20,
+	MinDimension: 16,
+	MaxDimension: 6000,
+}
+
+// ValidSize report whether size is a known cover size
+func ValidSize(size string) bool {
+	_, ok := ThumbnailWidths[size]
+	return ok || size == SizeOriginal
+}
+
+// Process sniff, validate and thumbnail an uploaded image
+func Process(data []byte, opts Options) (*Image, error) {
+	if int64(len(data)) > opts.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	// the declared content type is not trusted
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, ErrUnsupportedType
+	}
+
+	// check dimensions before decoding to refuse decompression bombs
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if config.Width < opts.MinDimension || config.Height < opts.MinDimension ||
+		config.Width > opts.MaxDimension || config.Height > opts.MaxDimension {
+		return nil, fmt.Errorf("%w: %dx%d, allowed %d to %d pixels", ErrDimension,
+			config.Width, config.Height, opts.MinDimension, opts.MaxDimension)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+
+	thumbnails := make(map[string][]byte, len(ThumbnailWidths))
+	for size, width := range ThumbnailWidths {
+		thumbnail, err := resize(src, width)
+		if err != nil {
+			return nil, err
+		}
+		thumbnails[size] = thumbnail
+	}
+
+	sum := sha256.Sum256(data)
+
+	return &Image{
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		ETag:        hex.EncodeToString(sum[:16]),
+		Original:    data,
+		Thumbnails:  thumbnails,
+	}, nil
+}
+
+// resize scale src down to width keeping the aspect ratio, smaller images are not enlarged
+func resize(src image.Image, width int) ([]byte, error) {
+	bounds := src.Bounds()
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	// JPEG has no alpha, transparent pixels become white
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Key return the blob key of a cover size, every uploaded image is a version keyed by its etag so the current
+// cover stays readable until the upload is committed
+func Key(bookID int64, etag, size string) string {
+	return VersionPrefix(bookID, etag) + size
+}
+
+// VersionPrefix return the blob key prefix of every size of a cover version
+func VersionPrefix(bookID int64, etag string) string {
+	return fmt.Sprintf("%s%s/", Prefix(bookID), etag)
+}
+
+// Prefix return the blob key prefix of every cover of a book
+func Prefix(bookID int64) string {
+	return fmt.Sprintf("books/%d/cover/", bookID)
+}
+
+// OptionsFromEnv override DefaultOptions with COVER_MAX_BYTES and COVER_MAX_DIMENSION
+func OptionsFromEnv() Options {
+	opts := DefaultOptions
+	if v, err := strconv.ParseInt(os.Getenv("COVER_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		opts.MaxBytes = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("COVER_MAX_DIMENSION")); err == nil && v > 0 {
+		opts.MaxDimension = v
+	}
+	return opts
+}
@@ -0,0 +1,148 @@
+package fixture
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/transfer"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// bookFixture is the YAML representation of a book
+type bookFixture struct {
+	ISBN10        string `yaml:"isbn10"`
+	ISBN13        string `yaml:"isbn13"`
+	Title         string `yaml:"title"`
+	Subtitle      string `yaml:"subtitle"`
+	Author        string `yaml:"author"`
+	Publisher     string `yaml:"publisher"`
+	PublishedDate string `yaml:"published_date"`
+	Language      string `yaml:"language"`
+	PageCount     int    `yaml:"page_count"`
+	Edition       string `yaml:"edition"`
+	Description   string `yaml:"description"`
+	Format        string `yaml:"format"`
+}
+
+// Dir return fixture directory of the environment
+func Dir(source, env string) string {
+	return filepath.Join(source, env)
+}
+
+// Key return the seed key of a fixture book, derived from its title and author as written in the fixture so
+// reloading the fixture update the same book even after its byline is rebuilt from the credited authors
+func Key(book *models.Book) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(book.Title) + "\n" + strings.ToLower(book.Author)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load every fixture file in dir, sorted by file name
+func Load(dir string) ([]*models.Book, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	books := make([]*models.Book, 0)
+	for _, name := range names {
+		loaded, err := LoadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, loaded...)
+	}
+
+	return books, nil
+}
+
+// LoadFile decode a YAML, JSON or CSV fixture file
+func LoadFile(path string) ([]*models.Book, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	books := make([]*models.Book, 0)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		books, err = decodeYAML(file)
+	case ".json":
+		err = json.NewDecoder(file).Decode(&books)
+	case ".csv":
+		books, err = decodeCSV(file)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fixture: %s: %w", path, err)
+	}
+	return books, nil
+}
+
+func decodeYAML(r io.Reader) ([]*models.Book, error) {
+	var fixtures []bookFixture
+	if err := yaml.NewDecoder(r).Decode(&fixtures); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	books := make([]*models.Book, 0, len(fixtures))
+	for _, f := range fixtures {
+		publishedDate, err := models.ParseDate(f.PublishedDate)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", f.Title, err)
+		}
+
+		books = append(books, &models.Book{
+			ISBN10:        f.ISBN10,
+			ISBN13:        f.ISBN13,
+			Title:         f.Title,
+			Subtitle:      f.Subtitle,
+			Author:        f.Author,
+			Publisher:     f.Publisher,
+			PublishedDate: publishedDate,
+			Language:      f.Language,
+			PageCount:     f.PageCount,
+			Edition:       f.Edition,
+			Description:   f.Description,
+			Format:        f.Format,
+		})
+	}
+
+	return books, nil
+}
+
+func decodeCSV(r io.Reader) ([]*models.Book, error) {
+	decoder, err := transfer.NewDecoder(transfer.FormatCSV, r)
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]*models.Book, 0)
+	for {
+		book, err := decoder.Next()
+		if err == io.EOF {
+			return books, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+}
@@ -0,0 +1,86 @@
+package fixture
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/util/isbn"
+)
+
+var (
+	titleAdjectives = []string{
+		"Silent", "Broken", "Golden", "Hidden", "Last", "Forgotten", "Crimson", "Endless",
+		"Distant", "Quiet", "Burning", "Wandering", "Secret", "Fading", "Northern", "Little",
+	}
+	titleNouns = []string{
+		"River", "Garden", "Empire", "Harbor", "Mountain", "Letter", "Orchard", "Island",
+		"Kingdom", "Lantern", "Archive", "Monsoon", "Compass", "Voyage", "Bridge", "Forest",
+	}
+	titlePatterns = []string{
+		"The %s %s",
+		"%s %s",
+		"Tales of the %s %s",
+		"Beyond the %s %s",
+		"Songs of the %s %s",
+	}
+	firstNames = []string{
+		"Ayu", "Budi", "Citra", "Dewi", "Eko", "Fajar", "Gita", "Hendra",
+		"Maria", "James", "Sofia", "Daniel", "Elena", "Lucas", "Hana", "Omar",
+	}
+	publishers = []string{
+		"Gramedia Pustaka Utama", "Bentang Pustaka", "Mizan", "Penguin Books", "HarperCollins",
+		"Vintage", "Faber & Faber", "Pan Macmillan",
+	}
+	languages = []string{"id", "en", "en-GB", "ms", "nl", "ja"}
+	formats   = []string{
+		models.FormatHardcover, models.FormatPaperback, models.FormatEbook, models.FormatAudiobook,
+	}
+	lastNames = []string{
+		"Santoso", "Wijaya", "Halim", "Pratama", "Lestari", "Nugroho", "Kusuma", "Saputra",
+		"Garcia", "Okafor", "Novak", "Tanaka", "Moreau", "Lindqvist", "Haddad", "Reyes",
+	}
+)
+
+// Generate n fake books, the same seed always produce the same books
+func Generate(n int, seed int64) []*models.Book {
+	random := rand.New(rand.NewSource(seed))
+	pick := func(values []string) string {
+		return values[random.Intn(len(values))]
+	}
+
+	// consecutive ISBN bodies starting at a random offset never collide
+	isbnBase := random.Intn(1e8)
+	epoch := time.Date(1950, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	seen := make(map[string]int, n)
+	books := make([]*models.Book, 0, n)
+	for i := 0; i < n; i++ {
+		title := fmt.Sprintf(pick(titlePatterns), pick(titleAdjectives), pick(titleNouns))
+		author := fmt.Sprintf("%s %s", pick(firstNames), pick(lastNames))
+
+		// keep the seed key unique so N generated books insert N rows
+		key := title + "\x00" + author
+		seen[key]++
+		if volume := seen[key]; volume > 1 {
+			title = fmt.Sprintf("%s, Volume %d", title, volume)
+		}
+
+		book := &models.Book{
+			ISBN13:        isbn.New13(fmt.Sprintf("9798%08d", (isbnBase+i)%1e8)),
+			Title:         title,
+			Author:        author,
+			Publisher:     pick(publishers),
+			PublishedDate: models.NewDate(epoch.AddDate(0, 0, random.Intn(365*70))),
+			Language:      pick(languages),
+			PageCount:     80 + random.Intn(900),
+			Format:        pick(formats),
+		}
+		book.Normalize()
+
+		books = append(books, book)
+	}
+
+	return books
+}
@@ -1,53 +1,171 @@
 package models
 
 import (
-	"database/sql"
+	"regexp"
+	"strings"
 	"time"
 
-	validator "gopkg.in/go-playground/validator.v9"
+	"golang.org/x/text/language"
+
+	"github.com/go-rest-api-boilerplate/util/isbn"
+	"github.com/go-rest-api-boilerplate/util/validation"
+)
+
+// the split_byline function of the migrations use the same patterns
+var (
+	// bylineSeparator split a byline between people
+	bylineSeparator = regexp.MustCompile(`\s*(?:;|&|\s+and\s+)\s*`)
+	// invertedName is a "Surname, Given" name, a surname of several words and a given name of one word around
+	// the only comma of a part
+	invertedName = regexp.MustCompile(`^([^,]+\s[^,]*[^[:space:],])\s*,\s*([^[:space:],]+)$`)
+	// nameSeparator split the other parts on their commas
+	nameSeparator = regexp.MustCompile(`\s*,\s*`)
+)
+
+// Book formats
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
 )
 
-// Book represented database model
+// BylineSeparator join the names of a byline, a comma would make "Plato, Aristotle" read as one inverted name
+const BylineSeparator = "; "
+
+// Author roles
+const (
+	RoleAuthor      = "author"
+	RoleEditor      = "editor"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+)
+
+// Book represented database model, its columns are mapped from the db tags
 type Book struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title" validate:"required"`
-	Author    string    `json:"author" validate:"required"`
-	UpdatedAt time.Time `json:"-"`
-	CreatedAt time.Time `json:"-"`
+	ID            int64         `json:"id" xml:"id" db:"id,readonly"`
+	ISBN10        string        `json:"isbn10" xml:"isbn10" db:"isbn10,nullzero" validate:"omitempty,isbn10" example:"0134190440"`
+	ISBN13        string        `json:"isbn13" xml:"isbn13" db:"isbn13,nullzero" validate:"omitempty,isbn13" example:"9780134190440"`
+	Title         string        `json:"title" xml:"title" db:"title" validate:"required,max=255"`
+	Subtitle      string        `json:"subtitle" xml:"subtitle" db:"subtitle" validate:"max=255"`
+	Author        string        `json:"author" xml:"author" db:"author" validate:"required_without=Authors,max=255"`
+	Authors       []*BookAuthor `json:"authors" xml:"authors>author" validate:"omitempty,dive"`
+	Publisher     string        `json:"publisher" xml:"publisher" db:"publisher" validate:"max=255"`
+	PublishedDate Date          `json:"published_date" xml:"published_date" db:"published_date" swaggertype:"string" format:"date"`
+	Language      string        `json:"language" xml:"language" db:"language" validate:"omitempty,bcp47" example:"en-US"`
+	PageCount     int           `json:"page_count" xml:"page_count" db:"page_count,nullzero" validate:"min=0"`
+	Edition       string        `json:"edition" xml:"edition" db:"edition" validate:"max=64"`
+	Description   string        `json:"description" xml:"description" db:"description"`
+	Format        string        `json:"format" xml:"format" db:"format" validate:"omitempty,oneof=hardcover paperback ebook audiobook" enums:"hardcover,paperback,ebook,audiobook"`
+	UpdatedAt     time.Time     `json:"-" xml:"-" db:"updated_at,readonly"`
+	CreatedAt     time.Time     `json:"-" xml:"-" db:"created_at,readonly"`
+}
+
+// BookAuthor is an author credited on a book, an unknown name create a new author
+type BookAuthor struct {
+	ID       int64  `json:"id" xml:"id"`
+	Name     string `json:"name" xml:"name" validate:"required_without=ID,max=255"`
+	Role     string `json:"role" xml:"role" validate:"omitempty,oneof=author editor translator illustrator" enums:"author,editor,translator,illustrator"`
+	Position int    `json:"position" xml:"position"`
+}
+
+// BookFilter is the list and export query of book, every book is listed when PerPage is 0
+type BookFilter struct {
+	IDs      []int64 `query:"id"`
+	Title    string  `query:"title"`
+	Author   string  `query:"author"`
+	AuthorID int64   `query:"author_id"`
+	Page     uint64  `query:"page"`
+	PerPage  uint64  `query:"per_page"`
 }
 
 // SuccessResponseList struct
 type SuccessResponseList struct {
-	Status  int64   `json:"status"`
-	Message string  `json:"message"`
-	Data    []*Book `json:"data"`
+	Status  int64   `json:"status" xml:"status"`
+	Message string  `json:"message" xml:"message"`
+	Data    []*Book `json:"data" xml:"data>book"`
 }
 
 // SuccessResponseObject struct
 type SuccessResponseObject struct {
-	Status  int64  `json:"status"`
-	Message string `json:"message"`
-	Data    *Book  `json:"data"`
+	Status  int64  `json:"status" xml:"status"`
+	Message string `json:"message" xml:"message"`
+	Data    *Book  `json:"data" xml:"data"`
 }
 
 // SuccessResponse struct
 type SuccessResponse struct {
-	Status  int64  `json:"status"`
-	Message string `json:"message"`
-}
-
-//ScanBook func
-func ScanBook(rows *sql.Rows) (*Book, error) {
-	var book Book
-	err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.UpdatedAt, &book.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &book, nil
+	Status  int64             `json:"status" xml:"status"`
+	Message string            `json:"message" xml:"message"`
+	Errors  validation.Errors `json:"errors,omitempty" xml:"error,omitempty"`
 }
 
 // Validate book
 func (b *Book) Validate() error {
-	validate := validator.New()
 	return validate.Struct(b)
 }
+
+// Normalize ISBN and language, a missing ISBN is derived from the other one
+func (b *Book) Normalize() {
+	b.ISBN10 = isbn.Normalize(b.ISBN10)
+	b.ISBN13 = isbn.Normalize(b.ISBN13)
+
+	if b.ISBN13 == "" && b.ISBN10 != "" {
+		b.ISBN13, _ = isbn.To13(b.ISBN10)
+	}
+	if b.ISBN10 == "" && b.ISBN13 != "" {
+		b.ISBN10, _ = isbn.To10(b.ISBN13)
+	}
+
+	if tag, err := language.Parse(b.Language); err == nil && b.Language != "" {
+		b.Language = tag.String()
+	}
+
+	b.Format = strings.ToLower(b.Format)
+
+	for i, author := range b.Authors {
+		author.Name = strings.TrimSpace(author.Name)
+		author.Role = strings.ToLower(author.Role)
+		if author.Role == "" {
+			author.Role = RoleAuthor
+		}
+		author.Position = i
+	}
+}
+
+// Byline return the names of the authors having the author role, or of every credited person if none
+func (b *Book) Byline() string {
+	names := make([]string, 0, len(b.Authors))
+	for _, author := range b.Authors {
+		if author.Role == RoleAuthor {
+			names = append(names, author.Name)
+		}
+	}
+	if len(names) == 0 {
+		for _, author := range b.Authors {
+			names = append(names, author.Name)
+		}
+	}
+
+	return strings.Join(names, BylineSeparator)
+}
+
+// SplitByline return one author per name of a free-text byline such as "Le Guin, Ursula and David Thomas". The
+// people are separated by ";", "&" and "and", and by commas unless the part reads "Surname, Given": a part
+// with a single comma, several words before it and one after it. A single word on each side like
+// "Plato, Aristotle" is two people, and a given name of several words like "Tolkien, J. R. R." is split in two
+// authors, such bylines are credited through the authors of the book instead
+func SplitByline(byline string) []*BookAuthor {
+	authors := make([]*BookAuthor, 0)
+	for _, part := range bylineSeparator.Split(strings.TrimSpace(byline), -1) {
+		part = invertedName.ReplaceAllString(strings.TrimSpace(part), "$2 $1")
+		for _, name := range nameSeparator.Split(part, -1) {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			authors = append(authors, &BookAuthor{Name: name, Role: RoleAuthor, Position: len(authors)})
+		}
+	}
+	return authors
+}
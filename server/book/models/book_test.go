@@ -0,0 +1,65 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitByline(t *testing.T) {
+	tests := []struct {
+		byline string
+		names  []string
+	}{
+		{"Martin Kleppmann", []string{"Martin Kleppmann"}},
+		{"Andrew Hunt and David Thomas", []string{"Andrew Hunt", "David Thomas"}},
+		{"Erich Gamma, Richard Helm & Ralph Johnson; John Vlissides",
+			[]string{"Erich Gamma", "Richard Helm", "Ralph Johnson", "John Vlissides"}},
+		{"Le Guin, Ursula", []string{"Ursula Le Guin"}},
+		{"Van Rossum ,Guido and Le Guin, Ursula", []string{"Guido Van Rossum", "Ursula Le Guin"}},
+		{"Plato; Aristotle", []string{"Plato", "Aristotle"}},
+		{"Plato, Aristotle", []string{"Plato", "Aristotle"}},
+		{"Hunt, Andrew and Thomas, David", []string{"Hunt", "Andrew", "Thomas", "David"}},
+		{"Tolkien, J. R. R.", []string{"Tolkien", "J. R. R."}},
+		{"Le Guin, Ursula K.", []string{"Le Guin", "Ursula K."}},
+		{"Erich Gamma, Richard Helm, Ralph", []string{"Erich Gamma", "Richard Helm", "Ralph"}},
+		{" , & ", []string{}},
+	}
+
+	for _, test := range tests {
+		authors := SplitByline(test.byline)
+		names := make([]string, 0, len(authors))
+		for i, author := range authors {
+			if author.Role != RoleAuthor || author.Position != i {
+				t.Errorf("%q: author %d is %+v", test.byline, i, author)
+			}
+			names = append(names, author.Name)
+		}
+		if !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%q: got %q, want %q", test.byline, names, test.names)
+		}
+	}
+}
+
+func TestBylineSplitBack(t *testing.T) {
+	bylines := [][]string{
+		{"Plato", "Aristotle"},
+		{"Andrew Hunt", "David Thomas"},
+		{"J. R. R. Tolkien"},
+	}
+
+	for _, names := range bylines {
+		book := &Book{}
+		for _, name := range names {
+			book.Authors = append(book.Authors, &BookAuthor{Name: name, Role: RoleAuthor})
+		}
+
+		split := SplitByline(book.Byline())
+		got := make([]string, 0, len(split))
+		for _, author := range split {
+			got = append(got, author.Name)
+		}
+		if !reflect.DeepEqual(got, names) {
+			t.Errorf("%q: got %q back", book.Byline(), got)
+		}
+	}
+}
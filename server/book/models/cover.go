@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Cover is the metadata of a book cover image
+type Cover struct {
+	BookID      int64     `json:"book_id" db:"book_id"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Width       int       `json:"width" db:"width"`
+	Height      int       `json:"height" db:"height"`
+	Size        int64     `json:"size" db:"size"`
+	ETag        string    `json:"etag" db:"etag"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at,readonly"`
+}
+
+// CoverResponse struct
+type CoverResponse struct {
+	Status  int64  `json:"status"`
+	Message string `json:"message"`
+	Data    *Cover `json:"data"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the JSON layout of Date
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date, the zero value is stored and encoded as null
+type Date struct {
+	time.Time
+}
+
+// NewDate return the date of t
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parse a YYYY-MM-DD date
+func ParseDate(s string) (Date, error) {
+	if s == "" {
+		return Date{}, nil
+	}
+
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{t}, nil
+}
+
+// String return the date as YYYY-MM-DD or empty when zero
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(DateLayout)
+}
+
+// MarshalJSON implement json.Marshaler
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implement json.Unmarshaler
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := ParseDate(*s)
+	if err != nil {
+		return fmt.Errorf("date must be formatted as %s", DateLayout)
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalText implement encoding.TextMarshaler, the zero date is empty
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implement encoding.TextUnmarshaler
+func (d *Date) UnmarshalText(data []byte) error {
+	parsed, err := ParseDate(string(data))
+	if err != nil {
+		return fmt.Errorf("date must be formatted as %s", DateLayout)
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan implement sql.Scanner
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v)
+	default:
+		return fmt.Errorf("models: cannot scan %T into Date", value)
+	}
+	return nil
+}
+
+// Value implement driver.Valuer
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
@@ -0,0 +1,30 @@
+package models
+
+// Book events written to the outbox, the payload is the book
+const (
+	EventBookCreated = "book.created"
+	EventBookUpdated = "book.updated"
+	EventBookDeleted = "book.deleted"
+
+	// EventBookCoverUpdated payload is the cover of the book
+	EventBookCoverUpdated = "book.cover_updated"
+
+	// BookAggregate is the aggregate type of book events
+	BookAggregate = "book"
+	// BookEventVersion is the schema version of the book event payload
+	BookEventVersion = 1
+)
+
+// Bulk book events of the imports, seeds and truncations, the payload is a BulkEvent. The imported books are
+// not listed, the consumers read the books of the tenant again
+const (
+	EventBookImported  = "book.imported"
+	EventBookTruncated = "book.truncated"
+)
+
+// BulkEvent is the payload of the bulk book events
+type BulkEvent struct {
+	Count int64 `json:"count"`
+	// IDs of the deleted books
+	IDs []int64 `json:"ids,omitempty"`
+}
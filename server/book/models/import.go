@@ -0,0 +1,28 @@
+package models
+
+import "fmt"
+
+// RowError is a rejected row of an import
+type RowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportResult is the outcome of an import
+type ImportResult struct {
+	Imported int64
+	Rows     int
+	Errors   []*RowError
+}
+
+// ImportResponse struct
+type ImportResponse struct {
+	Status   int64       `json:"status"`
+	Message  string      `json:"message"`
+	Imported int64       `json:"imported"`
+	Errors   []*RowError `json:"errors"`
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
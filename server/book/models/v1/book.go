@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+)
+
+// Book is a book in the version 1 of the API. Its shape is frozen, the changes of models.Book are mapped
+// by NewBook and Model
+type Book struct {
+	ID            int64         `json:"id" xml:"id"`
+	ISBN10        string        `json:"isbn10" xml:"isbn10" example:"0134190440"`
+	ISBN13        string        `json:"isbn13" xml:"isbn13" example:"9780134190440"`
+	Title         string        `json:"title" xml:"title" validate:"required"`
+	Subtitle      string        `json:"subtitle" xml:"subtitle"`
+	Author        string        `json:"author" xml:"author"`
+	Authors       []*BookAuthor `json:"authors" xml:"authors>author"`
+	Publisher     string        `json:"publisher" xml:"publisher"`
+	PublishedDate models.Date   `json:"published_date" xml:"published_date" swaggertype:"string" format:"date"`
+	Language      string        `json:"language" xml:"language" example:"en-US"`
+	PageCount     int           `json:"page_count" xml:"page_count"`
+	Edition       string        `json:"edition" xml:"edition"`
+	Description   string        `json:"description" xml:"description"`
+	Format        string        `json:"format" xml:"format" enums:"hardcover,paperback,ebook,audiobook"`
+	// UpdatedAt is the Last-Modified of the book
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at" readonly:"true"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" readonly:"true"`
+}
+
+// BookAuthor is an author credited on a book in the version 1 of the API
+type BookAuthor struct {
+	ID       int64  `json:"id" xml:"id"`
+	Name     string `json:"name" xml:"name"`
+	Role     string `json:"role" xml:"role" enums:"author,editor,translator,illustrator"`
+	Position int    `json:"position" xml:"position"`
+}
+
+// SuccessResponseList struct
+type SuccessResponseList struct {
+	Status  int64   `json:"status" xml:"status"`
+	Message string  `json:"message" xml:"message"`
+	Data    []*Book `json:"data" xml:"data>book"`
+}
+
+// SuccessResponseObject struct
+type SuccessResponseObject struct {
+	Status  int64  `json:"status" xml:"status"`
+	Message string `json:"message" xml:"message"`
+	Data    *Book  `json:"data" xml:"data"`
+}
+
+// NewBook return the representation of book, nil when book is nil
+func NewBook(book *models.Book) *Book {
+	if book == nil {
+		return nil
+	}
+
+	b := &Book{
+		ID:            book.ID,
+		ISBN10:        book.ISBN10,
+		ISBN13:        book.ISBN13,
+		Title:         book.Title,
+		Subtitle:      book.Subtitle,
+		Author:        book.Author,
+		Authors:       make([]*BookAuthor, 0, len(book.Authors)),
+		Publisher:     book.Publisher,
+		PublishedDate: book.PublishedDate,
+		Language:      book.Language,
+		PageCount:     book.PageCount,
+		Edition:       book.Edition,
+		Description:   book.Description,
+		Format:        book.Format,
+		UpdatedAt:     book.UpdatedAt,
+		CreatedAt:     book.CreatedAt,
+	}
+	for _, author := range book.Authors {
+		b.Authors = append(b.Authors, &BookAuthor{
+			ID:       author.ID,
+			Name:     author.Name,
+			Role:     author.Role,
+			Position: author.Position,
+		})
+	}
+	return b
+}
+
+// NewBooks return the representation of books
+func NewBooks(books []*models.Book) []*Book {
+	list := make([]*Book, 0, len(books))
+	for _, book := range books {
+		list = append(list, NewBook(book))
+	}
+	return list
+}
+
+// Model return the book represented by b, an empty book when b is nil
+func (b *Book) Model() *models.Book {
+	if b == nil {
+		return new(models.Book)
+	}
+
+	book := &models.Book{
+		ID:            b.ID,
+		ISBN10:        b.ISBN10,
+		ISBN13:        b.ISBN13,
+		Title:         b.Title,
+		Subtitle:      b.Subtitle,
+		Author:        b.Author,
+		Publisher:     b.Publisher,
+		PublishedDate: b.PublishedDate,
+		Language:      b.Language,
+		PageCount:     b.PageCount,
+		Edition:       b.Edition,
+		Description:   b.Description,
+		Format:        b.Format,
+	}
+	// a book without authors keep its byline
+	if b.Authors != nil {
+		book.Authors = make([]*models.BookAuthor, 0, len(b.Authors))
+	}
+	for _, author := range b.Authors {
+		if author == nil {
+			continue
+		}
+		book.Authors = append(book.Authors, &models.BookAuthor{
+			ID:       author.ID,
+			Name:     author.Name,
+			Role:     author.Role,
+			Position: author.Position,
+		})
+	}
+	return book
+}
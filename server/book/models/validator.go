@@ -0,0 +1,43 @@
+package models
+
+import (
+	"golang.org/x/text/language"
+	validator "gopkg.in/go-playground/validator.v9"
+
+	"github.com/go-rest-api-boilerplate/util/isbn"
+	"github.com/go-rest-api-boilerplate/util/validation"
+)
+
+// validate is shared by every model, validator.Validate caches struct metadata
+var validate = newValidator()
+
+func newValidator() *validation.Validator {
+	v := validation.New()
+
+	v.RegisterValidation("isbn10", func(fl validator.FieldLevel) bool {
+		return isbn.Valid10(fl.Field().String())
+	})
+	v.RegisterValidation("isbn13", func(fl validator.FieldLevel) bool {
+		return isbn.Valid13(fl.Field().String())
+	})
+	v.RegisterValidation("bcp47", func(fl validator.FieldLevel) bool {
+		_, err := language.Parse(fl.Field().String())
+		return err == nil
+	})
+
+	v.RegisterStructValidation(bookStructLevel, Book{})
+
+	return v
+}
+
+// bookStructLevel check both ISBN refer to the same book
+func bookStructLevel(sl validator.StructLevel) {
+	book := sl.Current().Interface().(Book)
+	if book.ISBN10 == "" || book.ISBN13 == "" || !isbn.Valid10(book.ISBN10) {
+		return
+	}
+
+	if isbn13, _ := isbn.To13(book.ISBN10); isbn13 != isbn.Normalize(book.ISBN13) {
+		sl.ReportError(book.ISBN13, "isbn13", "ISBN13", "eqisbn10", "")
+	}
+}
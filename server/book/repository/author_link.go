@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/lib/pq"
+)
+
+// ErrUnknownAuthor is returned when a book credit an author id that does not exist
+var ErrUnknownAuthor = errors.New("unknown author")
+
+// nameKeySQL must match the generated authors.name_key column
+const nameKeySQL = `lower(regexp_replace(%s, '[^[:alnum:]]', '', 'g'))`
+
+const (
+	loadAuthorsQuery = `SELECT ba.book_id, a.id, a.name, ba.role, ba.position
+  FROM book_authors ba
+  JOIN authors a ON a.id = ba.author_id
+ WHERE ba.book_id = ANY($1)
+ ORDER BY ba.book_id, ba.position`
+
+	// upsertAuthorSuffix return the existing author of the tenant having the same name key
+	upsertAuthorSuffix = `ON CONFLICT (tenant_id, name_key) DO UPDATE SET name = authors.name RETURNING id, name`
+
+	updateBylineQuery = `UPDATE books SET author = $1 WHERE id = $2`
+)
+
+// import linking, run after the staged books are inserted for the tenant $1
+var (
+	importAuthorsQuery = fmt.Sprintf(`INSERT INTO authors (name, tenant_id)
+SELECT s.author_name, $1
+  FROM %[1]s, split_byline(%[1]s.author) AS s
+ON CONFLICT (tenant_id, name_key) DO NOTHING`, bookImportTable)
+
+	importUnlinkQuery = fmt.Sprintf(`DELETE FROM book_authors ba
+ USING books b, %s i
+ WHERE ba.book_id = b.id AND b.tenant_id = $1 AND b.title = i.title AND b.author = i.author AND ba.role = 'author'`,
+		bookImportTable)
+
+	importLinkQuery = fmt.Sprintf(`INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT b.id, a.id, 'author', min(s.author_position)
+  FROM books b
+  JOIN (SELECT DISTINCT title, author FROM %s) i ON b.title = i.title AND b.author = i.author
+ CROSS JOIN LATERAL split_byline(b.author) AS s
+  JOIN authors a ON a.tenant_id = b.tenant_id AND a.name_key = %s
+ WHERE b.tenant_id = $1
+ GROUP BY b.id, a.id
+ON CONFLICT DO NOTHING`, bookImportTable, fmt.Sprintf(nameKeySQL, "s.author_name"))
+)
+
+// loadAuthors set the credited authors of books
+func loadAuthors(runner sq.BaseRunner, books []*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(books))
+	index := make(map[int64]*models.Book, len(books))
+	for _, book := range books {
+		book.Authors = make([]*models.BookAuthor, 0)
+		ids = append(ids, book.ID)
+		index[book.ID] = book
+	}
+
+	rows, err := runner.Query(loadAuthorsQuery, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int64
+		author := new(models.BookAuthor)
+		if err := rows.Scan(&bookID, &author.ID, &author.Name, &author.Role, &author.Position); err != nil {
+			return err
+		}
+		if book, ok := index[bookID]; ok {
+			book.Authors = append(book.Authors, author)
+		}
+	}
+
+	return rows.Err()
+}
+
+// syncAuthors replace the credits of book with authors of its tenant, the byline is split when no author is given
+func syncAuthors(runner sq.BaseRunner, tenantID string, book *models.Book) error {
+	authors := book.Authors
+	if len(authors) == 0 {
+		authors = models.SplitByline(book.Author)
+	}
+
+	for _, author := range authors {
+		var err error
+		if author.ID == 0 {
+			err = sq.Insert(authorTable).
+				Columns(authorNameColumn, tenantIDColumn).
+				Values(author.Name, tenantID).
+				Suffix(upsertAuthorSuffix).
+				RunWith(runner).
+				PlaceholderFormat(sq.Dollar).
+				QueryRow().
+				Scan(&author.ID, &author.Name)
+		} else {
+			err = sq.Select(authorNameColumn).
+				From(authorTable).
+				Where(sq.Eq{idColumn: author.ID, tenantIDColumn: tenantID}).
+				RunWith(runner).
+				PlaceholderFormat(sq.Dollar).
+				QueryRow().
+				Scan(&author.Name)
+			if err == sql.ErrNoRows {
+				err = fmt.Errorf("%w #%d", ErrUnknownAuthor, author.ID)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := sq.Delete(bookAuthorTable).
+		Where(sq.Eq{bookAuthorBookIDColumn: book.ID}).
+		RunWith(runner).
+		PlaceholderFormat(sq.Dollar).
+		Exec()
+	if err != nil {
+		return err
+	}
+
+	if len(authors) > 0 {
+		insert := sq.Insert(bookAuthorTable).
+			Columns(bookAuthorBookIDColumn, bookAuthorAuthorIDColumn, bookAuthorRoleColumn, bookAuthorPositionColumn).
+			Suffix("ON CONFLICT DO NOTHING")
+		for i, author := range authors {
+			author.Position = i
+			insert = insert.Values(book.ID, author.ID, author.Role, author.Position)
+		}
+
+		_, err = insert.RunWith(runner).PlaceholderFormat(sq.Dollar).Exec()
+		if err != nil {
+			return err
+		}
+	}
+
+	book.Authors = authors
+	if byline := book.Byline(); byline != book.Author {
+		if _, err := runner.Exec(updateBylineQuery, byline, book.ID); err != nil {
+			return err
+		}
+		book.Author = byline
+	}
+
+	return nil
+}
+
+// linkImportedAuthors credit the byline authors of every staged book of the tenant
+func linkImportedAuthors(runner sq.BaseRunner, tenantID string) error {
+	for _, query := range []string{importAuthorsQuery, importUnlinkQuery, importLinkQuery} {
+		if _, err := runner.Exec(query, tenantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,73 +3,157 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
 	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/lib/pq"
 )
 
 // BookRepository to get book data from databasesa
 type BookRepository interface {
-	List() ([]*models.Book, error)
-	Find(id int64) (*models.Book, error)
+	List(ctx context.Context, filter *models.BookFilter) ([]*models.Book, error)
+	Stream(ctx context.Context, filter *models.BookFilter, fn func(*models.Book) error) error
+	Import(ctx context.Context, next func() (*models.Book, error)) (int64, error)
+	Find(ctx context.Context, id int64) (*models.Book, error)
 	Insert(ctx context.Context, book *models.Book) (*models.Book, error)
 	Update(ctx context.Context, book *models.Book) (*models.Book, error)
 	Delete(ctx context.Context, id int64) error
+	Upsert(ctx context.Context, key string, book *models.Book) (*models.Book, error)
+	Truncate(ctx context.Context) ([]int64, error)
+	Touch(ctx context.Context, ids []int64) error
+	FindCover(ctx context.Context, bookID int64) (*models.Cover, error)
+	FindCovers(ctx context.Context, bookIDs []int64) ([]*models.Cover, error)
+	SaveCover(ctx context.Context, cover *models.Cover) (*models.Cover, error)
 }
 
+// ErrBookConflict is returned when a unique book attribute is already used
+var ErrBookConflict = errors.New("book already exists")
+
 //InitBookRepository struct
 type InitBookRepository struct {
-	connection *sql.DB
+	router *dbrouter.Router
 }
 
-// NewBookRepository return new instance of BookRepository
-func NewBookRepository(connection *sql.DB) BookRepository {
+// NewBookRepository return new instance of BookRepository, reading from the replicas of router
+func NewBookRepository(router *dbrouter.Router) BookRepository {
 	return &InitBookRepository{
-		connection: connection,
+		router: router,
 	}
 }
 
-//List func
-func (init *InitBookRepository) List() (list []*models.Book, err error) {
+func (init *InitBookRepository) listBuilder(tenantID string, filter *models.BookFilter) sq.SelectBuilder {
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	builder := psql.Select(BookColumns...).From(bookTable).OrderBy("id ASC")
+	builder := psql.Select(bookMapping.Columns()...).
+		From(bookTable).
+		Where(sq.Eq{tenantIDColumn: tenantID}).
+		OrderBy("id ASC")
+
+	if filter == nil {
+		return builder
+	}
+	if len(filter.IDs) > 0 {
+		builder = builder.Where(sq.Eq{idColumn: filter.IDs})
+	}
+	if filter.Title != "" {
+		builder = builder.Where(sq.ILike{bookTitleColumn: "%" + filter.Title + "%"})
+	}
+	if filter.Author != "" {
+		builder = builder.Where(sq.ILike{bookAuthorColumn: "%" + filter.Author + "%"})
+	}
+	if filter.AuthorID != 0 {
+		builder = builder.Where(sq.Expr(fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE %s = %s.%s AND %s = ?)",
+			bookAuthorTable, bookAuthorBookIDColumn, bookTable, idColumn, bookAuthorAuthorIDColumn), filter.AuthorID))
+	}
+	if filter.PerPage > 0 {
+		page := filter.Page
+		if page == 0 {
+			page = 1
+		}
+		builder = builder.Limit(filter.PerPage).Offset((page - 1) * filter.PerPage)
+	}
 
-	rows, err := builder.RunWith(init.connection).Query()
+	return builder
+}
 
+//List func
+func (init *InitBookRepository) List(ctx context.Context, filter *models.BookFilter) (list []*models.Book, err error) {
 	list = make([]*models.Book, 0)
 
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return list, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Read(ctx))
+	if err != nil {
+		return list, err
+	}
+
+	builder := init.listBuilder(tenantID, filter)
+
+	rows, err := builder.RunWith(trxn.DB).Query()
 	if err != nil {
 		return list, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var book *models.Book
-		book, err = models.ScanBook(rows)
+		book, err = scanBook(rows)
 		if err != nil {
 			return
 		}
 		list = append(list, book)
 	}
 
+	if err = rows.Err(); err != nil {
+		return list, err
+	}
+
+	err = loadAuthors(trxn.DB, list)
 	return list, err
 }
 
 //Find func
-func (init *InitBookRepository) Find(id int64) (book *models.Book, err error) {
+func (init *InitBookRepository) Find(ctx context.Context, id int64) (book *models.Book, err error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return book, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Read(ctx))
+	if err != nil {
+		return book, err
+	}
+
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-	builder := psql.Select(BookColumns...).
+	builder := psql.Select(bookMapping.Columns()...).
 		From(bookTable).
-		Where(sq.Eq{idColumn: id})
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
 
-	rows, err := builder.RunWith(init.connection).Query()
+	rows, err := builder.RunWith(trxn.DB).Query()
 	if err != nil {
 		return book, err
 	}
+	defer rows.Close()
 
 	if rows.Next() {
-		book, err = models.ScanBook(rows)
+		book, err = scanBook(rows)
+	}
+
+	// a transaction connection cannot run a query while rows are open
+	rows.Close()
+
+	if book != nil && err == nil {
+		err = loadAuthors(trxn.DB, []*models.Book{book})
 	}
 
 	return book, err
@@ -77,19 +161,31 @@ func (init *InitBookRepository) Find(id int64) (book *models.Book, err error) {
 
 //Insert func
 func (init *InitBookRepository) Insert(ctx context.Context, book *models.Book) (*models.Book, error) {
-	trxn, err := dbtrxn.Use(ctx, init.connection)
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return book, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return book, err
+	}
 
 	query := sq.Insert(bookTable).
-		Columns(bookTitleColumn, bookAuthorColumn).
-		Values(book.Title, book.Author).
+		Columns(bookMapping.WritableColumns()...).
+		Columns(tenantIDColumn).
+		Values(append(bookMapping.Values(book), tenantID)...).
 		Suffix("RETURNING \"id\"").
 		RunWith(trxn.DB).
 		PlaceholderFormat(sq.Dollar)
 
 	err = query.QueryRow().Scan(&book.ID)
+	if err == nil {
+		err = syncAuthors(trxn.DB, tenantID, book)
+	}
 	if err != nil {
 		trxn.SetError(err)
-		return book, err
+		return book, conflictError(err)
 	}
 
 	return book, err
@@ -97,20 +193,36 @@ func (init *InitBookRepository) Insert(ctx context.Context, book *models.Book) (
 
 //Update func
 func (init *InitBookRepository) Update(ctx context.Context, book *models.Book) (*models.Book, error) {
-	trxn, err := dbtrxn.Use(ctx, init.connection)
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return book, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return book, err
+	}
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 	builder := psql.Update(bookTable).
-		Set(bookTitleColumn, book.Title).
-		Set(bookAuthorColumn, book.Author).
+		SetMap(bookMapping.SetMap(book)).
 		Set(updatedAtColumn, time.Now()).
-		Where(sq.Eq{idColumn: book.ID})
+		Where(sq.Eq{idColumn: book.ID, tenantIDColumn: tenantID})
 
-	_, err = builder.RunWith(trxn.DB).Exec()
+	result, err := builder.RunWith(trxn.DB).Exec()
+	if err == nil {
+		// the books of the other tenants are never credited
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			err = ErrBookNotFound
+		}
+	}
+	if err == nil {
+		err = syncAuthors(trxn.DB, tenantID, book)
+	}
 
 	if err != nil {
 		trxn.SetError(err)
-		return book, err
+		return book, conflictError(err)
 	}
 
 	return book, err
@@ -118,11 +230,19 @@ func (init *InitBookRepository) Update(ctx context.Context, book *models.Book) (
 
 //Delete func
 func (init *InitBookRepository) Delete(ctx context.Context, id int64) error {
-	trxn, err := dbtrxn.Use(ctx, init.connection)
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return err
+	}
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 	builder := psql.Delete(bookTable).
-		Where(sq.Eq{idColumn: id})
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
 
 	_, err = builder.RunWith(trxn.DB).Exec()
 	if err != nil {
@@ -132,3 +252,253 @@ func (init *InitBookRepository) Delete(ctx context.Context, id int64) error {
 
 	return err
 }
+
+//Upsert func insert the book, or update the book of the tenant seeded with the same key
+func (init *InitBookRepository) Upsert(ctx context.Context, key string, book *models.Book) (*models.Book, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return book, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return book, err
+	}
+
+	query := sq.Insert(bookTable).
+		Columns(bookMapping.WritableColumns()...).
+		Columns(tenantIDColumn, bookSeedKeyColumn).
+		Values(append(bookMapping.Values(book), tenantID, key)...).
+		Suffix(upsertSuffix(bookSeedKeyColumn) + " RETURNING \"id\"").
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	err = query.QueryRow().Scan(&book.ID)
+	if err == nil {
+		err = syncAuthors(trxn.DB, tenantID, book)
+	}
+	if err != nil {
+		trxn.SetError(err)
+		return book, conflictError(err)
+	}
+
+	return book, err
+}
+
+//Truncate func delete every book of the tenant, it return the ids of the deleted books
+func (init *InitBookRepository) Truncate(ctx context.Context) (ids []int64, err error) {
+	ids = make([]int64, 0)
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return ids, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return ids, err
+	}
+
+	rows, err := trxn.DB.Query(fmt.Sprintf("DELETE FROM %s WHERE %s = $1 RETURNING %s", bookTable, tenantIDColumn,
+		idColumn), tenantID)
+	if err != nil {
+		trxn.SetError(err)
+		return ids, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			trxn.SetError(err)
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		trxn.SetError(err)
+	}
+
+	return ids, err
+}
+
+//Touch func tell the repository the books were written by another repository in the transaction of ctx,
+//such as the bylines of an author rename. The rows are already written, nothing is left to do
+func (init *InitBookRepository) Touch(ctx context.Context, ids []int64) error {
+	return nil
+}
+
+//Stream func
+func (init *InitBookRepository) Stream(ctx context.Context, filter *models.BookFilter, fn func(*models.Book) error) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := init.listBuilder(tenantID, filter).ToSql()
+	if err != nil {
+		return err
+	}
+
+	// server-side cursor must live in a transaction
+	tx, err := init.router.Read(ctx).BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", exportCursor, query), args...)
+	if err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM %s", exportFetchSize, exportCursor)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return err
+		}
+
+		batch := make([]*models.Book, 0, exportFetchSize)
+		for rows.Next() {
+			book, err := scanBook(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, book)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err == nil {
+			err = loadAuthors(tx, batch)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, book := range batch {
+			if err := fn(book); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+//Import func
+func (init *InitBookRepository) Import(ctx context.Context, next func() (*models.Book, error)) (count int64, err error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return count, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return count, err
+	}
+
+	tx, ok := trxn.DB.(dbtrxn.Tx)
+	if !ok {
+		return count, errors.New("repository: book import requires a transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			trxn.SetError(err)
+		}
+	}()
+
+	columns := strings.Join(bookMapping.WritableColumns(), ", ")
+	_, err = tx.Exec(fmt.Sprintf("CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+		bookImportTable, columns, bookTable))
+	if err != nil {
+		return count, err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(bookImportTable, bookMapping.WritableColumns()...))
+	if err != nil {
+		return count, err
+	}
+
+	for {
+		var book *models.Book
+		book, err = next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			_, err = stmt.Exec(bookMapping.Values(book)...)
+		}
+		if err != nil {
+			stmt.Close()
+			return count, err
+		}
+	}
+
+	// flush the COPY buffer
+	if _, err = stmt.Exec(); err != nil {
+		stmt.Close()
+		return count, err
+	}
+	if err = stmt.Close(); err != nil {
+		return count, err
+	}
+
+	// the rows update the book of the same isbn13, the last row of a duplicated isbn13 wins
+	key := fmt.Sprintf("COALESCE(%s, 'row ' || row)", bookISBN13Column)
+	result, err := tx.Exec(fmt.Sprintf(
+		"INSERT INTO %s (%s, %s) SELECT DISTINCT ON (%s) %s, $1 FROM (SELECT *, row_number() OVER () AS row FROM %s) staged "+
+			"ORDER BY %s, row DESC %s",
+		bookTable, columns, tenantIDColumn, key, columns, bookImportTable, key, upsertSuffix(bookISBN13Column)), tenantID)
+	if err != nil {
+		err = conflictError(err)
+		return count, err
+	}
+
+	count, err = result.RowsAffected()
+	if err != nil {
+		return count, err
+	}
+
+	err = linkImportedAuthors(tx, tenantID)
+	return count, err
+}
+
+// scanBook scan a row selected with the columns of bookMapping
+func scanBook(rows *sql.Rows) (*models.Book, error) {
+	book := new(models.Book)
+	if err := bookMapping.Scan(rows, book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// upsertSuffix update every writable column of the book of the tenant having the same value of the unique column
+func upsertSuffix(column string) string {
+	sets := make([]string, 0, len(bookMapping.WritableColumns())+1)
+	for _, column := range bookMapping.WritableColumns() {
+		sets = append(sets, fmt.Sprintf("\"%[1]s\" = EXCLUDED.\"%[1]s\"", column))
+	}
+	sets = append(sets, fmt.Sprintf("\"%s\" = now()", updatedAtColumn))
+
+	return fmt.Sprintf("ON CONFLICT (%s, %s) DO UPDATE SET %s", tenantIDColumn, column, strings.Join(sets, ", "))
+}
+
+// conflictError wrap unique violations into ErrBookConflict
+func conflictError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation {
+		return err
+	}
+
+	switch pqErr.Constraint {
+	case bookISBN13Constraint:
+		return fmt.Errorf("%w: isbn13 is already used by another book", ErrBookConflict)
+	}
+	return fmt.Errorf("%w: %s", ErrBookConflict, pqErr.Detail)
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/cache"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/invalidation"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"golang.org/x/sync/singleflight"
+)
+
+// Generation keys of the book cache, a new generation orphan the entries of the previous one
+const (
+	// cacheEpochKey is the generation of every book entry, renewed when books are bulk written
+	cacheEpochKey = "book:epoch"
+	// cacheListKey is the generation of the list pages, renewed by every write
+	cacheListKey = "book:list"
+)
+
+// CacheMetricsName is the expvar name of the book cache metrics
+const CacheMetricsName = "book"
+
+// Invalidated entity keys of books, "book:<tenant>:<id>" or every book
+const (
+	entityKeyPrefix = "book:"
+	entityKeyAll    = "book:*"
+)
+
+//InitCachedBookRepository struct
+type InitCachedBookRepository struct {
+	BookRepository
+	Cache     cache.Cache
+	TTL       time.Duration
+	Metrics   *cache.Metrics
+	Publisher invalidation.Publisher
+	group     singleflight.Group
+}
+
+// NewCachedBookRepository return a BookRepository reading Find and List through c. Lookups
+// inside a transaction bypass the cache and writes invalidate it once committed, the other
+// processes are notified through publisher. The books written by another repository are
+// invalidated through Touch
+func NewCachedBookRepository(repository BookRepository, c cache.Cache, ttl time.Duration,
+	publisher invalidation.Publisher) *InitCachedBookRepository {
+	return &InitCachedBookRepository{
+		BookRepository: repository,
+		Cache:          c,
+		TTL:            ttl,
+		Metrics:        cache.NewMetrics(CacheMetricsName),
+		Publisher:      publisher,
+	}
+}
+
+//Find func
+func (init *InitCachedBookRepository) Find(ctx context.Context, id int64) (*models.Book, error) {
+	tenantID := tenant.FromContext(ctx)
+	if dbtrxn.Retrieve(ctx) != nil || tenantID == "" {
+		return init.BookRepository.Find(ctx, id)
+	}
+	epoch, ok := init.generation(ctx, cacheEpochKey)
+	if !ok {
+		return init.BookRepository.Find(ctx, id)
+	}
+
+	var book *models.Book
+	err := init.load(ctx, bookCacheKey(epoch, tenantID, id), &book, func() (interface{}, error) {
+		// a lagging replica would cache a book older than its invalidation
+		book, err := init.BookRepository.Find(dbrouter.WithPrimary(ctx), id)
+		if book == nil {
+			// missing books are not cached
+			return nil, err
+		}
+		return book, err
+	})
+
+	return book, err
+}
+
+//List func
+func (init *InitCachedBookRepository) List(ctx context.Context, filter *models.BookFilter) ([]*models.Book, error) {
+	tenantID := tenant.FromContext(ctx)
+	if dbtrxn.Retrieve(ctx) != nil || tenantID == "" {
+		return init.BookRepository.List(ctx, filter)
+	}
+	epoch, ok := init.generation(ctx, cacheEpochKey)
+	var generation string
+	if ok {
+		generation, ok = init.generation(ctx, cacheListKey)
+	}
+	if !ok {
+		return init.BookRepository.List(ctx, filter)
+	}
+
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(data)
+	key := fmt.Sprintf("%s:%s:%s:%s:%s", cacheListKey, epoch, generation, tenantID, hex.EncodeToString(sum[:]))
+
+	list := make([]*models.Book, 0)
+	err = init.load(ctx, key, &list, func() (interface{}, error) {
+		return init.BookRepository.List(dbrouter.WithPrimary(ctx), filter)
+	})
+
+	return list, err
+}
+
+//Insert func
+func (init *InitCachedBookRepository) Insert(ctx context.Context, book *models.Book) (*models.Book, error) {
+	book, err := init.BookRepository.Insert(ctx, book)
+	if err == nil {
+		err = init.invalidate(ctx, book.ID)
+	}
+	return book, err
+}
+
+//Update func
+func (init *InitCachedBookRepository) Update(ctx context.Context, book *models.Book) (*models.Book, error) {
+	book, err := init.BookRepository.Update(ctx, book)
+	if err == nil {
+		err = init.invalidate(ctx, book.ID)
+	}
+	return book, err
+}
+
+//Delete func
+func (init *InitCachedBookRepository) Delete(ctx context.Context, id int64) error {
+	err := init.BookRepository.Delete(ctx, id)
+	if err == nil {
+		err = init.invalidate(ctx, id)
+	}
+	return err
+}
+
+//Upsert func
+func (init *InitCachedBookRepository) Upsert(ctx context.Context, key string, book *models.Book) (*models.Book, error) {
+	book, err := init.BookRepository.Upsert(ctx, key, book)
+	if err == nil {
+		err = init.invalidate(ctx, book.ID)
+	}
+	return book, err
+}
+
+//Truncate func
+func (init *InitCachedBookRepository) Truncate(ctx context.Context) ([]int64, error) {
+	ids, err := init.BookRepository.Truncate(ctx)
+	if err == nil {
+		err = init.flush(ctx)
+	}
+	return ids, err
+}
+
+//Import func
+func (init *InitCachedBookRepository) Import(ctx context.Context, next func() (*models.Book, error)) (int64, error) {
+	count, err := init.BookRepository.Import(ctx, next)
+	if err == nil {
+		err = init.flush(ctx)
+	}
+	return count, err
+}
+
+//Touch func invalidate the books written by another repository once the transaction of ctx is committed
+func (init *InitCachedBookRepository) Touch(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, entityKey(tenantID, id))
+	}
+
+	dbtrxn.OnCommit(ctx, func() {
+		for _, id := range ids {
+			init.evict(ctx, tenantID, id)
+		}
+	})
+	return init.publish(ctx, keys...)
+}
+
+// load decode the cached value of key into dest, or call fn once for the concurrent misses of key
+// and cache its non nil result
+func (init *InitCachedBookRepository) load(ctx context.Context, key string, dest interface{},
+	fn func() (interface{}, error)) error {
+	data, err := init.Cache.Get(ctx, key)
+	if err == nil {
+		if err = gob.NewDecoder(bytes.NewReader(data)).Decode(dest); err == nil {
+			init.Metrics.Hit()
+			return nil
+		}
+	}
+	if err != cache.ErrMiss {
+		init.Metrics.Error()
+		util.SessionLogger(ctx).Warnf("book cache get %s: %v", key, err)
+	}
+	init.Metrics.Miss()
+
+	// the callers sharing a load decode their own copy
+	shared, err, _ := init.group.Do(key, func() (interface{}, error) {
+		value, err := fn()
+		if err != nil || value == nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+			return nil, err
+		}
+		if err := init.Cache.Set(ctx, key, buf.Bytes(), init.TTL); err != nil {
+			init.Metrics.Error()
+			util.SessionLogger(ctx).Warnf("book cache set %s: %v", key, err)
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil || shared == nil {
+		return err
+	}
+
+	return gob.NewDecoder(bytes.NewReader(shared.([]byte))).Decode(dest)
+}
+
+// generation return the current generation of key, ok is false when the cache is unavailable.
+// A missing generation is created so an evicted one never revive older entries
+func (init *InitCachedBookRepository) generation(ctx context.Context, key string) (string, bool) {
+	data, err := init.Cache.Get(ctx, key)
+	if err == nil {
+		return string(data), true
+	}
+	if err == cache.ErrMiss {
+		// concurrent creations agree on the first generation
+		generation := newGeneration()
+		var created bool
+		created, err = init.Cache.SetIfAbsent(ctx, key, []byte(generation), 0)
+		if err == nil && created {
+			return generation, true
+		}
+		if err == nil {
+			data, err = init.Cache.Get(ctx, key)
+		}
+		if err == nil {
+			return string(data), true
+		}
+	}
+
+	init.Metrics.Error()
+	util.SessionLogger(ctx).Warnf("book cache generation %s: %v", key, err)
+	return "", false
+}
+
+// invalidate the book of the ctx tenant and every list page once the transaction of ctx is committed
+func (init *InitCachedBookRepository) invalidate(ctx context.Context, id int64) error {
+	tenantID := tenant.FromContext(ctx)
+	dbtrxn.OnCommit(ctx, func() {
+		init.evict(ctx, tenantID, id)
+	})
+	return init.publish(ctx, entityKey(tenantID, id))
+}
+
+// flush every book entry once the transaction of ctx is committed
+func (init *InitCachedBookRepository) flush(ctx context.Context) error {
+	dbtrxn.OnCommit(ctx, func() {
+		init.Flush(ctx)
+	})
+	return init.publish(ctx, entityKeyAll)
+}
+
+// publish the invalidated keys in the transaction of ctx, they are notified on commit
+func (init *InitCachedBookRepository) publish(ctx context.Context, keys ...string) error {
+	if init.Publisher == nil {
+		return nil
+	}
+	return init.Publisher.Publish(ctx, keys...)
+}
+
+// Invalidate implement invalidation.Handler, the keys of other entities are ignored
+func (init *InitCachedBookRepository) Invalidate(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if key == entityKeyAll {
+			init.Flush(ctx)
+			return
+		}
+		if !strings.HasPrefix(key, entityKeyPrefix) {
+			continue
+		}
+		tenantID, id := splitEntityKey(strings.TrimPrefix(key, entityKeyPrefix))
+		if id, err := strconv.ParseInt(id, 10, 64); err == nil {
+			init.evict(ctx, tenantID, id)
+		}
+	}
+}
+
+// Flush implement invalidation.Handler, a new epoch orphan every book entry
+func (init *InitCachedBookRepository) Flush(ctx context.Context) {
+	if err := init.Cache.Set(ctx, cacheEpochKey, []byte(newGeneration()), 0); err != nil {
+		init.Metrics.Error()
+		util.SessionLogger(ctx).Warnf("book cache flush: %v", err)
+	}
+}
+
+// evict the book of the tenant and every list page
+func (init *InitCachedBookRepository) evict(ctx context.Context, tenantID string, id int64) {
+	err := init.Cache.Set(ctx, cacheListKey, []byte(newGeneration()), 0)
+	if epoch, ok := init.generation(ctx, cacheEpochKey); ok && err == nil {
+		err = init.Cache.Delete(ctx, bookCacheKey(epoch, tenantID, id))
+	}
+	if err != nil {
+		init.Metrics.Error()
+		util.SessionLogger(ctx).Warnf("book cache invalidate #%d: %v", id, err)
+	}
+}
+
+// bookCacheKey scope the entries to a tenant, a book is never served to another one
+func bookCacheKey(epoch, tenantID string, id int64) string {
+	return fmt.Sprintf("book:%s:%s:%d", epoch, tenantID, id)
+}
+
+// entityKey return the invalidated key of a book, "book:<tenant>:<id>"
+func entityKey(tenantID string, id int64) string {
+	return fmt.Sprintf("%s%s:%d", entityKeyPrefix, tenantID, id)
+}
+
+// splitEntityKey return the tenant and the id of "<tenant>:<id>"
+func splitEntityKey(key string) (string, string) {
+	i := strings.LastIndex(key, ":")
+	if i < 0 {
+		return "", key
+	}
+	return key[:i], key[i+1:]
+}
+
+func newGeneration() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
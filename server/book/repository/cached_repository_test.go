@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/cache"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+// stubBookRepository serve the books of a map, Find wait for release when it is set
+type stubBookRepository struct {
+	BookRepository
+	mu      sync.Mutex
+	books   map[int64]models.Book
+	finds   int32
+	release chan struct{}
+}
+
+func (s *stubBookRepository) Find(ctx context.Context, id int64) (*models.Book, error) {
+	atomic.AddInt32(&s.finds, 1)
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	book, ok := s.books[id]
+	if !ok {
+		return nil, nil
+	}
+	return &book, nil
+}
+
+func (s *stubBookRepository) Update(ctx context.Context, book *models.Book) (*models.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books[book.ID] = *book
+	return book, nil
+}
+
+func (s *stubBookRepository) Touch(ctx context.Context, ids []int64) error {
+	return nil
+}
+
+// stubPublisher record the published keys
+type stubPublisher struct {
+	keys []string
+}
+
+func (s *stubPublisher) Publish(ctx context.Context, keys ...string) error {
+	s.keys = append(s.keys, keys...)
+	return nil
+}
+
+func newCachedRepository() (*InitCachedBookRepository, *stubBookRepository, *stubPublisher) {
+	util.Log = logrus.New()
+	base := &stubBookRepository{books: map[int64]models.Book{1: {ID: 1, Title: "Dune"}}}
+	publisher := &stubPublisher{}
+	return NewCachedBookRepository(base, cache.NewMemoryCache(0), time.Minute, publisher), base, publisher
+}
+
+func findTitle(t *testing.T, ctx context.Context, repository BookRepository, id int64) string {
+	t.Helper()
+	book, err := repository.Find(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book == nil {
+		return ""
+	}
+	return book.Title
+}
+
+func TestCachedFindSingleFlight(t *testing.T) {
+	repository, base, _ := newCachedRepository()
+	base.release = make(chan struct{})
+	ctx := tenant.With(context.Background(), "acme")
+
+	var wg sync.WaitGroup
+	titles := make([]string, 8)
+	for i := range titles {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			book, err := repository.Find(ctx, 1)
+			if err == nil && book != nil {
+				titles[i] = book.Title
+			}
+		}(i)
+	}
+
+	// the other lookups join the load in flight
+	for atomic.LoadInt32(&base.finds) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(base.release)
+	wg.Wait()
+
+	if finds := atomic.LoadInt32(&base.finds); finds != 1 {
+		t.Errorf("got %d loads, want the concurrent misses sharing 1", finds)
+	}
+	for i, title := range titles {
+		if title != "Dune" {
+			t.Errorf("lookup %d got %q", i, title)
+		}
+	}
+}
+
+func TestCachedFind(t *testing.T) {
+	repository, base, _ := newCachedRepository()
+	ctx := tenant.With(context.Background(), "acme")
+
+	findTitle(t, ctx, repository, 1)
+	findTitle(t, ctx, repository, 1)
+	if base.finds != 1 {
+		t.Errorf("got %d loads, want the second lookup cached", base.finds)
+	}
+
+	// another tenant and a transaction do not share the entry
+	findTitle(t, tenant.With(context.Background(), "globex"), repository, 1)
+	txCtx := ctx
+	dbtrxn.Begin(&txCtx)
+	findTitle(t, txCtx, repository, 1)
+	findTitle(t, context.Background(), repository, 1)
+	if base.finds != 4 {
+		t.Errorf("got %d loads, want the cache bypassed", base.finds)
+	}
+
+	// missing books are not cached
+	findTitle(t, ctx, repository, 2)
+	findTitle(t, ctx, repository, 2)
+	if base.finds != 6 {
+		t.Errorf("got %d loads, want the missing book loaded again", base.finds)
+	}
+}
+
+func TestCachedInvalidationOnCommit(t *testing.T) {
+	repository, base, publisher := newCachedRepository()
+	ctx := tenant.With(context.Background(), "acme")
+	findTitle(t, ctx, repository, 1)
+
+	txCtx := ctx
+	commit := dbtrxn.Begin(&txCtx)
+	if _, err := repository.Update(txCtx, &models.Book{ID: 1, Title: "Dune Messiah"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(publisher.keys, []string{"book:acme:1"}) {
+		t.Errorf("got published keys %v", publisher.keys)
+	}
+	// the other transactions read the committed book until the commit
+	if title := findTitle(t, ctx, repository, 1); title != "Dune" {
+		t.Errorf("got %q before the commit, want the cached book", title)
+	}
+	if err := commit(); err != nil {
+		t.Fatal(err)
+	}
+	if title := findTitle(t, ctx, repository, 1); title != "Dune Messiah" {
+		t.Errorf("got %q after the commit, want the updated book", title)
+	}
+
+	// a rolled back write does not evict the entry
+	txCtx = ctx
+	commit = dbtrxn.Begin(&txCtx)
+	if _, err := repository.Update(txCtx, &models.Book{ID: 1, Title: "Children of Dune"}); err != nil {
+		t.Fatal(err)
+	}
+	dbtrxn.Retrieve(txCtx).Err = errors.New("rollback")
+	commit()
+	if title := findTitle(t, ctx, repository, 1); title != "Dune Messiah" {
+		t.Errorf("got %q after the rollback, want the cached book", title)
+	}
+	if base.finds != 2 {
+		t.Errorf("got %d loads, want 2", base.finds)
+	}
+}
+
+func TestCachedTouch(t *testing.T) {
+	repository, base, publisher := newCachedRepository()
+	ctx := tenant.With(context.Background(), "acme")
+	findTitle(t, ctx, repository, 1)
+
+	// the book is renamed by another repository
+	base.books[1] = models.Book{ID: 1, Title: "Dune", Author: "Frank Herbert"}
+	txCtx := ctx
+	commit := dbtrxn.Begin(&txCtx)
+	if err := repository.Touch(txCtx, []int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(publisher.keys, []string{"book:acme:1", "book:acme:2"}) {
+		t.Errorf("got published keys %v", publisher.keys)
+	}
+	if book, _ := repository.Find(ctx, 1); book.Author != "" {
+		t.Errorf("got %q before the commit, want the cached book", book.Author)
+	}
+	if err := commit(); err != nil {
+		t.Fatal(err)
+	}
+	if book, _ := repository.Find(ctx, 1); book.Author != "Frank Herbert" {
+		t.Errorf("got %q after the commit, want the renamed book", book.Author)
+	}
+}
+
+func TestCachedInvalidate(t *testing.T) {
+	repository, base, _ := newCachedRepository()
+	ctx := tenant.With(context.Background(), "acme")
+	findTitle(t, ctx, repository, 1)
+
+	// the keys of other tenants and entities are ignored
+	repository.Invalidate(ctx, []string{"book:globex:1", "author:acme:1"})
+	findTitle(t, ctx, repository, 1)
+	if base.finds != 1 {
+		t.Errorf("got %d loads, want the entry kept", base.finds)
+	}
+
+	repository.Invalidate(ctx, []string{"book:acme:1"})
+	findTitle(t, ctx, repository, 1)
+	repository.Invalidate(ctx, []string{entityKeyAll})
+	findTitle(t, ctx, repository, 1)
+	if base.finds != 3 {
+		t.Errorf("got %d loads, want the entry evicted twice", base.finds)
+	}
+}
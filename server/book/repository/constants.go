@@ -1,22 +1,73 @@
 package repository
 
+import (
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/util/dbmap"
+)
+
 // Table Name
 const (
 	bookTable = "books"
+
+	// bookImportTable is the per-transaction staging table of an import
+	bookImportTable = "books_import"
+
+	authorTable     = "authors"
+	bookAuthorTable = "book_authors"
+	coverTable      = "book_covers"
+)
+
+// Export cursor
+const (
+	exportCursor    = "books_export"
+	exportFetchSize = 500
 )
 
 // Table Column Names
 const (
 	idColumn        = "id"
+	tenantIDColumn  = "tenant_id"
 	updatedAtColumn = "updated_at"
 	createdAtColumn = "created_at"
 
 	// Book Table Column Names
-	bookTitleColumn  = "title"
-	bookAuthorColumn = "author"
+	bookTitleColumn   = "title"
+	bookAuthorColumn  = "author"
+	bookISBN13Column  = "isbn13"
+	bookSeedKeyColumn = "seed_key"
+)
+
+// Author Table Column Names
+const (
+	authorNameColumn = "name"
+)
+
+// Book Author Table Column Names
+const (
+	bookAuthorBookIDColumn   = "book_id"
+	bookAuthorAuthorIDColumn = "author_id"
+	bookAuthorRoleColumn     = "role"
+	bookAuthorPositionColumn = "position"
+)
+
+// Cover Table Column Names
+const (
+	coverBookIDColumn = "book_id"
+)
+
+// postgres error codes
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Constraint Names
+const (
+	bookISBN13Constraint = "books_isbn13_key"
 )
 
-// Table Columns
+// Table Columns, mapped from the db tags of the models
 var (
-	BookColumns = []string{idColumn, bookTitleColumn, bookAuthorColumn, updatedAtColumn, createdAtColumn}
+	bookMapping  = dbmap.Of(&models.Book{})
+	coverMapping = dbmap.Of(&models.Cover{})
 )
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/lib/pq"
+)
+
+// ErrBookNotFound is returned when a book does not exist
+var ErrBookNotFound = errors.New("book not found")
+
+// coverTenantScope keep the covers of the books of the tenant
+const coverTenantScope = "EXISTS (SELECT 1 FROM books WHERE books.id = book_covers.book_id AND books.tenant_id = ?)"
+
+//FindCover func
+func (init *InitBookRepository) FindCover(ctx context.Context, bookID int64) (cover *models.Cover, err error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return cover, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Read(ctx))
+	if err != nil {
+		return cover, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(coverMapping.Columns()...).
+		From(coverTable).
+		Where(sq.Eq{coverBookIDColumn: bookID}).
+		Where(coverTenantScope, tenantID)
+
+	rows, err := builder.RunWith(trxn.DB).Query()
+	if err != nil {
+		return cover, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		cover, err = scanCover(rows)
+	}
+
+	return cover, err
+}
+
+//FindCovers func
+func (init *InitBookRepository) FindCovers(ctx context.Context, bookIDs []int64) (list []*models.Cover, err error) {
+	list = make([]*models.Cover, 0, len(bookIDs))
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return list, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Read(ctx))
+	if err != nil {
+		return list, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(coverMapping.Columns()...).
+		From(coverTable).
+		Where(sq.Eq{coverBookIDColumn: bookIDs}).
+		Where(coverTenantScope, tenantID)
+
+	rows, err := builder.RunWith(trxn.DB).Query()
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cover *models.Cover
+		cover, err = scanCover(rows)
+		if err != nil {
+			return
+		}
+		list = append(list, cover)
+	}
+
+	return list, rows.Err()
+}
+
+//SaveCover func
+func (init *InitBookRepository) SaveCover(ctx context.Context, cover *models.Cover) (*models.Cover, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return cover, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return cover, err
+	}
+
+	// the books of the other tenants do not exist
+	var found int
+	err = sq.Select("1").
+		From(bookTable).
+		Where(sq.Eq{idColumn: cover.BookID, tenantIDColumn: tenantID}).
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar).
+		QueryRow().
+		Scan(&found)
+	if err == sql.ErrNoRows {
+		return cover, ErrBookNotFound
+	}
+	if err != nil {
+		trxn.SetError(err)
+		return cover, err
+	}
+
+	query := sq.Insert(coverTable).
+		Columns(coverMapping.WritableColumns()...).
+		Values(coverMapping.Values(cover)...).
+		Suffix(coverUpsertSuffix() + " RETURNING \"updated_at\"").
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	err = query.QueryRow().Scan(&cover.UpdatedAt)
+	if err != nil {
+		trxn.SetError(err)
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return cover, ErrBookNotFound
+		}
+		return cover, err
+	}
+
+	return cover, err
+}
+
+// scanCover scan a row selected with the columns of coverMapping
+func scanCover(rows *sql.Rows) (*models.Cover, error) {
+	cover := new(models.Cover)
+	if err := coverMapping.Scan(rows, cover); err != nil {
+		return nil, err
+	}
+	return cover, nil
+}
+
+// coverUpsertSuffix replace the cover of the book
+func coverUpsertSuffix() string {
+	sets := make([]string, 0, len(coverMapping.WritableColumns()))
+	for _, column := range coverMapping.WritableColumns() {
+		if column != coverBookIDColumn {
+			sets = append(sets, fmt.Sprintf("\"%[1]s\" = EXCLUDED.\"%[1]s\"", column))
+		}
+	}
+	sets = append(sets, fmt.Sprintf("\"%s\" = now()", updatedAtColumn))
+
+	return fmt.Sprintf("ON CONFLICT (\"%s\") DO UPDATE SET %s", coverBookIDColumn, strings.Join(sets, ", "))
+}
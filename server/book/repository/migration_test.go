@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+)
+
+const migrationSource = "file://../../../scripts/migration/book"
+
+// openMigrationDatabase create an empty database on the server of DB_TEST_URL, it is dropped after the test
+func openMigrationDatabase(t *testing.T) (*sql.DB, string) {
+	serverURL := os.Getenv("DB_TEST_URL")
+	if serverURL == "" {
+		t.Skip("DB_TEST_URL is not set")
+	}
+	server, err := sql.Open("postgres", serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	name := fmt.Sprintf("book_migration_test_%d", time.Now().UnixNano())
+	if _, err := server.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatal(err)
+	}
+	databaseURL, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	databaseURL.Path = "/" + name
+
+	db, err := sql.Open("postgres", databaseURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := server.Exec("DROP DATABASE " + name); err != nil {
+			t.Errorf("drop %s: %v", name, err)
+		}
+	})
+	return db, databaseURL.String()
+}
+
+// credits return the authors of every book title in order, read with the bypass role
+func credits(t *testing.T, db *sql.DB) map[string][]string {
+	rows, err := db.Query(`SELECT b.title, a.name
+  FROM books b
+  JOIN book_authors ba ON ba.book_id = b.id AND ba.role = 'author'
+  JOIN authors a ON a.id = ba.author_id
+ ORDER BY b.title, ba.position`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	result := make(map[string][]string)
+	for rows.Next() {
+		var title, name string
+		if err := rows.Scan(&title, &name); err != nil {
+			t.Fatal(err)
+		}
+		result[title] = append(result[title], name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// TestSplitBylineMigration credit again the books whose byline the author migration misread, like the two
+// people of "Plato, Aristotle" credited as "Aristotle Plato"
+func TestSplitBylineMigration(t *testing.T) {
+	db, databaseURL := openMigrationDatabase(t)
+	migration, err := migrate.New(migrationSource, databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migration.Close()
+
+	// the books written before the authors
+	if err := migration.Migrate(3); err != nil {
+		t.Fatal(err)
+	}
+	bylines := map[string]string{
+		"Dialogues":     "Plato, Aristotle",
+		"Earthsea":      "Le Guin, Ursula",
+		"Pragmatic":     "Hunt, Andrew and Thomas, David",
+		"White Teeth":   "Smith, Zadie",
+		"Design Things": "Erich Gamma, Richard Helm",
+	}
+	for title, byline := range bylines {
+		if _, err := db.Exec("INSERT INTO books (title, author) VALUES ($1, $2)", title, byline); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := migration.Migrate(13); err != nil {
+		t.Fatal(err)
+	}
+
+	// the session of the test see every tenant
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("SET ROLE tenant_bypass"); err != nil {
+		t.Fatal(err)
+	}
+	if got := credits(t, db)["Dialogues"]; !reflect.DeepEqual(got, []string{"Aristotle Plato"}) {
+		t.Fatalf("got %q credited before the fix, want the misread name", got)
+	}
+	// the books credited through their authors since are kept
+	_, err = db.Exec(`WITH author AS (
+  INSERT INTO authors (name, tenant_id) VALUES ('Z. Smith', 'default') RETURNING id
+)
+UPDATE book_authors SET author_id = (SELECT id FROM author)
+ WHERE book_id = (SELECT id FROM books WHERE title = 'White Teeth')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("RESET ROLE"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migration.Migrate(14); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("SET ROLE tenant_bypass"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"Dialogues":     {"Plato", "Aristotle"},
+		"Earthsea":      {"Ursula Le Guin"},
+		"Pragmatic":     {"Hunt", "Andrew", "Thomas", "David"},
+		"White Teeth":   {"Z. Smith"},
+		"Design Things": {"Erich Gamma", "Richard Helm"},
+	}
+	if got := credits(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("got credits %q, want %q", got, want)
+	}
+
+	var misread int
+	if err := db.QueryRow("SELECT count(*) FROM authors WHERE name = 'Aristotle Plato'").Scan(&misread); err != nil {
+		t.Fatal(err)
+	}
+	if misread != 0 {
+		t.Error("the misread author without book is kept")
+	}
+
+	// the function of the migrations split like the models
+	for _, byline := range append([]string{"Van Rossum ,Guido; Tolkien, J. R. R. & Plato"}, valuesOf(bylines)...) {
+		rows, err := db.QueryContext(context.Background(),
+			"SELECT author_name FROM split_byline($1) ORDER BY author_position", byline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0)
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, name)
+		}
+		rows.Close()
+
+		want := make([]string, 0)
+		for _, author := range models.SplitByline(byline) {
+			want = append(want, author.Name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("split_byline(%q) = %q, models.SplitByline = %q", byline, got, want)
+		}
+	}
+
+	// the credits are kept by a rollback, the migration applied again leave them as they are
+	if _, err := db.Exec("RESET ROLE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := migration.Steps(-1); err != nil {
+		t.Fatal(err)
+	}
+	if err := migration.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("SET ROLE tenant_bypass"); err != nil {
+		t.Fatal(err)
+	}
+	if got := credits(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("got credits %q migrated again, want %q", got, want)
+	}
+}
+
+func valuesOf(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
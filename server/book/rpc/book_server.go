@@ -0,0 +1,244 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/book/bookpb"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/go-rest-api-boilerplate/server/book/stream"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Page size of ListBooks
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var watchTypes = map[string]bool{
+	models.EventBookCreated: true,
+	models.EventBookUpdated: true,
+	models.EventBookDeleted: true,
+}
+
+//InitBookServer struct
+type InitBookServer struct {
+	bookpb.UnimplementedBookServiceServer
+	Book service.BookService
+	Hub  *stream.Hub
+}
+
+//NewBookServer func
+func NewBookServer(bookService service.BookService, hub *stream.Hub) bookpb.BookServiceServer {
+	return &InitBookServer{
+		Book: bookService,
+		Hub:  hub,
+	}
+}
+
+//GetBook func
+func (init *InitBookServer) GetBook(ctx context.Context, req *bookpb.GetBookRequest) (*bookpb.Book, error) {
+	book, err := init.Book.GetBook(ctx, req.GetId())
+	if err == nil && book == nil {
+		err = service.ErrBookNotFound
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toProto(book), nil
+}
+
+//ListBooks func
+func (init *InitBookServer) ListBooks(ctx context.Context, req *bookpb.ListBooksRequest) (*bookpb.ListBooksResponse, error) {
+	pageSize := req.GetPageSize()
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize < 0 || pageSize > MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", MaxPageSize)
+	}
+
+	page, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	books, err := init.Book.ListBook(ctx, &models.BookFilter{
+		Title:    req.GetTitle(),
+		Author:   req.GetAuthor(),
+		AuthorID: req.GetAuthorId(),
+		Page:     page,
+		PerPage:  uint64(pageSize),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &bookpb.ListBooksResponse{Books: make([]*bookpb.Book, 0, len(books))}
+	for _, book := range books {
+		resp.Books = append(resp.Books, toProto(book))
+	}
+	// a full page may be followed by an empty one
+	if len(books) == int(pageSize) {
+		resp.NextPageToken = encodePageToken(page + 1)
+	}
+
+	return resp, nil
+}
+
+//CreateBook func
+func (init *InitBookServer) CreateBook(ctx context.Context, req *bookpb.CreateBookRequest) (*bookpb.Book, error) {
+	book, err := validBook(req.GetBook())
+	if err != nil {
+		return nil, err
+	}
+
+	// the written book is read back from the primary
+	ctx = dbrouter.WithReadYourWrites(ctx)
+	book, err = init.Book.CreateBook(ctx, book)
+	if err == nil {
+		book, err = init.Book.GetBook(ctx, book.ID)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toProto(book), nil
+}
+
+//UpdateBook func
+func (init *InitBookServer) UpdateBook(ctx context.Context, req *bookpb.UpdateBookRequest) (*bookpb.Book, error) {
+	book, err := validBook(req.GetBook())
+	if err != nil {
+		return nil, err
+	}
+
+	// the written book is read back from the primary
+	ctx = dbrouter.WithReadYourWrites(ctx)
+	book, err = init.Book.UpdateBook(ctx, book)
+	if err == nil {
+		book, err = init.Book.GetBook(ctx, book.ID)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toProto(book), nil
+}
+
+//DeleteBook func
+func (init *InitBookServer) DeleteBook(ctx context.Context, req *bookpb.DeleteBookRequest) (*emptypb.Empty, error) {
+	if err := init.Book.DeleteBook(ctx, req.GetId()); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+//WatchBooks func stream the book changes like the /book/stream endpoint
+func (init *InitBookServer) WatchBooks(req *bookpb.WatchBooksRequest, srv bookpb.BookService_WatchBooksServer) error {
+	for _, t := range req.GetTypes() {
+		if !watchTypes[t] {
+			return status.Errorf(codes.InvalidArgument, "unknown event type %q", t)
+		}
+	}
+
+	subscription, replay, ok := init.Hub.Subscribe(tenant.FromContext(srv.Context()), req.GetTypes(),
+		req.GetLastEventId())
+	defer subscription.Close()
+
+	if !ok {
+		replay = append([]*stream.Event{{Type: stream.EventReset, At: time.Now()}}, replay...)
+	}
+	for _, event := range replay {
+		if err := srv.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case event, open := <-subscription.C:
+			if !open {
+				// too slow, the client reconnects and resumes
+				return status.Error(codes.Unavailable, "subscription lagged behind, resume from the last event")
+			}
+			if err := srv.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// validBook convert, normalize and validate a book message like the REST body
+func validBook(message *bookpb.Book) (*models.Book, error) {
+	if message == nil {
+		return nil, status.Error(codes.InvalidArgument, "book is required")
+	}
+
+	book, err := fromProto(message)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "published_date must be formatted as %s", models.DateLayout)
+	}
+
+	book.Normalize()
+	if err := book.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return book, nil
+}
+
+// statusError map the errors of the book service to status codes
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrBookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrBookConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, service.ErrUnknownAuthor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, tenant.ErrMissing):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// encodePageToken return the opaque token of a page
+func encodePageToken(page uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(page, 10)))
+}
+
+// decodePageToken return the page of a token, the first page when empty
+func decodePageToken(token string) (uint64, error) {
+	if token == "" {
+		return 1, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		var page uint64
+		page, err = strconv.ParseUint(string(data), 10, 64)
+		if err == nil && page > 0 {
+			return page, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid page_token %q", token)
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"github.com/go-rest-api-boilerplate/server/book/bookpb"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/stream"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProto convert a book to its message
+func toProto(book *models.Book) *bookpb.Book {
+	message := &bookpb.Book{
+		Id:            book.ID,
+		Isbn10:        book.ISBN10,
+		Isbn13:        book.ISBN13,
+		Title:         book.Title,
+		Subtitle:      book.Subtitle,
+		Author:        book.Author,
+		Authors:       make([]*bookpb.BookAuthor, 0, len(book.Authors)),
+		Publisher:     book.Publisher,
+		PublishedDate: book.PublishedDate.String(),
+		Language:      book.Language,
+		PageCount:     int32(book.PageCount),
+		Edition:       book.Edition,
+		Description:   book.Description,
+		Format:        book.Format,
+	}
+	if !book.CreatedAt.IsZero() {
+		message.CreatedAt = timestamppb.New(book.CreatedAt)
+	}
+	if !book.UpdatedAt.IsZero() {
+		message.UpdatedAt = timestamppb.New(book.UpdatedAt)
+	}
+
+	for _, author := range book.Authors {
+		message.Authors = append(message.Authors, &bookpb.BookAuthor{
+			Id:       author.ID,
+			Name:     author.Name,
+			Role:     author.Role,
+			Position: int32(author.Position),
+		})
+	}
+
+	return message
+}
+
+// fromProto convert a message to a book, the timestamps are ignored
+func fromProto(message *bookpb.Book) (*models.Book, error) {
+	publishedDate, err := models.ParseDate(message.GetPublishedDate())
+	if err != nil {
+		return nil, err
+	}
+
+	book := &models.Book{
+		ID:            message.GetId(),
+		ISBN10:        message.GetIsbn10(),
+		ISBN13:        message.GetIsbn13(),
+		Title:         message.GetTitle(),
+		Subtitle:      message.GetSubtitle(),
+		Author:        message.GetAuthor(),
+		Publisher:     message.GetPublisher(),
+		PublishedDate: publishedDate,
+		Language:      message.GetLanguage(),
+		PageCount:     int(message.GetPageCount()),
+		Edition:       message.GetEdition(),
+		Description:   message.GetDescription(),
+		Format:        message.GetFormat(),
+	}
+
+	for _, author := range message.GetAuthors() {
+		book.Authors = append(book.Authors, &models.BookAuthor{
+			ID:   author.GetId(),
+			Name: author.GetName(),
+			Role: author.GetRole(),
+		})
+	}
+
+	return book, nil
+}
+
+// eventToProto convert a stream event to its message
+func eventToProto(event *stream.Event) *bookpb.BookEvent {
+	return &bookpb.BookEvent{
+		Id:     event.ID,
+		Type:   event.Type,
+		BookId: event.BookID,
+		At:     timestamppb.New(event.At),
+	}
+}
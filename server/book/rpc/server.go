@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"os"
+
+	"github.com/go-rest-api-boilerplate/server/book/bookpb"
+	"github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/go-rest-api-boilerplate/server/book/stream"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/auth"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// DefaultPort is the gRPC port when GRPC_PORT is not set
+const DefaultPort = "9090"
+
+//Port func return GRPC_PORT or DefaultPort
+func Port() string {
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		return port
+	}
+	return DefaultPort
+}
+
+// unscopedMethods are served without authentication nor tenant
+var unscopedMethods = []string{"/grpc.health.", "/grpc.reflection."}
+
+//NewServer func return a gRPC server of the book service with health checking and reflection,
+//the book calls are authenticated like the REST requests and scoped to the tenant of their metadata.
+//The server is plaintext only when serverTLS is nil, the principal of a call is then its bearer token.
+//The writes of the read-your-writes calls pin the next calls to the primary for the window of replicas
+func NewServer(bookService service.BookService, hub *stream.Hub, resolver *tenant.Resolver,
+	authenticator *auth.Authenticator, serverTLS *tls.Config, replicas dbrouter.Config) *grpc.Server {
+	options := []grpc.ServerOption{util.UnaryInterceptors(), util.StreamInterceptors(),
+		grpc.ChainUnaryInterceptor(auth.UnaryInterceptor(authenticator, unscopedMethods...),
+			tenant.UnaryInterceptor(resolver, unscopedMethods...), dbrouter.UnaryInterceptor(replicas)),
+		grpc.ChainStreamInterceptor(auth.StreamInterceptor(authenticator, unscopedMethods...),
+			tenant.StreamInterceptor(resolver, unscopedMethods...))}
+	if serverTLS != nil {
+		// the verified client certificates name the principal of the calls
+		options = append(options, grpc.Creds(credentials.NewTLS(serverTLS)))
+	}
+	server := grpc.NewServer(options...)
+
+	bookpb.RegisterBookServiceServer(server, NewBookServer(bookService, hub))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(bookpb.BookService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return server
+}
@@ -1,25 +1,70 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
 
+	"github.com/go-rest-api-boilerplate/server/book/cover"
+	"github.com/go-rest-api-boilerplate/server/book/fixture"
 	"github.com/go-rest-api-boilerplate/server/book/models"
 	"github.com/go-rest-api-boilerplate/server/book/repository"
+	"github.com/go-rest-api-boilerplate/server/book/transfer"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/audit"
+	"github.com/go-rest-api-boilerplate/util/blob"
 	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/outbox"
 )
 
 //BookService interface
 type BookService interface {
-	ListBook() ([]*models.Book, error)
-	GetBook(id int64) (*models.Book, error)
-	CreateBook(book *models.Book) (*models.Book, error)
-	UpdateBook(book *models.Book) (*models.Book, error)
-	DeleteBook(id int64) error
+	ListBook(ctx context.Context, filter *models.BookFilter) ([]*models.Book, error)
+	ExportBook(ctx context.Context, filter *models.BookFilter, fn func(*models.Book) error) error
+	ImportBook(ctx context.Context, decoder transfer.Decoder) (*models.ImportResult, error)
+	SeedBook(ctx context.Context, books []*models.Book, truncate bool) error
+	GetBook(ctx context.Context, id int64) (*models.Book, error)
+	CreateBook(ctx context.Context, book *models.Book) (*models.Book, error)
+	UpdateBook(ctx context.Context, book *models.Book) (*models.Book, error)
+	DeleteBook(ctx context.Context, id int64) error
+	RefreshBook(ctx context.Context, ids []int64) error
+	UploadCover(ctx context.Context, id int64, r io.Reader) (*models.Cover, error)
+	GetCover(ctx context.Context, id int64, size string) (io.ReadCloser, *models.Cover, error)
+	ListCover(ctx context.Context, ids []int64) ([]*models.Cover, error)
 }
 
+var (
+	// ErrBookConflict is returned when a unique book attribute is already used
+	ErrBookConflict = repository.ErrBookConflict
+	// ErrUnknownAuthor is returned when a book credit an author id that does not exist
+	ErrUnknownAuthor = repository.ErrUnknownAuthor
+	// ErrBookNotFound is returned when a book does not exist
+	ErrBookNotFound = repository.ErrBookNotFound
+	// ErrCoverNotFound is returned when a book has no cover
+	ErrCoverNotFound = errors.New("book has no cover")
+	// ErrMalformedImport is returned when an import upload cannot be read
+	ErrMalformedImport = transfer.ErrMalformed
+)
+
+// Audited entities
+const (
+	auditBookEntity  = "book"
+	auditCoverEntity = "book_cover"
+)
+
+// errImportRejected rollback an import having invalid rows
+var errImportRejected = errors.New("service: import rejected, no book imported")
+
 //InitBookService struct
 type InitBookService struct {
-	Repository *InitBookRepositoryInterface
+	Repository   *InitBookRepositoryInterface
+	Audit        audit.Writer
+	Outbox       outbox.Writer
+	Blob         blob.Store
+	CoverOptions cover.Options
 }
 
 //InitBookRepositoryInterface struct
@@ -28,17 +73,22 @@ type InitBookRepositoryInterface struct {
 }
 
 // NewBookService return new instance of BookRepository
-func NewBookService(bookRepository repository.BookRepository) BookService {
+func NewBookService(bookRepository repository.BookRepository, auditWriter audit.Writer, outboxWriter outbox.Writer,
+	blobStore blob.Store, coverOptions cover.Options) BookService {
 	return &InitBookService{
 		Repository: &InitBookRepositoryInterface{
 			Book: bookRepository,
 		},
+		Audit:        auditWriter,
+		Outbox:       outboxWriter,
+		Blob:         blobStore,
+		CoverOptions: coverOptions,
 	}
 }
 
 //ListBook func
-func (init *InitBookService) ListBook() ([]*models.Book, error) {
-	books, err := init.Repository.Book.List()
+func (init *InitBookService) ListBook(ctx context.Context, filter *models.BookFilter) ([]*models.Book, error) {
+	books, err := init.Repository.Book.List(ctx, filter)
 
 	if err != nil {
 		return books, err
@@ -48,8 +98,8 @@ func (init *InitBookService) ListBook() ([]*models.Book, error) {
 }
 
 //GetBook func
-func (init *InitBookService) GetBook(id int64) (*models.Book, error) {
-	book, err := init.Repository.Book.Find(id)
+func (init *InitBookService) GetBook(ctx context.Context, id int64) (*models.Book, error) {
+	book, err := init.Repository.Book.Find(ctx, id)
 
 	if err != nil {
 		return book, err
@@ -59,12 +109,17 @@ func (init *InitBookService) GetBook(id int64) (*models.Book, error) {
 }
 
 //CreateBook func
-func (init *InitBookService) CreateBook(book *models.Book) (*models.Book, error) {
+func (init *InitBookService) CreateBook(ctx context.Context, book *models.Book) (*models.Book, error) {
 	//start transaction
-	ctx := context.Background()
 	defer dbtrxn.Begin(&ctx)()
 
 	book, err := init.Repository.Book.Insert(ctx, book)
+	if err == nil {
+		err = init.Audit.Record(ctx, audit.ActionCreate, auditBookEntity, entityID(book.ID), nil, book)
+	}
+	if err == nil {
+		err = init.emit(ctx, models.EventBookCreated, book)
+	}
 
 	//transaction commit or rollback if error
 	dbtrxn.Error(ctx)
@@ -73,12 +128,34 @@ func (init *InitBookService) CreateBook(book *models.Book) (*models.Book, error)
 }
 
 //UpdateBook func
-func (init *InitBookService) UpdateBook(book *models.Book) (*models.Book, error) {
+func (init *InitBookService) UpdateBook(ctx context.Context, book *models.Book) (*models.Book, error) {
 	//start transaction
-	ctx := context.Background()
 	defer dbtrxn.Begin(&ctx)()
 
-	book, err := init.Repository.Book.Update(ctx, book)
+	before, err := init.Repository.Book.Find(ctx, book.ID)
+	if err == nil && before == nil {
+		err = ErrBookNotFound
+	}
+	if err != nil {
+		return book, err
+	}
+
+	book, err = init.Repository.Book.Update(ctx, book)
+
+	// the stored row is audited, it includes the derived byline and author ids
+	var after *models.Book
+	if err == nil {
+		after, err = init.Repository.Book.Find(ctx, book.ID)
+	}
+	if err == nil {
+		err = init.Audit.Record(ctx, audit.ActionUpdate, auditBookEntity, entityID(book.ID), before, after)
+	}
+	if err == nil {
+		err = init.emit(ctx, models.EventBookUpdated, after)
+	}
+	if err != nil {
+		dbtrxn.Retrieve(ctx).Err = err
+	}
 
 	//transaction commit or rollback if error
 	dbtrxn.Error(ctx)
@@ -87,15 +164,327 @@ func (init *InitBookService) UpdateBook(book *models.Book) (*models.Book, error)
 }
 
 //DeleteBook func
-func (init *InitBookService) DeleteBook(id int64) error {
+func (init *InitBookService) DeleteBook(ctx context.Context, id int64) error {
 	//start transaction
-	ctx := context.Background()
-	defer dbtrxn.Begin(&ctx)()
+	commit := dbtrxn.Begin(&ctx)
 
-	err := init.Repository.Book.Delete(ctx, id)
+	before, err := init.Repository.Book.Find(ctx, id)
+	if err == nil && before == nil {
+		err = ErrBookNotFound
+	}
+	if err == nil {
+		err = init.Repository.Book.Delete(ctx, id)
+	}
+	if err == nil {
+		err = init.Audit.Record(ctx, audit.ActionDelete, auditBookEntity, entityID(id), before, nil)
+	}
+	if err == nil {
+		err = init.emit(ctx, models.EventBookDeleted, before)
+	}
 
 	//transaction commit or rollback if error
-	dbtrxn.Error(ctx)
+	if commitErr := commit(); err == nil {
+		err = commitErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// blobs are removed once the book is gone, a failure only leaves orphans
+	init.deleteCovers(ctx, id)
+
+	return nil
+}
+
+//RefreshBook func emit the update events of the books rewritten by another module, such as the byline of
+//the books of a renamed author, in the transaction of ctx. They are audited with the rewrite, their cached copies
+//are invalidated on commit
+func (init *InitBookService) RefreshBook(ctx context.Context, ids []int64) error {
+	if err := init.Repository.Book.Touch(ctx, ids); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		book, err := init.Repository.Book.Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if book == nil {
+			continue
+		}
+		// the previous state is the one of the rewriting module, only the result is audited
+		err = init.Audit.Record(ctx, audit.ActionUpdate, auditBookEntity, entityID(id), nil, book)
+		if err == nil {
+			err = init.emit(ctx, models.EventBookUpdated, book)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//SeedBook func upsert the books on their fixture key, after deleting every book of the tenant when truncate is
+//set. Every deleted and seeded book is audited, the seed is published as bulk events
+func (init *InitBookService) SeedBook(ctx context.Context, books []*models.Book, truncate bool) error {
+	//start transaction
+	commit := dbtrxn.Begin(&ctx)
+
+	var truncated []int64
+	var err error
+	if truncate {
+		truncated, err = init.Repository.Book.Truncate(ctx)
+		for _, id := range truncated {
+			if err != nil {
+				break
+			}
+			err = init.Audit.Record(ctx, audit.ActionDelete, auditBookEntity, entityID(id), nil, nil)
+		}
+		if err == nil {
+			err = init.emitBulk(ctx, models.EventBookTruncated,
+				&models.BulkEvent{Count: int64(len(truncated)), IDs: truncated})
+		}
+	}
+	for _, book := range books {
+		if err != nil {
+			break
+		}
+		var seeded *models.Book
+		seeded, err = init.Repository.Book.Upsert(ctx, fixture.Key(book), book)
+		if err == nil {
+			err = init.Audit.Record(ctx, audit.ActionSeed, auditBookEntity, entityID(seeded.ID), nil, seeded)
+		}
+	}
+	if err == nil && len(books) > 0 {
+		err = init.emitBulk(ctx, models.EventBookImported, &models.BulkEvent{Count: int64(len(books))})
+	}
+
+	//transaction commit or rollback if error
+	if commitErr := commit(); err == nil {
+		err = commitErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// the covers of the truncated books are removed once they are gone
+	for _, id := range truncated {
+		init.deleteCovers(ctx, id)
+	}
+
+	return nil
+}
+
+//ExportBook func
+func (init *InitBookService) ExportBook(ctx context.Context, filter *models.BookFilter, fn func(*models.Book) error) error {
+	return init.Repository.Book.Stream(ctx, filter, fn)
+}
+
+//ImportBook func
+func (init *InitBookService) ImportBook(ctx context.Context, decoder transfer.Decoder) (*models.ImportResult, error) {
+	result := &models.ImportResult{Errors: make([]*models.RowError, 0)}
+
+	// skip rejected rows so every error of the file is reported at once
+	next := func() (*models.Book, error) {
+		for {
+			book, err := decoder.Next()
+			if err == io.EOF {
+				return nil, err
+			}
+			result.Rows = decoder.Row()
+
+			var rowErr *models.RowError
+			if errors.As(err, &rowErr) {
+				result.Errors = append(result.Errors, rowErr)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			book.Normalize()
+			if err := book.Validate(); err != nil {
+				result.Errors = append(result.Errors, &models.RowError{Row: decoder.Row(), Message: err.Error()})
+				continue
+			}
+
+			return book, nil
+		}
+	}
+
+	//start transaction
+	commit := dbtrxn.Begin(&ctx)
+
+	imported, err := init.Repository.Book.Import(ctx, next)
+	if err == nil && len(result.Errors) > 0 {
+		// nothing is imported unless every row is valid
+		dbtrxn.Retrieve(ctx).Err = errImportRejected
+	}
+	if err == nil && len(result.Errors) == 0 {
+		// an import is audited as a whole, the rows are in the uploaded file
+		err = init.Audit.Record(ctx, audit.ActionImport, auditBookEntity, "", nil, map[string]int64{
+			"rows":     int64(result.Rows),
+			"imported": imported,
+		})
+	}
+	if err == nil && len(result.Errors) == 0 {
+		err = init.emitBulk(ctx, models.EventBookImported, &models.BulkEvent{Count: imported})
+	}
+
+	//transaction commit or rollback if error
+	if commitErr := commit(); err == nil {
+		err = commitErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.Errors) == 0 {
+		result.Imported = imported
+	}
+
+	return result, err
+}
+
+//UploadCover func
+func (init *InitBookService) UploadCover(ctx context.Context, id int64, r io.Reader) (*models.Cover, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, init.CoverOptions.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := cover.Process(data, init.CoverOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	//start transaction
+	commit := dbtrxn.Begin(&ctx)
+
+	before, err := init.Repository.Book.FindCover(ctx, id)
+	if err != nil {
+		commit()
+		return nil, err
+	}
+
+	bookCover, err := init.Repository.Book.SaveCover(ctx, &models.Cover{
+		BookID:      id,
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Size:        int64(len(img.Original)),
+		ETag:        img.ETag,
+	})
+	if err == nil {
+		// the metadata is rolled back when a blob cannot be stored
+		if err = init.putCover(ctx, id, img); err != nil {
+			dbtrxn.Retrieve(ctx).Err = err
+		}
+	}
+	if err == nil {
+		action := audit.ActionUpdate
+		if before == nil {
+			action = audit.ActionCreate
+		}
+		err = init.Audit.Record(ctx, action, auditCoverEntity, entityID(id), before, bookCover)
+	}
+	if err == nil {
+		err = init.Outbox.Write(ctx, models.EventBookCoverUpdated, models.BookAggregate, entityID(id),
+			models.BookEventVersion, bookCover)
+	}
+
+	//transaction commit or rollback if error
+	if commitErr := commit(); err == nil {
+		err = commitErr
+	}
+
+	// uploading the current image again write the blobs of the current version
+	unchanged := before != nil && before.ETag == img.ETag
+	switch {
+	case err != nil && !unchanged:
+		init.deleteCover(ctx, id, img.ETag)
+	case err == nil && before != nil && !unchanged:
+		init.deleteCover(ctx, id, before.ETag)
+	}
+
+	return bookCover, err
+}
+
+func (init *InitBookService) putCover(ctx context.Context, id int64, img *cover.Image) error {
+	err := init.Blob.Put(ctx, cover.Key(id, img.ETag, cover.SizeOriginal), bytes.NewReader(img.Original),
+		int64(len(img.Original)), img.ContentType)
+	if err != nil {
+		return err
+	}
+
+	for size, thumbnail := range img.Thumbnails {
+		err := init.Blob.Put(ctx, cover.Key(id, img.ETag, size), bytes.NewReader(thumbnail),
+			int64(len(thumbnail)), cover.ThumbnailContentType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteCovers remove the blobs of every cover version of a book, a failure only leaves orphans
+func (init *InitBookService) deleteCovers(ctx context.Context, id int64) {
+	if err := init.Blob.DeletePrefix(ctx, cover.Prefix(id)); err != nil {
+		util.SessionLogger(ctx).Warnf("delete cover of book #%d: %v", id, err)
+	}
+}
+
+// deleteCover remove the blobs of a cover version, a failure only leaves orphans
+func (init *InitBookService) deleteCover(ctx context.Context, id int64, etag string) {
+	if err := init.Blob.DeletePrefix(ctx, cover.VersionPrefix(id, etag)); err != nil {
+		util.SessionLogger(ctx).Warnf("delete cover %s of book #%d: %v", etag, id, err)
+	}
+}
+
+//ListCover func return the covers of the books having one
+func (init *InitBookService) ListCover(ctx context.Context, ids []int64) ([]*models.Cover, error) {
+	return init.Repository.Book.FindCovers(ctx, ids)
+}
+
+//GetCover func
+func (init *InitBookService) GetCover(ctx context.Context, id int64, size string) (io.ReadCloser, *models.Cover, error) {
+	bookCover, err := init.Repository.Book.FindCover(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bookCover == nil {
+		return nil, nil, ErrCoverNotFound
+	}
+
+	body, info, err := init.Blob.Get(ctx, cover.Key(id, bookCover.ETag, size))
+	if err == blob.ErrNotFound {
+		return nil, nil, ErrCoverNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if size != cover.SizeOriginal {
+		bookCover.ContentType = info.ContentType
+		bookCover.Size = info.Size
+	}
+
+	return body, bookCover, nil
+}
+
+// emit write a book event to the outbox in the transaction of ctx
+func (init *InitBookService) emit(ctx context.Context, eventType string, book *models.Book) error {
+	return init.Outbox.Write(ctx, eventType, models.BookAggregate, entityID(book.ID), models.BookEventVersion, book)
+}
+
+// emitBulk write a bulk book event of the tenant of ctx to the outbox, it has no aggregate id
+func (init *InitBookService) emitBulk(ctx context.Context, eventType string, event *models.BulkEvent) error {
+	return init.Outbox.Write(ctx, eventType, models.BookAggregate, "", models.BookEventVersion, event)
+}
 
-	return err
+// entityID format a book id as an audit entity id
+func entityID(id int64) string {
+	return strconv.FormatInt(id, 10)
 }
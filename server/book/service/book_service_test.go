@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/server/book/cover"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/repository"
+	"github.com/go-rest-api-boilerplate/util/audit"
+	"github.com/go-rest-api-boilerplate/util/blob"
+)
+
+// seedRepository keep the books in memory, the other methods are not used by the seed
+type seedRepository struct {
+	repository.BookRepository
+	books map[int64]*models.Book
+	keys  map[string]int64
+}
+
+func (r *seedRepository) Find(ctx context.Context, id int64) (*models.Book, error) {
+	return r.books[id], nil
+}
+
+func (r *seedRepository) Upsert(ctx context.Context, key string, book *models.Book) (*models.Book, error) {
+	id, ok := r.keys[key]
+	if !ok {
+		id = int64(len(r.books) + 1)
+		r.keys[key] = id
+	}
+	book.ID = id
+	r.books[id] = book
+	return book, nil
+}
+
+func (r *seedRepository) Touch(ctx context.Context, ids []int64) error {
+	return nil
+}
+
+func (r *seedRepository) Truncate(ctx context.Context) ([]int64, error) {
+	ids := make([]int64, 0, len(r.books))
+	for id := range r.books {
+		ids = append(ids, id)
+	}
+	r.books = make(map[int64]*models.Book)
+	return ids, nil
+}
+
+func newSeedService(t *testing.T) (*InitBookService, *seedRepository, *stubOutbox, blob.Store) {
+	service, _, store := newCoverService(t)
+	repository := &seedRepository{books: make(map[int64]*models.Book), keys: make(map[string]int64)}
+	service.Repository.Book = repository
+	return service, repository, service.Outbox.(*stubOutbox), store
+}
+
+// entries return the sorted audit entries of the service and reset them
+func entries(service *InitBookService) []string {
+	auditWriter := service.Audit.(*stubAudit)
+	recorded := auditWriter.entries
+	auditWriter.entries = nil
+	sort.Strings(recorded)
+	return recorded
+}
+
+func TestSeedBookEvents(t *testing.T) {
+	service, repository, events, store := newSeedService(t)
+	ctx := context.Background()
+
+	books := []*models.Book{{Title: "Refactoring", Author: "Martin Fowler"}, {Title: "Go", Author: "Alan Donovan"}}
+	if err := service.SeedBook(ctx, books, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{models.EventBookImported + " "}; !reflect.DeepEqual(events.events, want) {
+		t.Errorf("got events %v, want %v", events.events, want)
+	}
+	if got, want := entries(service), []string{audit.ActionSeed + " 1", audit.ActionSeed + " 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got audit entries %v, want %v", got, want)
+	}
+
+	if err := store.Put(ctx, cover.Key(1, "v1", cover.SizeOriginal), strings.NewReader("x"), 1, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	events.events = nil
+	if err := service.SeedBook(ctx, books[:1], true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{models.EventBookTruncated + " ", models.EventBookImported + " "}
+	if !reflect.DeepEqual(events.events, want) {
+		t.Errorf("got events %v, want %v", events.events, want)
+	}
+	want = []string{audit.ActionDelete + " 1", audit.ActionDelete + " 2", audit.ActionSeed + " 1"}
+	if got := entries(service); !reflect.DeepEqual(got, want) {
+		t.Errorf("got audit entries %v, want a delete of every truncated book and a seed", got)
+	}
+	if len(repository.books) != 1 {
+		t.Errorf("got %d books, want the seeded book only", len(repository.books))
+	}
+	if exists(t, store, cover.Key(1, "v1", cover.SizeOriginal)) {
+		t.Error("the cover of a truncated book is not deleted")
+	}
+}
+
+func TestRefreshBookEvents(t *testing.T) {
+	service, repository, events, _ := newSeedService(t)
+	ctx := context.Background()
+
+	repository.books[3] = &models.Book{ID: 3, Title: "Refactoring"}
+	if err := service.RefreshBook(ctx, []int64{3, 404}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{models.EventBookUpdated + " 3"}; !reflect.DeepEqual(events.events, want) {
+		t.Errorf("got events %v, want an update of the existing book only", events.events)
+	}
+	if got, want := entries(service), []string{audit.ActionUpdate + " 3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got audit entries %v, want %v", got, want)
+	}
+}
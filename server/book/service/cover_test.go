@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/server/book/cover"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/repository"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/blob"
+	"github.com/sirupsen/logrus"
+)
+
+// coverRepository keep the cover of a single book, the other methods are not used by the cover upload
+type coverRepository struct {
+	repository.BookRepository
+	cover *models.Cover
+}
+
+func (r *coverRepository) FindCover(ctx context.Context, bookID int64) (*models.Cover, error) {
+	return r.cover, nil
+}
+
+func (r *coverRepository) SaveCover(ctx context.Context, c *models.Cover) (*models.Cover, error) {
+	r.cover = c
+	return c, nil
+}
+
+// stubAudit keep the recorded entries, it fail the mutations with err
+type stubAudit struct {
+	err     error
+	entries []string
+}
+
+func (a *stubAudit) Record(ctx context.Context, action, entity, entityID string, before, after interface{}) error {
+	if a.err == nil {
+		a.entries = append(a.entries, action+" "+entityID)
+	}
+	return a.err
+}
+
+// stubOutbox keep the written events
+type stubOutbox struct {
+	events []string
+}
+
+func (o *stubOutbox) Write(ctx context.Context, eventType, aggregateType, aggregateID string, version int,
+	payload interface{}) error {
+	o.events = append(o.events, eventType+" "+aggregateID)
+	return nil
+}
+
+func newCoverService(t *testing.T) (*InitBookService, *stubAudit, blob.Store) {
+	util.Log = logrus.New()
+
+	root, err := ioutil.TempDir("", "cover-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	store, err := blob.NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditWriter := &stubAudit{}
+	service := NewBookService(&coverRepository{}, auditWriter, &stubOutbox{}, store,
+		cover.DefaultOptions).(*InitBookService)
+	return service, auditWriter, store
+}
+
+func pngImage(t *testing.T, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 48))
+	for x := 0; x < 32; x++ {
+		for y := 0; y < 48; y++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func exists(t *testing.T, store blob.Store, key string) bool {
+	body, _, err := store.Get(context.Background(), key)
+	if err == blob.ErrNotFound {
+		return false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	return true
+}
+
+func TestUploadCoverReplaceVersion(t *testing.T) {
+	service, _, store := newCoverService(t)
+	ctx := context.Background()
+
+	first, err := service.UploadCover(ctx, 1, bytes.NewReader(pngImage(t, color.White)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for size := range cover.ThumbnailWidths {
+		if !exists(t, store, cover.Key(1, first.ETag, size)) {
+			t.Errorf("missing %s thumbnail", size)
+		}
+	}
+
+	// the same image keep its blobs
+	if _, err := service.UploadCover(ctx, 1, bytes.NewReader(pngImage(t, color.White))); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(t, store, cover.Key(1, first.ETag, cover.SizeOriginal)) {
+		t.Fatal("reuploading the current cover deleted it")
+	}
+
+	second, err := service.UploadCover(ctx, 1, bytes.NewReader(pngImage(t, color.Black)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ETag == first.ETag {
+		t.Fatal("both images have the same etag")
+	}
+	if exists(t, store, cover.Key(1, first.ETag, cover.SizeOriginal)) {
+		t.Error("the replaced cover is not deleted")
+	}
+	if !exists(t, store, cover.Key(1, second.ETag, cover.SizeOriginal)) {
+		t.Error("the new cover is not stored")
+	}
+
+	events := service.Outbox.(*stubOutbox).events
+	if len(events) != 3 || events[2] != models.EventBookCoverUpdated+" 1" {
+		t.Errorf("got events %v, want a cover event by upload", events)
+	}
+
+	body, current, err := service.GetCover(ctx, 1, cover.SizeSmall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if current.ContentType != cover.ThumbnailContentType {
+		t.Errorf("got %s thumbnail", current.ContentType)
+	}
+}
+
+func TestUploadCoverRollback(t *testing.T) {
+	service, auditWriter, store := newCoverService(t)
+	ctx := context.Background()
+
+	first, err := service.UploadCover(ctx, 1, bytes.NewReader(pngImage(t, color.White)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the blobs are written when the audit fails
+	auditWriter.err = errors.New("audit failed")
+	data := pngImage(t, color.Black)
+	img, err := cover.Process(data, cover.DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.UploadCover(ctx, 1, bytes.NewReader(data)); err != auditWriter.err {
+		t.Fatalf("got %v, want the audit error", err)
+	}
+
+	if !exists(t, store, cover.Key(1, first.ETag, cover.SizeOriginal)) {
+		t.Error("a failed upload deleted the current cover")
+	}
+	if exists(t, store, cover.Key(1, img.ETag, cover.SizeOriginal)) {
+		t.Error("a failed upload left its blobs")
+	}
+}
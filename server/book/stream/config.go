@@ -0,0 +1,31 @@
+package stream
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config of the book stream
+type Config struct {
+	BufferSize int
+	Heartbeat  time.Duration
+}
+
+// DefaultConfig replay the last 1000 events and send a heartbeat every 15 seconds
+var DefaultConfig = Config{
+	BufferSize: 1000,
+	Heartbeat:  15 * time.Second,
+}
+
+// ConfigFromEnv override DefaultConfig with STREAM_BUFFER_SIZE and STREAM_HEARTBEAT
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if v, err := strconv.Atoi(os.Getenv("STREAM_BUFFER_SIZE")); err == nil && v > 0 {
+		config.BufferSize = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("STREAM_HEARTBEAT")); err == nil && v > 0 {
+		config.Heartbeat = v
+	}
+	return config
+}
@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// EventReset tell a client to reload every book, its missed events are unknown. A reset without tenant
+// concern every tenant
+const EventReset = "reset"
+
+// subscriptionBuffer is the number of events a slow client can lag behind
+const subscriptionBuffer = 64
+
+type (
+	// Event is a book change notified by the database
+	Event struct {
+		ID       int64     `json:"id"`
+		Type     string    `json:"type" enums:"book.created,book.updated,book.deleted,reset"`
+		BookID   int64     `json:"book_id,omitempty"`
+		TenantID string    `json:"tenant_id,omitempty"`
+		At       time.Time `json:"at"`
+	}
+
+	// Hub fan out the events to the subscriptions and keep the latest ones for replay
+	Hub struct {
+		sync.Mutex
+		buffer        []*Event
+		next          int
+		full          bool
+		subscriptions map[*Subscription]bool
+	}
+
+	// Subscription receive the events of its tenant and types on C, C is closed when
+	// the subscription lags behind so the client reconnects and resumes
+	Subscription struct {
+		C      chan *Event
+		hub    *Hub
+		tenant string
+		types  map[string]bool
+	}
+)
+
+// NewHub return a Hub replaying up to size events
+func NewHub(size int) *Hub {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Hub{
+		buffer:        make([]*Event, size),
+		subscriptions: make(map[*Subscription]bool),
+	}
+}
+
+// Publish an event to the matching subscriptions
+func (h *Hub) Publish(event *Event) {
+	h.Lock()
+	defer h.Unlock()
+
+	// a reset without tenant invalidate the events before it, the reset of a tenant is replayed to it
+	if event.Type == EventReset && event.TenantID == "" {
+		h.next, h.full = 0, false
+		for i := range h.buffer {
+			h.buffer[i] = nil
+		}
+	}
+
+	h.buffer[h.next] = event
+	h.next = (h.next + 1) % len(h.buffer)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	for s := range h.subscriptions {
+		if !s.match(event) {
+			continue
+		}
+
+		select {
+		case s.C <- event:
+		default:
+			delete(h.subscriptions, s)
+			close(s.C)
+		}
+	}
+}
+
+// Subscribe to the events of the tenant of types, every type when empty. When
+// lastEventID is not 0 the buffered events after it are returned, ok is false
+// when it is no longer buffered and the client must reload
+func (h *Hub) Subscribe(tenantID string, types []string, lastEventID int64) (s *Subscription, replay []*Event,
+	ok bool) {
+	s = &Subscription{
+		C:      make(chan *Event, subscriptionBuffer),
+		hub:    h,
+		tenant: tenantID,
+		types:  make(map[string]bool, len(types)),
+	}
+	for _, t := range types {
+		s.types[t] = true
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	// registering under the lock leave no gap between the replay and the live events
+	h.subscriptions[s] = true
+
+	if lastEventID == 0 {
+		return s, nil, true
+	}
+
+	found := false
+	for _, event := range h.events() {
+		if found && s.match(event) {
+			replay = append(replay, event)
+		}
+		if event.ID == lastEventID {
+			found = true
+		}
+	}
+
+	return s, replay, found
+}
+
+// events return the buffered events in publication order
+func (h *Hub) events() []*Event {
+	if !h.full {
+		return h.buffer[:h.next]
+	}
+
+	events := make([]*Event, 0, len(h.buffer))
+	events = append(events, h.buffer[h.next:]...)
+	return append(events, h.buffer[:h.next]...)
+}
+
+// Close the subscription
+func (s *Subscription) Close() {
+	s.hub.Lock()
+	defer s.hub.Unlock()
+
+	if s.hub.subscriptions[s] {
+		delete(s.hub.subscriptions, s)
+		close(s.C)
+	}
+}
+
+func (s *Subscription) match(event *Event) bool {
+	if event.Type == EventReset {
+		return event.TenantID == "" || event.TenantID == s.tenant
+	}
+	return event.TenantID == s.tenant && (len(s.types) == 0 || s.types[event.Type])
+}
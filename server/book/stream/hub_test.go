@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"testing"
+)
+
+func event(id int64, tenantID, eventType string) *Event {
+	return &Event{ID: id, Type: eventType, BookID: id, TenantID: tenantID}
+}
+
+func received(s *Subscription) []int64 {
+	ids := make([]int64, 0)
+	for {
+		select {
+		case e, ok := <-s.C:
+			if !ok {
+				return ids
+			}
+			ids = append(ids, e.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func equal(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHubFilter(t *testing.T) {
+	hub := NewHub(16)
+	all, _, _ := hub.Subscribe("acme", nil, 0)
+	deleted, _, _ := hub.Subscribe("acme", []string{"book.deleted"}, 0)
+	defer all.Close()
+	defer deleted.Close()
+
+	hub.Publish(event(1, "acme", "book.created"))
+	hub.Publish(event(2, "other", "book.created"))
+	hub.Publish(event(3, "acme", "book.deleted"))
+	hub.Publish(event(4, "", EventReset))
+
+	if got := received(all); !equal(got, []int64{1, 3, 4}) {
+		t.Errorf("got %v, want the events of the tenant and the reset", got)
+	}
+	if got := received(deleted); !equal(got, []int64{3, 4}) {
+		t.Errorf("got %v, want the deletions of the tenant and the reset", got)
+	}
+}
+
+func TestHubReplay(t *testing.T) {
+	hub := NewHub(3)
+	for id := int64(1); id <= 4; id++ {
+		hub.Publish(event(id, "acme", "book.updated"))
+	}
+
+	s, replay, ok := hub.Subscribe("acme", nil, 2)
+	defer s.Close()
+	if !ok || len(replay) != 2 || replay[0].ID != 3 || replay[1].ID != 4 {
+		t.Fatalf("got %v %v, want the events after 2", ok, replay)
+	}
+
+	// the event 1 is no longer buffered
+	other, replay, ok := hub.Subscribe("acme", nil, 1)
+	defer other.Close()
+	if ok || len(replay) != 0 {
+		t.Errorf("got %v %v, want a reload", ok, replay)
+	}
+
+	// a reset drop the buffered events
+	hub.Publish(event(5, "", EventReset))
+	reset, _, ok := hub.Subscribe("acme", nil, 4)
+	defer reset.Close()
+	if ok {
+		t.Error("an event before the reset was replayed")
+	}
+}
+
+func TestHubTenantReset(t *testing.T) {
+	hub := NewHub(16)
+	acme, _, _ := hub.Subscribe("acme", []string{"book.deleted"}, 0)
+	other, _, _ := hub.Subscribe("other", nil, 0)
+	defer acme.Close()
+	defer other.Close()
+
+	// a truncation notify a reset per tenant
+	hub.Publish(event(1, "acme", "book.deleted"))
+	hub.Publish(event(2, "acme", EventReset))
+	hub.Publish(event(3, "other", EventReset))
+
+	if got := received(acme); !equal(got, []int64{1, 2}) {
+		t.Errorf("got %v, want the deletion and the reset of the tenant", got)
+	}
+	if got := received(other); !equal(got, []int64{3}) {
+		t.Errorf("got %v, want the reset of the tenant", got)
+	}
+
+	// the reset of a tenant is replayed to it, the buffer is kept
+	s, replay, ok := hub.Subscribe("acme", nil, 1)
+	defer s.Close()
+	if !ok || len(replay) != 1 || replay[0].ID != 2 {
+		t.Errorf("got %v %v, want the reset replayed", ok, replay)
+	}
+}
+
+func TestHubSlowSubscription(t *testing.T) {
+	hub := NewHub(1)
+	s, _, _ := hub.Subscribe("acme", nil, 0)
+
+	for id := int64(1); id <= subscriptionBuffer+1; id++ {
+		hub.Publish(event(id, "acme", "book.updated"))
+	}
+
+	if got := received(s); len(got) != subscriptionBuffer {
+		t.Fatalf("got %d events, want the buffered ones", len(got))
+	}
+	if _, ok := <-s.C; ok {
+		t.Error("the lagging subscription is not closed")
+	}
+
+	// closing a dropped subscription is a no-op
+	s.Close()
+}
@@ -0,0 +1,79 @@
+package stream
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/lib/pq"
+)
+
+// Channel is notified by the books triggers
+const Channel = "book_changes"
+
+// pingInterval detect a dead connection while the channel is quiet
+const pingInterval = 90 * time.Second
+
+// Listener publish the notifications of Channel to a Hub
+type Listener struct {
+	listener *pq.Listener
+	hub      *Hub
+	stop     chan bool
+	done     chan bool
+}
+
+// NewListener return a Listener publishing the notifications of listener, the listener is owned by the caller
+func NewListener(listener *pq.Listener, hub *Hub) *Listener {
+	return &Listener{
+		listener: listener,
+		hub:      hub,
+		stop:     make(chan bool),
+		done:     make(chan bool),
+	}
+}
+
+// Start listening in the background
+func (l *Listener) Start() error {
+	if err := l.listener.Listen(Channel); err != nil {
+		return err
+	}
+
+	go l.run()
+	return nil
+}
+
+// Stop listening
+func (l *Listener) Stop() error {
+	l.stop <- true
+	<-l.done
+	return l.listener.Unlisten(Channel)
+}
+
+func (l *Listener) run() {
+	defer close(l.done)
+
+	logger := util.Log.WithField("context", "stream")
+	for {
+		select {
+		case notification := <-l.listener.Notify:
+			// nil after a reconnection, the notifications sent meanwhile are lost
+			if notification == nil {
+				l.hub.Publish(&Event{Type: EventReset, At: time.Now()})
+				continue
+			}
+
+			event := new(Event)
+			if err := json.Unmarshal([]byte(notification.Extra), event); err != nil {
+				logger.Warnf("invalid notification %q: %v", notification.Extra, err)
+				continue
+			}
+			event.At = time.Now()
+			l.hub.Publish(event)
+		case <-time.After(pingInterval):
+			// detect a dead connection while the channel is quiet
+			go l.listener.Ping()
+		case <-l.stop:
+			return
+		}
+	}
+}
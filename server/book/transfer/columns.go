@@ -0,0 +1,104 @@
+package transfer
+
+import (
+	"strconv"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+)
+
+// column is a CSV column of a book
+type column struct {
+	name string
+	get  func(*models.Book) string
+	// set is nil for read-only columns
+	set func(*models.Book, string) error
+}
+
+var columns = []column{
+	{
+		name: "id",
+		get:  func(b *models.Book) string { return strconv.FormatInt(b.ID, 10) },
+	},
+	{
+		name: "isbn10",
+		get:  func(b *models.Book) string { return b.ISBN10 },
+		set:  func(b *models.Book, v string) error { b.ISBN10 = v; return nil },
+	},
+	{
+		name: "isbn13",
+		get:  func(b *models.Book) string { return b.ISBN13 },
+		set:  func(b *models.Book, v string) error { b.ISBN13 = v; return nil },
+	},
+	{
+		name: "title",
+		get:  func(b *models.Book) string { return b.Title },
+		set:  func(b *models.Book, v string) error { b.Title = v; return nil },
+	},
+	{
+		name: "subtitle",
+		get:  func(b *models.Book) string { return b.Subtitle },
+		set:  func(b *models.Book, v string) error { b.Subtitle = v; return nil },
+	},
+	{
+		name: "author",
+		get:  func(b *models.Book) string { return b.Author },
+		set:  func(b *models.Book, v string) error { b.Author = v; return nil },
+	},
+	{
+		name: "publisher",
+		get:  func(b *models.Book) string { return b.Publisher },
+		set:  func(b *models.Book, v string) error { b.Publisher = v; return nil },
+	},
+	{
+		name: "published_date",
+		get:  func(b *models.Book) string { return b.PublishedDate.String() },
+		set: func(b *models.Book, v string) (err error) {
+			b.PublishedDate, err = models.ParseDate(v)
+			return err
+		},
+	},
+	{
+		name: "language",
+		get:  func(b *models.Book) string { return b.Language },
+		set:  func(b *models.Book, v string) error { b.Language = v; return nil },
+	},
+	{
+		name: "page_count",
+		get: func(b *models.Book) string {
+			if b.PageCount == 0 {
+				return ""
+			}
+			return strconv.Itoa(b.PageCount)
+		},
+		set: func(b *models.Book, v string) (err error) {
+			if v == "" {
+				return nil
+			}
+			b.PageCount, err = strconv.Atoi(v)
+			return err
+		},
+	},
+	{
+		name: "edition",
+		get:  func(b *models.Book) string { return b.Edition },
+		set:  func(b *models.Book, v string) error { b.Edition = v; return nil },
+	},
+	{
+		name: "description",
+		get:  func(b *models.Book) string { return b.Description },
+		set:  func(b *models.Book, v string) error { b.Description = v; return nil },
+	},
+	{
+		name: "format",
+		get:  func(b *models.Book) string { return b.Format },
+		set:  func(b *models.Book, v string) error { b.Format = v; return nil },
+	},
+}
+
+func csvHeader() []string {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	return header
+}
@@ -0,0 +1,134 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+)
+
+// Supported formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+)
+
+// Encoder write books one by one to a stream
+type Encoder interface {
+	Begin() error
+	Encode(book *models.Book) error
+	// Flush buffered rows to the underlying writer
+	Flush() error
+	End() error
+}
+
+// NewEncoder return the encoder of format
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{writer: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &jsonEncoder{writer: w, encoder: json.NewEncoder(w)}, nil
+	case FormatJSON:
+		return &jsonEncoder{writer: w, encoder: json.NewEncoder(w), array: true}, nil
+	}
+
+	return nil, fmt.Errorf("transfer: unsupported format %q", format)
+}
+
+// ContentType return the MIME type of format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvEncoder) Begin() error {
+	return e.writer.Write(csvHeader())
+}
+
+func (e *csvEncoder) Encode(book *models.Book) error {
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.get(book)
+	}
+	return e.writer.Write(record)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) End() error {
+	return e.Flush()
+}
+
+type jsonEncoder struct {
+	writer  io.Writer
+	encoder *json.Encoder
+	array   bool
+	count   int
+}
+
+func (e *jsonEncoder) Begin() error {
+	if !e.array {
+		return nil
+	}
+	_, err := io.WriteString(e.writer, "[")
+	return err
+}
+
+func (e *jsonEncoder) Encode(book *models.Book) error {
+	if e.array && e.count > 0 {
+		if _, err := io.WriteString(e.writer, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	return e.encoder.Encode(book)
+}
+
+func (e *jsonEncoder) Flush() error {
+	return nil
+}
+
+func (e *jsonEncoder) End() error {
+	if !e.array {
+		return nil
+	}
+	_, err := io.WriteString(e.writer, "]\n")
+	return err
+}
+
+// Table render a list of books as CSV, with the columns of the export
+type Table []*models.Book
+
+// WriteCSV implement negotiate.Table
+func (t Table) WriteCSV(w io.Writer) error {
+	encoder, err := NewEncoder(FormatCSV, w)
+	if err != nil {
+		return err
+	}
+	if err := encoder.Begin(); err != nil {
+		return err
+	}
+	for _, book := range t {
+		if err := encoder.Encode(book); err != nil {
+			return err
+		}
+	}
+	return encoder.End()
+}
@@ -0,0 +1,164 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+)
+
+// ErrMalformed is returned when the upload cannot be read as a whole, like a CSV without title column
+var ErrMalformed = errors.New("malformed import")
+
+// Decoder read books one by one from a stream
+type Decoder interface {
+	// Next return io.EOF after the last row, *models.RowError for a malformed row and ErrMalformed when the
+	// rest of the upload cannot be read
+	Next() (*models.Book, error)
+	// Row return the 1-based data row number of the last Next call
+	Row() int
+}
+
+// NewDecoder return the decoder of format
+func NewDecoder(format string, r io.Reader) (Decoder, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.ReuseRecord = true
+		reader.FieldsPerRecord = -1
+		return &csvDecoder{reader: reader}, nil
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		return &ndjsonDecoder{scanner: scanner}, nil
+	}
+
+	return nil, fmt.Errorf("transfer: unsupported import format %q", format)
+}
+
+// DetectFormat guess the import format from the content type or file name
+func DetectFormat(contentType, filename string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonlines":
+		return FormatNDJSON
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+
+	return ""
+}
+
+type csvDecoder struct {
+	reader *csv.Reader
+	index  map[string]int
+	row    int
+	// err is the header error, returned by every call
+	err error
+}
+
+func (d *csvDecoder) Next() (*models.Book, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if d.index == nil {
+		header, err := d.reader.Read()
+		if _, ok := err.(*csv.ParseError); ok {
+			err = fmt.Errorf("%w: csv header: %v", ErrMalformed, err)
+		}
+		if err != nil {
+			d.err = err
+			return nil, err
+		}
+
+		index := make(map[string]int, len(header))
+		for i, name := range header {
+			index[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		if _, ok := index["title"]; !ok {
+			d.err = fmt.Errorf("%w: csv header must contain %q", ErrMalformed, "title")
+			return nil, d.err
+		}
+		d.index = index
+	}
+
+	record, err := d.reader.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	d.row++
+
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return nil, &models.RowError{Row: d.row, Message: err.Error()}
+		}
+		return nil, err
+	}
+
+	book := new(models.Book)
+	for _, c := range columns {
+		i, ok := d.index[c.name]
+		if !ok || c.set == nil || i >= len(record) {
+			continue
+		}
+
+		if err := c.set(book, strings.TrimSpace(record[i])); err != nil {
+			return nil, &models.RowError{Row: d.row, Message: fmt.Sprintf("%s: %v", c.name, err)}
+		}
+	}
+
+	return book, nil
+}
+
+func (d *csvDecoder) Row() int {
+	return d.row
+}
+
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func (d *ndjsonDecoder) Next() (*models.Book, error) {
+	for d.scanner.Scan() {
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		d.row++
+
+		var book models.Book
+		if err := json.Unmarshal(line, &book); err != nil {
+			return nil, &models.RowError{Row: d.row, Message: err.Error()}
+		}
+		book.ID = 0
+
+		return &book, nil
+	}
+
+	if err := d.scanner.Err(); err == bufio.ErrTooLong {
+		return nil, fmt.Errorf("%w: row %d: %v", ErrMalformed, d.row+1, err)
+	} else if err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (d *ndjsonDecoder) Row() int {
+	return d.row
+}
@@ -0,0 +1,98 @@
+package transfer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+)
+
+func TestCSVDecoderMalformedHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing title", "author,isbn13\nAlan Donovan,9780134190440\n"},
+		{"unterminated quote", "\"title,author\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoder, err := NewDecoder(FormatCSV, strings.NewReader(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the header error is returned by every call, no row is read
+			for i := 0; i < 2; i++ {
+				if _, err := decoder.Next(); !errors.Is(err, ErrMalformed) {
+					t.Fatalf("call %d: got %v, want ErrMalformed", i+1, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVDecoderRows(t *testing.T) {
+	input := "Title,Author,Page_Count\nThe Go Programming Language,Alan Donovan,380\nRefactoring,Martin Fowler,many\n"
+	decoder, err := NewDecoder(FormatCSV, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Title != "The Go Programming Language" || book.Author != "Alan Donovan" || book.PageCount != 380 {
+		t.Errorf("got %+v", book)
+	}
+
+	_, err = decoder.Next()
+	var rowErr *models.RowError
+	if !errors.As(err, &rowErr) || rowErr.Row != 2 {
+		t.Fatalf("got %v, want a RowError of row 2", err)
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+}
+
+func TestCSVDecoderEmpty(t *testing.T) {
+	decoder, err := NewDecoder(FormatCSV, strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+}
+
+func TestNDJSONDecoder(t *testing.T) {
+	input := "{\"id\": 7, \"title\": \"Refactoring\"}\n\n{\"title\": \n"
+	decoder, err := NewDecoder(FormatNDJSON, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.ID != 0 || book.Title != "Refactoring" {
+		t.Errorf("got %+v, want the title without id", book)
+	}
+
+	_, err = decoder.Next()
+	var rowErr *models.RowError
+	if !errors.As(err, &rowErr) || rowErr.Row != 2 {
+		t.Fatalf("got %v, want a RowError of row 2", err)
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+}
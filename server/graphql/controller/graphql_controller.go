@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/go-rest-api-boilerplate/server/graphql/models"
+	"github.com/go-rest-api-boilerplate/server/graphql/schema"
+	"github.com/go-rest-api-boilerplate/util/dbrouter"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/labstack/echo/v4"
+)
+
+// maxRequestBytes bound the body of a GraphQL request
+const maxRequestBytes = 1 << 20
+
+//InitGraphQLController struct
+type InitGraphQLController struct {
+	Schema graphql.Schema
+	Book   service.BookService
+	Config schema.Config
+}
+
+//NewGraphQLRoutes func
+func NewGraphQLRoutes(bookService service.BookService, config schema.Config) (func(g *echo.Group), error) {
+	bookSchema, err := schema.NewSchema(bookService)
+	if err != nil {
+		return nil, err
+	}
+
+	graphQLServer := &InitGraphQLController{
+		Schema: bookSchema,
+		Book:   bookService,
+		Config: config,
+	}
+
+	return func(g *echo.Group) {
+		g.GET("/graphql", graphQLServer.GetGraphQL)
+		g.POST("/graphql", graphQLServer.PostGraphQL)
+	}, nil
+}
+
+// GetGraphQL godoc
+// @Summary Run a GraphQL query
+// @Description Run a GraphQL query, mutations must be posted. In development a browser is served the GraphiQL playground
+// @Tags graphql
+// @Accept */*
+// @Produce json
+// @Param query query string true "GraphQL document"
+// @Param operationName query string false "Operation to run"
+// @Param variables query string false "JSON object of the variables"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Router /graphql [get]
+// GetGraphQL func
+func (init *InitGraphQLController) GetGraphQL(ctx echo.Context) error {
+	query := ctx.QueryParam("query")
+	if query == "" && init.Config.Playground &&
+		strings.Contains(ctx.Request().Header.Get(echo.HeaderAccept), echo.MIMETextHTML) {
+		return ctx.HTML(http.StatusOK, playground)
+	}
+
+	req := &models.Request{
+		Query:         query,
+		OperationName: ctx.QueryParam("operationName"),
+	}
+	if variables := ctx.QueryParam("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			return reject(ctx, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		}
+	}
+
+	return init.serve(ctx, req, false)
+}
+
+// PostGraphQL godoc
+// @Summary Run a GraphQL operation
+// @Description Run a GraphQL query or mutation, the body is a JSON request or an application/graphql document
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body models.Request true "GraphQL request"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /graphql [post]
+// PostGraphQL func
+func (init *InitGraphQLController) PostGraphQL(ctx echo.Context) error {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxRequestBytes))
+	if err != nil {
+		return reject(ctx, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+	}
+
+	req := new(models.Request)
+	mediaType, _, _ := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	if mediaType == "application/graphql" {
+		req.Query = string(body)
+	} else if err := json.Unmarshal(body, req); err != nil {
+		return reject(ctx, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+	}
+
+	return init.serve(ctx, req, true)
+}
+
+// serve parse, validate and limit the document before executing it
+func (init *InitGraphQLController) serve(ctx echo.Context, req *models.Request, mutable bool) error {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return reject(ctx, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+	}
+
+	validation := graphql.ValidateDocument(&init.Schema, document, nil)
+	if !validation.IsValid {
+		return reject(ctx, http.StatusBadRequest, validation.Errors)
+	}
+
+	mutation := schema.OperationType(document, req.OperationName) == "mutation"
+	if !mutable && mutation {
+		ctx.Response().Header().Set(echo.HeaderAllow, http.MethodPost)
+		return reject(ctx, http.StatusMethodNotAllowed,
+			[]gqlerrors.FormattedError{gqlerrors.NewFormattedError("mutations must be sent with POST")})
+	}
+
+	if err := schema.Check(document, req.OperationName, req.Variables, init.Config); err != nil {
+		return reject(ctx, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+	}
+
+	// the books written by a mutation are read back from the primary
+	reqCtx := ctx.Request().Context()
+	if mutation {
+		reqCtx = dbrouter.WithReadYourWrites(reqCtx)
+	}
+
+	// loaders batch and cache the lookups of this request only
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        init.Schema,
+		AST:           document,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       schema.WithLoaders(reqCtx, init.Book),
+	})
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// reject answer a request refused before execution
+func reject(ctx echo.Context, status int, errs []gqlerrors.FormattedError) error {
+	return ctx.JSON(status, &graphql.Result{Errors: errs})
+}
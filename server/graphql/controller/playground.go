@@ -0,0 +1,29 @@
+package controller
+
+// playground is the GraphiQL page, served in development only
+const playground = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Book GraphQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@1.4.7/graphiql.min.css">
+  <style>html, body, #graphiql { height: 100%; margin: 0; }</style>
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script src="https://unpkg.com/react@17/umd/react.production.min.js"></script>
+  <script src="https://unpkg.com/react-dom@17/umd/react-dom.production.min.js"></script>
+  <script src="https://unpkg.com/graphiql@1.4.7/graphiql.min.js"></script>
+  <script>
+    function fetcher(params) {
+      return fetch(window.location.pathname, {
+        method: 'POST',
+        headers: {'Accept': 'application/json', 'Content-Type': 'application/json'},
+        body: JSON.stringify(params)
+      }).then(function (response) { return response.json(); });
+    }
+    ReactDOM.render(React.createElement(GraphiQL, {fetcher: fetcher}), document.getElementById('graphiql'));
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,21 @@
+package models
+
+// Request is a GraphQL request, sent as JSON body or as query parameters of a GET request
+type Request struct {
+	Query         string                 `json:"query" example:"{ books(page: {perPage: 5}) { id title cover { url(size: SMALL) } } }"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Error is a GraphQL error, extensions.code is set for the errors of a resolver
+type Error struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Response is a GraphQL response, data is null when the request is rejected before execution
+type Response struct {
+	Data   interface{} `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
@@ -0,0 +1,33 @@
+package schema
+
+import (
+	"os"
+	"strconv"
+)
+
+// Config limit the cost of a GraphQL document
+type Config struct {
+	MaxDepth      int
+	MaxComplexity int
+	Playground    bool
+}
+
+// DefaultConfig allow 8 levels of selection and a complexity of 1000, without playground
+var DefaultConfig = Config{
+	MaxDepth:      8,
+	MaxComplexity: 1000,
+}
+
+// ConfigFromEnv override DefaultConfig with GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY,
+// the GraphiQL playground is served when APP_ENV is development
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if v, err := strconv.Atoi(os.Getenv("GRAPHQL_MAX_DEPTH")); err == nil && v > 0 {
+		config.MaxDepth = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("GRAPHQL_MAX_COMPLEXITY")); err == nil && v > 0 {
+		config.MaxComplexity = v
+	}
+	config.Playground = os.Getenv("APP_ENV") == "development"
+	return config
+}
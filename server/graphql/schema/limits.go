@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// limits measure the depth and complexity of the operation of a validated document
+type limits struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// Check return an error when the operation exceed the depth or complexity of config
+func Check(document *ast.Document, operationName string, variables map[string]interface{}, config Config) error {
+	l := &limits{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			l.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	if depth := l.depth(operation.SelectionSet, map[string]bool{}); depth > config.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, config.MaxDepth)
+	}
+	if complexity := l.complexity(operation.SelectionSet, map[string]bool{}); complexity > config.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, config.MaxComplexity)
+	}
+	return nil
+}
+
+// OperationType return the type of the operation executed for operationName, query mutation or subscription
+func OperationType(document *ast.Document, operationName string) string {
+	for _, definition := range document.Definitions {
+		if d, ok := definition.(*ast.OperationDefinition); ok {
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				return d.Operation
+			}
+		}
+	}
+	return ""
+}
+
+// depth return the deepest field nesting of set, fragments count as their fields
+func (l *limits) depth(set *ast.SelectionSet, visiting map[string]bool) int {
+	if set == nil {
+		return 0
+	}
+
+	max := 0
+	for _, selection := range set.Selections {
+		var d int
+		switch s := selection.(type) {
+		case *ast.Field:
+			d = 1 + l.depth(s.SelectionSet, visiting)
+		case *ast.InlineFragment:
+			d = l.depth(s.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			d = l.spread(s, visiting, l.depth)
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// complexity cost 1 per field, the fields selected under a list of books cost once per book of the page
+func (l *limits) complexity(set *ast.SelectionSet, visiting map[string]bool) int {
+	if set == nil {
+		return 0
+	}
+
+	total := 0
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			total += 1 + l.multiplier(s)*l.complexity(s.SelectionSet, visiting)
+		case *ast.InlineFragment:
+			total += l.complexity(s.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			total += l.spread(s, visiting, l.complexity)
+		}
+	}
+	return total
+}
+
+// spread measure a fragment, a cycle measure nothing and is reported by validation
+func (l *limits) spread(s *ast.FragmentSpread, visiting map[string]bool,
+	measure func(*ast.SelectionSet, map[string]bool) int) int {
+	fragment, ok := l.fragments[s.Name.Value]
+	if !ok || visiting[s.Name.Value] {
+		return 0
+	}
+
+	visiting[s.Name.Value] = true
+	defer delete(visiting, s.Name.Value)
+	return measure(fragment.SelectionSet, visiting)
+}
+
+// multiplier return the page size requested by a books field, 1 for other fields
+func (l *limits) multiplier(field *ast.Field) int {
+	if field.Name.Value != "books" {
+		return 1
+	}
+
+	perPage := DefaultPerPage
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "page" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.ObjectValue:
+			for _, f := range value.Fields {
+				if f.Name.Value == "perPage" {
+					if v, ok := l.value(f.Value).(int); ok {
+						perPage = v
+					}
+				}
+			}
+		case *ast.Variable:
+			if page, ok := l.variables[value.Name.Value].(map[string]interface{}); ok {
+				if v, ok := number(page["perPage"]); ok {
+					perPage = v
+				}
+			}
+		}
+	}
+
+	if perPage < 1 || perPage > MaxPerPage {
+		// the resolver reject it, count the largest page
+		perPage = MaxPerPage
+	}
+	return perPage
+}
+
+// value return the int of a literal or variable
+func (l *limits) value(v ast.Value) interface{} {
+	switch value := v.(type) {
+	case *ast.IntValue:
+		var n int
+		if _, err := fmt.Sscan(value.Value, &n); err == nil {
+			return n
+		}
+	case *ast.Variable:
+		if n, ok := number(l.variables[value.Name.Value]); ok {
+			return n
+		}
+	}
+	return nil
+}
+
+// number convert a decoded JSON variable to int
+func number(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestCheck(t *testing.T) {
+	config := Config{MaxDepth: 3, MaxComplexity: 100}
+
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]interface{}
+		err       string
+	}{
+		{"within limits", `{ book(id: 1) { title cover { url } } }`, "", nil, ""},
+		{"too deep", `{ book(id: 1) { cover { url { x } } } }`, "", nil, "depth 4"},
+		{"fragment depth", `query { book(id: 1) { ...cover } } fragment cover on Book { cover { url { x } } }`, "",
+			nil, "depth 4"},
+		{"fragment cycle", `query { book(id: 1) { ...a } } fragment a on Book { ...b } fragment b on Book { ...a }`,
+			"", nil, ""},
+		// 1 + 10 * 2
+		{"page literal", `{ books(page: {perPage: 10}) { id title } }`, "", nil, ""},
+		// 1 + 20 * 5
+		{"default page", `{ books { id title author isbn13 format } }`, "", nil, "complexity 101"},
+		{"page variable", `query ($page: PageInput) { books(page: $page) { id title author isbn13 format } }`, "",
+			map[string]interface{}{"page": map[string]interface{}{"perPage": float64(5)}}, ""},
+		{"page field variable", `query ($n: Int) { books(page: {perPage: $n}) { id title } }`, "",
+			map[string]interface{}{"n": float64(60)}, "complexity 121"},
+		// the resolver reject the page, it costs the largest one
+		{"page out of range", `{ books(page: {perPage: 100000}) { id } }`, "", nil, "complexity 101"},
+		{"named operation", `query small { book(id: 1) { id } } query deep { a { b { c { d } } } }`, "small", nil,
+			""},
+		{"other named operation", `query small { book(id: 1) { id } } query deep { a { b { c { d } } } }`, "deep",
+			nil, "depth 4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document, err := parser.Parse(parser.ParseParams{Source: test.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = Check(document, test.operation, test.variables, config)
+			if test.err == "" && err != nil {
+				t.Fatalf("got %v, want no error", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("got %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestOperationType(t *testing.T) {
+	document, err := parser.Parse(parser.ParseParams{Source: `query list { books { id } } mutation remove { deleteBook(id: 1) }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := OperationType(document, "remove"); got != "mutation" {
+		t.Errorf("got %q, want mutation", got)
+	}
+	if got := OperationType(document, "missing"); got != "" {
+		t.Errorf("got %q for a missing operation", got)
+	}
+}
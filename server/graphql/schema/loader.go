@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/service"
+)
+
+// BatchFunc load the values of many keys at once, a missing key resolve to null
+type BatchFunc func(ctx context.Context, keys []int64) (map[int64]interface{}, error)
+
+// Loader collect the keys requested while the executor resolve one level of the query
+// and load them in a single batch when the first value is needed
+type Loader struct {
+	mu      sync.Mutex
+	batch   BatchFunc
+	pending []int64
+	values  map[int64]interface{}
+	errs    map[int64]error
+}
+
+// NewLoader return a Loader caching its values for its lifetime, one request
+func NewLoader(batch BatchFunc) *Loader {
+	return &Loader{
+		batch:  batch,
+		values: make(map[int64]interface{}),
+		errs:   make(map[int64]error),
+	}
+}
+
+// Load queue key and return a thunk, the executor call every thunk of a level after resolving the level
+func (l *Loader) Load(ctx context.Context, key int64) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.loaded(key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if !l.loaded(key) {
+			l.dispatch(ctx)
+		}
+		return l.values[key], l.errs[key]
+	}
+}
+
+func (l *Loader) loaded(key int64) bool {
+	_, ok := l.values[key]
+	if !ok {
+		_, ok = l.errs[key]
+	}
+	return ok
+}
+
+// dispatch load every pending key, the lock is held
+func (l *Loader) dispatch(ctx context.Context) {
+	keys := make([]int64, 0, len(l.pending))
+	seen := make(map[int64]bool, len(l.pending))
+	for _, key := range l.pending {
+		if !seen[key] && !l.loaded(key) {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	l.pending = nil
+
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.values[key] = values[key]
+	}
+}
+
+// loaders of one request
+type loaders struct {
+	book  *Loader
+	cover *Loader
+}
+
+type loadersKey struct{}
+
+// WithLoaders return a context carrying new loaders backed by bookService
+func WithLoaders(ctx context.Context, bookService service.BookService) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		book: NewLoader(func(ctx context.Context, ids []int64) (map[int64]interface{}, error) {
+			books, err := bookService.ListBook(ctx, &models.BookFilter{IDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[int64]interface{}, len(books))
+			for _, book := range books {
+				values[book.ID] = book
+			}
+			return values, nil
+		}),
+		cover: NewLoader(func(ctx context.Context, ids []int64) (map[int64]interface{}, error) {
+			covers, err := bookService.ListCover(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[int64]interface{}, len(covers))
+			for _, cover := range covers {
+				values[cover.BookID] = cover
+			}
+			return values, nil
+		}),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,398 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/server/book/cover"
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/service"
+	"github.com/graphql-go/graphql"
+)
+
+// Error codes of the extensions of a resolver error
+const (
+	CodeBadUserInput = "BAD_USER_INPUT"
+	CodeNotFound     = "NOT_FOUND"
+	CodeConflict     = "CONFLICT"
+	CodeInternal     = "INTERNAL"
+)
+
+// Pagination of books
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Error is a resolver error carrying a code in its extensions
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap return the cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Extensions implement gqlerrors.ExtendedError
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+// serviceError map the errors of the book service to their code
+func serviceError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrBookNotFound):
+		return &Error{Code: CodeNotFound, Err: err}
+	case errors.Is(err, service.ErrBookConflict):
+		return &Error{Code: CodeConflict, Err: err}
+	case errors.Is(err, service.ErrUnknownAuthor):
+		return &Error{Code: CodeBadUserInput, Err: err}
+	}
+	return &Error{Code: CodeInternal, Err: err}
+}
+
+//NewSchema func
+func NewSchema(bookService service.BookService) (graphql.Schema, error) {
+	coverSize := graphql.NewEnum(graphql.EnumConfig{
+		Name: "CoverSize",
+		Values: graphql.EnumValueConfigMap{
+			"ORIGINAL": &graphql.EnumValueConfig{Value: cover.SizeOriginal},
+			"SMALL":    &graphql.EnumValueConfig{Value: cover.SizeSmall},
+			"MEDIUM":   &graphql.EnumValueConfig{Value: cover.SizeMedium},
+			"LARGE":    &graphql.EnumValueConfig{Value: cover.SizeLarge},
+		},
+	})
+
+	coverType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Cover",
+		Fields: graphql.Fields{
+			"contentType": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"width":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"height":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"size":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"etag":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"updatedAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"url": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Args: graphql.FieldConfigArgument{
+					"size": &graphql.ArgumentConfig{Type: coverSize, DefaultValue: cover.SizeOriginal},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					c := p.Source.(*models.Cover)
+					return fmt.Sprintf("/book/%d/cover?size=%s", c.BookID, p.Args["size"]), nil
+				},
+			},
+		},
+	})
+
+	bookAuthorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BookAuthor",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"role":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"position": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	bookType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Book",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"isbn10":    &graphql.Field{Type: graphql.String},
+			"isbn13":    &graphql.Field{Type: graphql.String},
+			"title":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"subtitle":  &graphql.Field{Type: graphql.String},
+			"author":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"authors":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookAuthorType)))},
+			"publisher": &graphql.Field{Type: graphql.String},
+			"publishedDate": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if date := p.Source.(*models.Book).PublishedDate.String(); date != "" {
+						return date, nil
+					}
+					return nil, nil
+				},
+			},
+			"language":    &graphql.Field{Type: graphql.String},
+			"pageCount":   &graphql.Field{Type: graphql.Int},
+			"edition":     &graphql.Field{Type: graphql.String},
+			"description": &graphql.Field{Type: graphql.String},
+			"format":      &graphql.Field{Type: graphql.String},
+			"cover": &graphql.Field{
+				Type: coverType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).cover.Load(p.Context, p.Source.(*models.Book).ID), nil
+				},
+			},
+		},
+	})
+
+	bookFilterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"author":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"authorId": &graphql.InputObjectFieldConfig{Type: graphql.ID},
+		},
+	})
+
+	pageInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PageInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"page":    &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 1},
+			"perPage": &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: DefaultPerPage},
+		},
+	})
+
+	bookAuthorInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookAuthorInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":   &graphql.InputObjectFieldConfig{Type: graphql.ID},
+			"name": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"role": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	bookInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"isbn10":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"isbn13":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"title":         &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"subtitle":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"author":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"authors":       &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(bookAuthorInput))},
+			"publisher":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"publishedDate": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"language":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"pageCount":     &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"edition":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"description":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"format":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"book": &graphql.Field{
+				Type: bookType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					load := loadersFrom(p.Context).book.Load(p.Context, id)
+					return func() (interface{}, error) {
+						book, err := load()
+						if err != nil {
+							return nil, serviceError(err)
+						}
+						return book, nil
+					}, nil
+				},
+			},
+			"books": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType))),
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: bookFilterInput},
+					"page":   &graphql.ArgumentConfig{Type: pageInput},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					filter, err := bookFilter(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					books, err := bookService.ListBook(p.Context, filter)
+					if err != nil {
+						return nil, serviceError(err)
+					}
+					return books, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createBook": &graphql.Field{
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					book, err := bookFromInput(p.Args["input"])
+					if err != nil {
+						return nil, err
+					}
+					book, err = bookService.CreateBook(p.Context, book)
+					if err == nil {
+						book, err = bookService.GetBook(p.Context, book.ID)
+					}
+					if err != nil {
+						return nil, serviceError(err)
+					}
+					return book, nil
+				},
+			},
+			"updateBook": &graphql.Field{
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					book, err := bookFromInput(p.Args["input"])
+					if err != nil {
+						return nil, err
+					}
+					book.ID = id
+					book, err = bookService.UpdateBook(p.Context, book)
+					if err == nil {
+						book, err = bookService.GetBook(p.Context, id)
+					}
+					if err != nil {
+						return nil, serviceError(err)
+					}
+					return book, nil
+				},
+			},
+			"deleteBook": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					if err := bookService.DeleteBook(p.Context, id); err != nil {
+						return nil, serviceError(err)
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+// idArg parse an ID argument, ID values are strings
+func idArg(value interface{}) (int64, error) {
+	s, _ := value.(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, &Error{Code: CodeBadUserInput, Err: fmt.Errorf("invalid id %q", s)}
+	}
+	return id, nil
+}
+
+// bookFilter build the filter of the books query
+func bookFilter(args map[string]interface{}) (*models.BookFilter, error) {
+	filter := &models.BookFilter{Page: 1, PerPage: DefaultPerPage}
+
+	if input, ok := args["filter"].(map[string]interface{}); ok {
+		filter.Title, _ = input["title"].(string)
+		filter.Author, _ = input["author"].(string)
+		if input["authorId"] != nil {
+			id, err := idArg(input["authorId"])
+			if err != nil {
+				return nil, err
+			}
+			filter.AuthorID = id
+		}
+	}
+
+	if input, ok := args["page"].(map[string]interface{}); ok {
+		page, _ := input["page"].(int)
+		perPage, _ := input["perPage"].(int)
+		if page < 1 || perPage < 1 || perPage > MaxPerPage {
+			return nil, &Error{Code: CodeBadUserInput,
+				Err: fmt.Errorf("page must be at least 1 and perPage between 1 and %d", MaxPerPage)}
+		}
+		filter.Page = uint64(page)
+		filter.PerPage = uint64(perPage)
+	}
+
+	return filter, nil
+}
+
+// bookFromInput decode a BookInput like the REST body, then normalize and validate it
+func bookFromInput(input interface{}) (*models.Book, error) {
+	data, err := json.Marshal(snakeKeys(input))
+	if err != nil {
+		return nil, &Error{Code: CodeBadUserInput, Err: err}
+	}
+
+	book := new(models.Book)
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, &Error{Code: CodeBadUserInput, Err: err}
+	}
+
+	book.Normalize()
+	if err := book.Validate(); err != nil {
+		return nil, &Error{Code: CodeBadUserInput, Err: err}
+	}
+	return book, nil
+}
+
+// snakeKeys rename the camelCase keys of GraphQL inputs to the snake_case of the JSON models,
+// ID values are converted to numbers
+func snakeKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if key == "id" {
+				if s, ok := item.(string); ok {
+					item = json.Number(s)
+				}
+			}
+			out[snakeCase(key)] = snakeKeys(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = snakeKeys(item)
+		}
+		return out
+	}
+	return value
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util/validation"
+)
+
+// validate is shared by every model, validator.Validate caches struct metadata
+var validate = validation.New()
+
+// Tenant is a library branch owning its own catalog
+type Tenant struct {
+	ID        string    `json:"id" validate:"required,max=63"`
+	Name      string    `json:"name" validate:"required,max=255"`
+	Active    bool      `json:"active"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+//ScanTenant func
+func ScanTenant(rows *sql.Rows) (*Tenant, error) {
+	var tenant Tenant
+	err := rows.Scan(&tenant.ID, &tenant.Name, &tenant.Active, &tenant.UpdatedAt, &tenant.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// Validate tenant
+func (t *Tenant) Validate() error {
+	return validate.Struct(t)
+}
+
+// Normalize lowercase the tenant id and trim its name
+func (t *Tenant) Normalize() {
+	t.ID = strings.ToLower(strings.TrimSpace(t.ID))
+	t.Name = strings.TrimSpace(t.Name)
+}
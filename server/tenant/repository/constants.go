@@ -0,0 +1,27 @@
+package repository
+
+// Table Name
+const (
+	tenantTable = "tenants"
+)
+
+// Table Column Names
+const (
+	idColumn        = "id"
+	updatedAtColumn = "updated_at"
+	createdAtColumn = "created_at"
+
+	// Tenant Table Column Names
+	tenantNameColumn   = "name"
+	tenantActiveColumn = "active"
+)
+
+// postgres error codes
+const (
+	uniqueViolation = "23505"
+)
+
+// Table Columns
+var (
+	TenantColumns = []string{idColumn, tenantNameColumn, tenantActiveColumn, updatedAtColumn, createdAtColumn}
+)
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/server/tenant/models"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/lib/pq"
+)
+
+// TenantRepository to get tenant data from database
+type TenantRepository interface {
+	List(ctx context.Context) ([]*models.Tenant, error)
+	Find(ctx context.Context, id string) (*models.Tenant, error)
+	Insert(ctx context.Context, tenant *models.Tenant) (*models.Tenant, error)
+	SetActive(ctx context.Context, id string, active bool) error
+}
+
+var (
+	// ErrTenantConflict is returned when a tenant with the same id exists
+	ErrTenantConflict = errors.New("tenant already exists")
+	// ErrTenantNotFound is returned when a tenant does not exist
+	ErrTenantNotFound = errors.New("tenant not found")
+)
+
+//InitTenantRepository struct
+type InitTenantRepository struct {
+	connection *sql.DB
+}
+
+// NewTenantRepository return new instance of TenantRepository
+func NewTenantRepository(connection *sql.DB) TenantRepository {
+	return &InitTenantRepository{
+		connection: connection,
+	}
+}
+
+//List func
+func (init *InitTenantRepository) List(ctx context.Context) (list []*models.Tenant, err error) {
+	list = make([]*models.Tenant, 0)
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(TenantColumns...).From(tenantTable).OrderBy("id ASC")
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tenant *models.Tenant
+		tenant, err = models.ScanTenant(rows)
+		if err != nil {
+			return
+		}
+		list = append(list, tenant)
+	}
+
+	return list, rows.Err()
+}
+
+//Find func
+func (init *InitTenantRepository) Find(ctx context.Context, id string) (tenant *models.Tenant, err error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(TenantColumns...).
+		From(tenantTable).
+		Where(sq.Eq{idColumn: id})
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return tenant, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		tenant, err = models.ScanTenant(rows)
+	}
+
+	return tenant, err
+}
+
+//Insert func
+func (init *InitTenantRepository) Insert(ctx context.Context, tenant *models.Tenant) (*models.Tenant, error) {
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return tenant, err
+	}
+
+	query := sq.Insert(tenantTable).
+		Columns(idColumn, tenantNameColumn, tenantActiveColumn).
+		Values(tenant.ID, tenant.Name, tenant.Active).
+		Suffix("RETURNING \"updated_at\", \"created_at\"").
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	err = query.QueryRow().Scan(&tenant.UpdatedAt, &tenant.CreatedAt)
+	if err != nil {
+		trxn.SetError(err)
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return tenant, ErrTenantConflict
+		}
+		return tenant, err
+	}
+
+	return tenant, err
+}
+
+//SetActive func
+func (init *InitTenantRepository) SetActive(ctx context.Context, id string, active bool) error {
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Update(tenantTable).
+		Set(tenantActiveColumn, active).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{idColumn: id})
+
+	result, err := builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTenantNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-rest-api-boilerplate/server/tenant/models"
+	"github.com/go-rest-api-boilerplate/server/tenant/repository"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+)
+
+var (
+	// ErrTenantConflict is returned when a tenant with the same id exists
+	ErrTenantConflict = repository.ErrTenantConflict
+	// ErrTenantNotFound is returned when a tenant does not exist
+	ErrTenantNotFound = repository.ErrTenantNotFound
+)
+
+//TenantService interface
+type TenantService interface {
+	ListTenant(ctx context.Context) ([]*models.Tenant, error)
+	GetTenant(ctx context.Context, id string) (*models.Tenant, error)
+	CreateTenant(ctx context.Context, t *models.Tenant) (*models.Tenant, error)
+	SetTenantActive(ctx context.Context, id string, active bool) error
+	Active(ctx context.Context, id string) (bool, error)
+}
+
+//InitTenantService struct
+type InitTenantService struct {
+	Repository *InitTenantRepositoryInterface
+}
+
+//InitTenantRepositoryInterface struct
+type InitTenantRepositoryInterface struct {
+	Tenant repository.TenantRepository
+}
+
+// NewTenantService return new instance of TenantService
+func NewTenantService(tenantRepository repository.TenantRepository) TenantService {
+	return &InitTenantService{
+		Repository: &InitTenantRepositoryInterface{
+			Tenant: tenantRepository,
+		},
+	}
+}
+
+//ListTenant func
+func (init *InitTenantService) ListTenant(ctx context.Context) ([]*models.Tenant, error) {
+	return init.Repository.Tenant.List(ctx)
+}
+
+//GetTenant func
+func (init *InitTenantService) GetTenant(ctx context.Context, id string) (*models.Tenant, error) {
+	return init.Repository.Tenant.Find(ctx, id)
+}
+
+//CreateTenant func
+func (init *InitTenantService) CreateTenant(ctx context.Context, t *models.Tenant) (*models.Tenant, error) {
+	t.Normalize()
+	if err := t.Validate(); err != nil {
+		return t, err
+	}
+	if !tenant.Valid(t.ID) {
+		return t, tenant.ErrInvalid
+	}
+
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	t, err := init.Repository.Tenant.Insert(ctx, t)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return t, err
+}
+
+//SetTenantActive func
+func (init *InitTenantService) SetTenantActive(ctx context.Context, id string, active bool) error {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	err := init.Repository.Tenant.SetActive(ctx, id, active)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return err
+}
+
+//Active func implement tenant.Store
+func (init *InitTenantService) Active(ctx context.Context, id string) (bool, error) {
+	t, err := init.Repository.Tenant.Find(ctx, id)
+	if err != nil || t == nil {
+		return false, err
+	}
+	return t.Active, nil
+}
@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-rest-api-boilerplate/server/webhook/models"
+	"github.com/go-rest-api-boilerplate/server/webhook/service"
+	"github.com/go-rest-api-boilerplate/util/validation"
+	"github.com/labstack/echo/v4"
+)
+
+//InitWebhookController struct
+type InitWebhookController struct {
+	Service *InitWebhookServiceInterface
+}
+
+//InitWebhookServiceInterface struct
+type InitWebhookServiceInterface struct {
+	Webhook service.WebhookService
+}
+
+//NewWebhookRoutes func
+func NewWebhookRoutes(webhookService service.WebhookService) func(g *echo.Group) {
+	webhookServer := &InitWebhookController{
+		Service: &InitWebhookServiceInterface{
+			Webhook: webhookService,
+		},
+	}
+
+	return func(g *echo.Group) {
+		g.GET("/webhook", webhookServer.GetListWebhook)
+		g.GET("/webhook/:id", webhookServer.GetWebhook)
+		g.GET("/webhook/:id/deliveries", webhookServer.GetListDelivery)
+		g.POST("/webhook", webhookServer.CreateWebhook)
+		g.PUT("/webhook", webhookServer.UpdateWebhook)
+		g.DELETE("/webhook/:id", webhookServer.DeleteWebhook)
+	}
+}
+
+// GetListWebhook godoc
+// @Summary Get list of webhook
+// @Description Get list of webhook item, secrets are not returned
+// @Tags webhook
+// @Accept */*
+// @Produce json
+// @Success 200 {object} models.SuccessResponseList
+// @Failure 500 {object} models.SuccessResponseList
+// @Router /webhook [get]
+// GetListWebhook func
+func (init *InitWebhookController) GetListWebhook(ctx echo.Context) error {
+	webhooks, err := init.Service.Webhook.ListWebhook(ctx.Request().Context())
+	if err != nil {
+		data := &models.SuccessResponseList{
+			Status:  500,
+			Message: "failed",
+			Data:    make([]*models.Webhook, 0),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseList{
+		Status:  200,
+		Message: "success",
+		Data:    webhooks,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Description Get a webhook item, the secret is not returned
+// @Tags webhook
+// @Accept */*
+// @Produce json
+// @Param id path integer true "Webhook ID"
+// @Success 200 {object} models.SuccessResponseObject
+// @Failure 500 {object} models.SuccessResponseObject
+// @Router /webhook/{id} [get]
+// GetWebhook func
+func (init *InitWebhookController) GetWebhook(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	webhook, err := init.Service.Webhook.GetWebhook(ctx.Request().Context(), id)
+	if err != nil {
+		data := &models.SuccessResponseObject{
+			Status:  500,
+			Message: "failed",
+			Data:    new(models.Webhook),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseObject{
+		Status:  200,
+		Message: "success",
+		Data:    webhook,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// GetListDelivery godoc
+// @Summary Get the deliveries of a webhook
+// @Description Get the deliveries of a webhook with the last response code, newest first
+// @Tags webhook
+// @Accept */*
+// @Produce json
+// @Param id path integer true "Webhook ID"
+// @Param status query string false "Delivery status" Enums(pending, succeeded, failed)
+// @Param page query integer false "Page, starting at 1"
+// @Param per_page query integer false "Deliveries per page, at most 200"
+// @Success 200 {object} models.SuccessResponseDeliveryList
+// @Failure 400 {object} models.SuccessResponseDeliveryList
+// @Failure 404 {object} models.SuccessResponseDeliveryList
+// @Failure 500 {object} models.SuccessResponseDeliveryList
+// @Router /webhook/{id}/deliveries [get]
+// GetListDelivery func
+func (init *InitWebhookController) GetListDelivery(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	filter := new(models.DeliveryFilter)
+	err := (&echo.DefaultBinder{}).BindQueryParams(ctx, filter)
+	if err != nil {
+		data := &models.SuccessResponseDeliveryList{
+			Status:  400,
+			Message: err.Error(),
+			Data:    make([]*models.Delivery, 0),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	deliveries, pagination, err := init.Service.Webhook.ListDelivery(ctx.Request().Context(), id, filter)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		data := &models.SuccessResponseDeliveryList{
+			Status:  404,
+			Message: err.Error(),
+			Data:    make([]*models.Delivery, 0),
+		}
+
+		return ctx.JSON(http.StatusNotFound, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponseDeliveryList{
+			Status:  500,
+			Message: "failed",
+			Data:    make([]*models.Delivery, 0),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseDeliveryList{
+		Status:     200,
+		Message:    "success",
+		Data:       deliveries,
+		Pagination: pagination,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description Subscribe an endpoint to book events. The response holds the secret signing the deliveries, generated when missing, it is not returned again
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Param webhook body models.Webhook true "Param Webhook"
+// @Param Idempotency-Key header string false "Key replaying the response of a retried request"
+// @Success 201 {object} models.SuccessResponseObject
+// @Failure 400 {object} models.SuccessResponseObject
+// @Failure 500 {object} models.SuccessResponseObject
+// @Router /webhook [post]
+// CreateWebhook func
+func (init *InitWebhookController) CreateWebhook(ctx echo.Context) error {
+	var webhook *models.Webhook
+	err := ctx.Bind(&webhook)
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponseObject{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	webhook.Normalize()
+	err = webhook.Validate()
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponseObject{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	webhook, err = init.Service.Webhook.CreateWebhook(ctx.Request().Context(), webhook)
+	if err != nil {
+		data := &models.SuccessResponseObject{
+			Status:  500,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseObject{
+		Status:  201,
+		Message: fmt.Sprintf("Create webhook success #%d", webhook.ID),
+		Data:    webhook,
+	}
+
+	return ctx.JSON(http.StatusCreated, data)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Update a webhook item, the secret is kept when empty. Activating a disabled webhook reset its failure count and resume its deliveries
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Param webhook body models.Webhook true "Param Webhook"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.SuccessResponse
+// @Failure 404 {object} models.SuccessResponse
+// @Failure 500 {object} models.SuccessResponse
+// @Router /webhook [put]
+// UpdateWebhook func
+func (init *InitWebhookController) UpdateWebhook(ctx echo.Context) error {
+	var webhook *models.Webhook
+	err := ctx.Bind(&webhook)
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	webhook.Normalize()
+	err = webhook.Validate()
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	_, err = init.Service.Webhook.UpdateWebhook(ctx.Request().Context(), webhook)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		data := &models.SuccessResponse{
+			Status:  404,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusNotFound, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  200,
+		Message: "success",
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook item and its deliveries
+// @Tags webhook
+// @Accept */*
+// @Produce json
+// @Param id path integer true "Webhook ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.SuccessResponse
+// @Failure 500 {object} models.SuccessResponse
+// @Router /webhook/{id} [delete]
+// DeleteWebhook func
+func (init *InitWebhookController) DeleteWebhook(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	err := init.Service.Webhook.DeleteWebhook(ctx.Request().Context(), id)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		data := &models.SuccessResponse{
+			Status:  404,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusNotFound, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: "failed",
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  200,
+		Message: "success",
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
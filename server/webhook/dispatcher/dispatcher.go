@@ -0,0 +1,309 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/server/webhook/models"
+	"github.com/go-rest-api-boilerplate/server/webhook/repository"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+)
+
+// maxResponseBytes is the part of the response body kept in the delivery log
+const maxResponseBytes = 1024
+
+const userAgent = "go-rest-api-boilerplate-webhook/1.0"
+
+type (
+	// Config tune the deliveries of a Dispatcher
+	Config struct {
+		Interval     time.Duration
+		Workers      int
+		Timeout      time.Duration
+		MaxAttempts  int
+		MinBackoff   time.Duration
+		MaxBackoff   time.Duration
+		DisableAfter int
+		// AllowPrivate let the endpoints resolve to loopback and private addresses, such as in development
+		AllowPrivate bool
+	}
+
+	// endpoint bound the concurrent deliveries of a webhook
+	endpoint struct {
+		slots   chan bool
+		claimed int
+	}
+
+	// Dispatcher send the pending deliveries in the background, Workers bound
+	// the deliveries in flight and Webhook.MaxConcurrency the ones of an endpoint
+	Dispatcher struct {
+		sync.Mutex
+		repository repository.WebhookRepository
+		client     *http.Client
+		config     Config
+		workers    chan bool
+		endpoints  map[int64]*endpoint
+		inflight   sync.WaitGroup
+		stop       chan bool
+		done       chan bool
+	}
+)
+
+// DefaultConfig retry for about a day and disable a webhook after 20 consecutive failures
+var DefaultConfig = Config{
+	Interval:     time.Second,
+	Workers:      32,
+	Timeout:      10 * time.Second,
+	MaxAttempts:  10,
+	MinBackoff:   10 * time.Second,
+	MaxBackoff:   6 * time.Hour,
+	DisableAfter: 20,
+}
+
+// ConfigFromEnv override DefaultConfig with WEBHOOK_POLL_INTERVAL, WEBHOOK_WORKERS,
+// WEBHOOK_TIMEOUT, WEBHOOK_MAX_ATTEMPTS, WEBHOOK_DISABLE_AFTER and WEBHOOK_ALLOW_PRIVATE
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if v, err := time.ParseDuration(os.Getenv("WEBHOOK_POLL_INTERVAL")); err == nil && v > 0 {
+		config.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_WORKERS")); err == nil && v > 0 {
+		config.Workers = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil && v > 0 {
+		config.Timeout = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && v > 0 {
+		config.MaxAttempts = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_DISABLE_AFTER")); err == nil && v > 0 {
+		config.DisableAfter = v
+	}
+	config.AllowPrivate, _ = strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE"))
+	return config
+}
+
+// NewDispatcher return a Dispatcher of the deliveries stored by the repository
+func NewDispatcher(webhookRepository repository.WebhookRepository, config Config) *Dispatcher {
+	return &Dispatcher{
+		repository: webhookRepository,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			// the endpoints are set by the API clients, they cannot reach the internal network
+			Transport: guardedTransport(config.AllowPrivate),
+			// a redirect is a misconfigured endpoint, the signature is not sent elsewhere
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config:    config,
+		workers:   make(chan bool, config.Workers),
+		endpoints: make(map[int64]*endpoint),
+		stop:      make(chan bool),
+		done:      make(chan bool),
+	}
+}
+
+// Start dispatching in the background
+func (d *Dispatcher) Start() error {
+	go d.run()
+	return nil
+}
+
+// Stop claiming deliveries and wait for the ones in flight
+func (d *Dispatcher) Stop() error {
+	d.stop <- true
+	<-d.done
+	return nil
+}
+
+// lease cover a claimed delivery waiting behind every other worker on its endpoint
+func (d *Dispatcher) lease() time.Duration {
+	return d.config.Timeout * time.Duration(d.config.Workers+1)
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	logger := util.Log.WithField("context", "webhook")
+	for {
+		if free := cap(d.workers) - len(d.workers); free > 0 {
+			jobs, err := d.repository.Claim(context.Background(), free, d.lease(), d.busy())
+			if err != nil {
+				logger.Errorf("claim deliveries: %v", err)
+			}
+
+			for _, job := range jobs {
+				d.workers <- true
+				d.inflight.Add(1)
+				go d.deliver(job, d.acquire(job))
+			}
+		}
+
+		select {
+		case <-d.stop:
+			d.inflight.Wait()
+			return
+		case <-time.After(d.config.Interval):
+		}
+	}
+}
+
+// busy return the webhooks having as many claimed deliveries as their limit
+func (d *Dispatcher) busy() []int64 {
+	d.Lock()
+	defer d.Unlock()
+
+	busy := make([]int64, 0)
+	for webhookID, e := range d.endpoints {
+		if e.claimed >= cap(e.slots) {
+			busy = append(busy, webhookID)
+		}
+	}
+	return busy
+}
+
+// acquire count a claimed delivery of the job webhook and return its endpoint
+func (d *Dispatcher) acquire(job *models.Job) *endpoint {
+	d.Lock()
+	defer d.Unlock()
+
+	size := job.MaxConcurrency
+	if size < 1 {
+		size = 1
+	}
+
+	// a changed limit applies once the deliveries in flight are done
+	e, ok := d.endpoints[job.WebhookID]
+	if !ok || (cap(e.slots) != size && e.claimed == 0) {
+		e = &endpoint{slots: make(chan bool, size)}
+		d.endpoints[job.WebhookID] = e
+	}
+	e.claimed++
+	return e
+}
+
+// release a delivery of the endpoint, an idle endpoint is forgotten
+func (d *Dispatcher) release(webhookID int64, e *endpoint) {
+	d.Lock()
+	defer d.Unlock()
+
+	e.claimed--
+	if e.claimed == 0 && d.endpoints[webhookID] == e {
+		delete(d.endpoints, webhookID)
+	}
+}
+
+func (d *Dispatcher) deliver(job *models.Job, e *endpoint) {
+	defer func() {
+		d.release(job.WebhookID, e)
+		<-d.workers
+		d.inflight.Done()
+	}()
+
+	e.slots <- true
+	attempt := d.send(job)
+	<-e.slots
+
+	d.save(job, attempt)
+}
+
+// send POST the event to the endpoint, any status other than 2xx is a failure
+func (d *Dispatcher) send(job *models.Job) *models.Attempt {
+	attempt := new(models.Attempt)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, job.URL, bytes.NewReader(job.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req = req.WithContext(ctx)
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, job.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(job.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, job.Payload))
+
+	start := time.Now()
+	res, err := d.client.Do(req)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+	// drain the rest so the connection is reused
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64<<10))
+
+	attempt.ResponseCode = res.StatusCode
+	attempt.ResponseBody = sanitize(string(body))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected response status %s", res.Status)
+	}
+
+	return attempt
+}
+
+// save the attempt, schedule the retry and update the failure count of the endpoint
+func (d *Dispatcher) save(job *models.Job, attempt *models.Attempt) {
+	logger := util.Log.WithField("context", "webhook").WithField("delivery_id", job.ID)
+
+	succeeded := attempt.Error == ""
+	status := models.DeliverySucceeded
+	var retryIn time.Duration
+	if !succeeded {
+		attempts := job.Attempts + 1
+		status = models.DeliveryPending
+		retryIn = util.Backoff(attempts, d.config.MinBackoff, d.config.MaxBackoff)
+		if attempts >= d.config.MaxAttempts {
+			status = models.DeliveryFailed
+		}
+		logger.Warnf("attempt %d to %s failed: %s", attempts, job.URL, attempt.Error)
+	}
+
+	//start transaction
+	ctx := context.Background()
+	commit := dbtrxn.Begin(&ctx)
+
+	err := d.repository.SaveAttempt(ctx, job, attempt, status, retryIn)
+	active := true
+	if err == nil {
+		active, err = d.repository.SaveEndpointResult(ctx, job.WebhookID, succeeded, d.config.DisableAfter)
+	}
+
+	//transaction commit or rollback if error
+	if commitErr := commit(); err == nil {
+		err = commitErr
+	}
+	if err != nil {
+		// the delivery is retried once its lease expires
+		logger.Errorf("save attempt: %v", err)
+		return
+	}
+	if !active {
+		logger.Warnf("webhook #%d is disabled, its deliveries are paused", job.WebhookID)
+	}
+}
+
+// sanitize make a response body storable as postgres text
+func sanitize(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "�"), "\x00", "")
+}
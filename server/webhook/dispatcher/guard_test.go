@@ -0,0 +1,63 @@
+package dispatcher
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/server/webhook/models"
+)
+
+func TestForbidden(t *testing.T) {
+	tests := []struct {
+		ip        string
+		forbidden bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"93.184.216.34", false},
+		{"2606:2800:220:1::1", false},
+	}
+
+	for _, test := range tests {
+		if got := Forbidden(net.ParseIP(test.ip)); got != test.forbidden {
+			t.Errorf("Forbidden(%s) = %t, want %t", test.ip, got, test.forbidden)
+		}
+	}
+}
+
+func TestSendGuard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	job := &models.Job{URL: server.URL, Secret: "secret"}
+	job.EventType = "book.created"
+	job.Payload = []byte(`{}`)
+
+	// the test server listen on the loopback
+	attempt := NewDispatcher(nil, DefaultConfig).send(job)
+	if !strings.Contains(attempt.Error, ErrForbiddenAddress.Error()) || attempt.ResponseCode != 0 {
+		t.Errorf("got %+v, want the loopback endpoint refused", attempt)
+	}
+
+	config := DefaultConfig
+	config.AllowPrivate = true
+	attempt = NewDispatcher(nil, config).send(job)
+	if attempt.Error != "" || attempt.ResponseCode != http.StatusNoContent {
+		t.Errorf("got %+v, want the delivery allowed", attempt)
+	}
+}
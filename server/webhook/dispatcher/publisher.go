@@ -0,0 +1,26 @@
+package dispatcher
+
+import (
+	"context"
+
+	"github.com/go-rest-api-boilerplate/server/webhook/repository"
+	"github.com/go-rest-api-boilerplate/util/outbox"
+)
+
+// Publisher queue a delivery of every outbox event for each subscribed webhook,
+// the deliveries are written in the transaction of the outbox relay
+type Publisher struct {
+	repository repository.WebhookRepository
+}
+
+// NewPublisher return an outbox.Publisher feeding the webhook deliveries
+func NewPublisher(webhookRepository repository.WebhookRepository) outbox.Publisher {
+	return &Publisher{
+		repository: webhookRepository,
+	}
+}
+
+//Publish func
+func (p *Publisher) Publish(ctx context.Context, event *outbox.Event) error {
+	return p.repository.Fanout(ctx, event)
+}
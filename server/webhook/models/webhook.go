@@ -0,0 +1,211 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util/validation"
+	"github.com/lib/pq"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// validate is shared by every model, validator.Validate caches struct metadata
+var validate = newValidator()
+
+func newValidator() *validation.Validator {
+	v := validation.New()
+
+	v.RegisterValidation("httpurl", func(fl validator.FieldLevel) bool {
+		u, err := url.Parse(fl.Field().String())
+		return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+	})
+
+	return v
+}
+
+// EventAll subscribe a webhook to every event
+const EventAll = "*"
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Pagination defaults of the delivery log
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 200
+)
+
+// Webhook is an endpoint subscribed to events, Secret is only returned on creation
+type Webhook struct {
+	ID                  int64      `json:"id"`
+	URL                 string     `json:"url" validate:"required,url,httpurl,max=2048" example:"https://example.com/hooks/books"`
+	Events              []string   `json:"events" validate:"required,min=1,dive,oneof=* book.created book.updated book.deleted book.cover_updated book.imported book.truncated" example:"book.created,book.updated"`
+	Secret              string     `json:"secret,omitempty" validate:"omitempty,min=16,max=128"`
+	Active              bool       `json:"active"`
+	MaxConcurrency      int        `json:"max_concurrency" validate:"min=0,max=64"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	UpdatedAt           time.Time  `json:"-"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// Delivery is an event sent, or to send, to a webhook
+type Delivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int64           `json:"webhook_id"`
+	EventID       int64           `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"-"`
+	Status        string          `json:"status" enums:"pending,succeeded,failed"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"response_code,omitempty"`
+	ResponseBody  string          `json:"response_body,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	DurationMS    int64           `json:"duration_ms,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Job is a claimed delivery with its endpoint
+type Job struct {
+	Delivery
+	URL            string
+	Secret         string
+	MaxConcurrency int
+}
+
+// Attempt is the outcome of sending a delivery
+type Attempt struct {
+	ResponseCode int
+	ResponseBody string
+	Error        string
+	Duration     time.Duration
+}
+
+// DeliveryFilter is the list query of the delivery log
+type DeliveryFilter struct {
+	Status  string `query:"status"`
+	Page    uint64 `query:"page"`
+	PerPage uint64 `query:"per_page"`
+}
+
+// Pagination of a list response
+type Pagination struct {
+	Page    uint64 `json:"page"`
+	PerPage uint64 `json:"per_page"`
+	Total   int64  `json:"total"`
+}
+
+// SuccessResponseList struct
+type SuccessResponseList struct {
+	Status  int64      `json:"status"`
+	Message string     `json:"message"`
+	Data    []*Webhook `json:"data"`
+}
+
+// SuccessResponseObject struct
+type SuccessResponseObject struct {
+	Status  int64             `json:"status"`
+	Message string            `json:"message"`
+	Data    *Webhook          `json:"data"`
+	Errors  validation.Errors `json:"errors,omitempty"`
+}
+
+// SuccessResponseDeliveryList struct
+type SuccessResponseDeliveryList struct {
+	Status     int64       `json:"status"`
+	Message    string      `json:"message"`
+	Data       []*Delivery `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// SuccessResponse struct
+type SuccessResponse struct {
+	Status  int64             `json:"status"`
+	Message string            `json:"message"`
+	Errors  validation.Errors `json:"errors,omitempty"`
+}
+
+//ScanWebhook func
+func ScanWebhook(rows *sql.Rows) (*Webhook, error) {
+	var webhook Webhook
+	var disabledAt pq.NullTime
+	err := rows.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Active,
+		&webhook.MaxConcurrency, &webhook.ConsecutiveFailures, &disabledAt, &webhook.UpdatedAt, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if disabledAt.Valid {
+		webhook.DisabledAt = &disabledAt.Time
+	}
+	return &webhook, nil
+}
+
+//ScanDelivery func
+func ScanDelivery(rows *sql.Rows) (*Delivery, error) {
+	var delivery Delivery
+	var responseCode, durationMS sql.NullInt64
+	var responseBody, deliveryErr sql.NullString
+	var deliveredAt pq.NullTime
+	err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Status,
+		&delivery.Attempts, &responseCode, &responseBody, &deliveryErr, &durationMS, &delivery.NextAttemptAt,
+		&deliveredAt, &delivery.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.ResponseCode = int(responseCode.Int64)
+	delivery.ResponseBody = responseBody.String
+	delivery.Error = deliveryErr.String
+	delivery.DurationMS = durationMS.Int64
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
+
+// Validate webhook
+func (w *Webhook) Validate() error {
+	return validate.Struct(w)
+}
+
+// Normalize trim the URL and remove duplicated events
+func (w *Webhook) Normalize() {
+	w.URL = strings.TrimSpace(w.URL)
+
+	seen := make(map[string]bool, len(w.Events))
+	events := make([]string, 0, len(w.Events))
+	for _, event := range w.Events {
+		event = strings.TrimSpace(event)
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	w.Events = events
+}
+
+// Normalize default and clamp the pagination
+func (f *DeliveryFilter) Normalize() {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.PerPage == 0 {
+		f.PerPage = DefaultPerPage
+	}
+	if f.PerPage > MaxPerPage {
+		f.PerPage = MaxPerPage
+	}
+}
+
+// Offset of the first delivery of the page
+func (f *DeliveryFilter) Offset() uint64 {
+	return (f.Page - 1) * f.PerPage
+}
@@ -0,0 +1,68 @@
+package repository
+
+// Table Name
+const (
+	webhookTable  = "webhooks"
+	deliveryTable = "webhook_deliveries"
+)
+
+// Table Column Names
+const (
+	idColumn        = "id"
+	tenantIDColumn  = "tenant_id"
+	updatedAtColumn = "updated_at"
+	createdAtColumn = "created_at"
+
+	// Webhook Table Column Names
+	webhookURLColumn                 = "url"
+	webhookEventsColumn              = "events"
+	webhookSecretColumn              = "secret"
+	webhookActiveColumn              = "active"
+	webhookMaxConcurrencyColumn      = "max_concurrency"
+	webhookConsecutiveFailuresColumn = "consecutive_failures"
+	webhookDisabledAtColumn          = "disabled_at"
+
+	// Delivery Table Column Names
+	deliveryWebhookIDColumn     = "webhook_id"
+	deliveryEventIDColumn       = "event_id"
+	deliveryEventTypeColumn     = "event_type"
+	deliveryStatusColumn        = "status"
+	deliveryAttemptsColumn      = "attempts"
+	deliveryResponseCodeColumn  = "response_code"
+	deliveryResponseBodyColumn  = "response_body"
+	deliveryErrorColumn         = "error"
+	deliveryDurationMSColumn    = "duration_ms"
+	deliveryNextAttemptAtColumn = "next_attempt_at"
+	deliveredAtColumn           = "delivered_at"
+)
+
+// Table Columns
+var (
+	WebhookColumns = []string{
+		idColumn,
+		webhookURLColumn,
+		webhookEventsColumn,
+		webhookActiveColumn,
+		webhookMaxConcurrencyColumn,
+		webhookConsecutiveFailuresColumn,
+		webhookDisabledAtColumn,
+		updatedAtColumn,
+		createdAtColumn,
+	}
+
+	DeliveryColumns = []string{
+		idColumn,
+		deliveryWebhookIDColumn,
+		deliveryEventIDColumn,
+		deliveryEventTypeColumn,
+		deliveryStatusColumn,
+		deliveryAttemptsColumn,
+		deliveryResponseCodeColumn,
+		deliveryResponseBodyColumn,
+		deliveryErrorColumn,
+		deliveryDurationMSColumn,
+		deliveryNextAttemptAtColumn,
+		deliveredAtColumn,
+		createdAtColumn,
+	}
+)
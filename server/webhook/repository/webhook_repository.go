@@ -0,0 +1,422 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/server/webhook/models"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/outbox"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/lib/pq"
+)
+
+// WebhookRepository to get webhook data from database
+type WebhookRepository interface {
+	List(ctx context.Context) ([]*models.Webhook, error)
+	Find(ctx context.Context, id int64) (*models.Webhook, error)
+	Insert(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, webhookID int64, filter *models.DeliveryFilter) ([]*models.Delivery, int64, error)
+	Fanout(ctx context.Context, event *outbox.Event) error
+	Claim(ctx context.Context, limit int, lease time.Duration, busy []int64) ([]*models.Job, error)
+	SaveAttempt(ctx context.Context, job *models.Job, attempt *models.Attempt, status string, retryIn time.Duration) error
+	SaveEndpointResult(ctx context.Context, webhookID int64, succeeded bool, disableAfter int) (bool, error)
+}
+
+// ErrWebhookNotFound is returned when a webhook does not exist
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// fanoutQuery create a delivery of the event for every active subscribed webhook of its tenant
+const fanoutQuery = `INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+SELECT id, $1, $2, $3
+  FROM webhooks
+ WHERE active
+   AND tenant_id = $4
+   AND ($2 = ANY (events) OR '*' = ANY (events))
+    ON CONFLICT ON CONSTRAINT webhook_deliveries_event_key DO NOTHING`
+
+// claimQuery lease the due deliveries of active webhooks, at most max_concurrency
+// per webhook and none of the busy webhooks in $3. A delivery whose worker died
+// becomes due again when the lease expires
+const claimQuery = `UPDATE webhook_deliveries d
+   SET next_attempt_at = now() + $2::float8 * interval '1 millisecond'
+  FROM webhooks w
+ WHERE w.id = d.webhook_id
+   AND d.id IN (SELECT p.id
+                  FROM webhook_deliveries p
+                 WHERE p.status = 'pending'
+                   AND p.next_attempt_at <= now()
+                   AND p.id IN (SELECT c.id
+                                  FROM (SELECT q.id, qw.max_concurrency,
+                                               row_number() OVER (PARTITION BY q.webhook_id
+                                                                  ORDER BY q.next_attempt_at, q.id) AS rank
+                                          FROM webhook_deliveries q
+                                          JOIN webhooks qw ON qw.id = q.webhook_id
+                                         WHERE q.status = 'pending'
+                                           AND q.next_attempt_at <= now()
+                                           AND qw.active
+                                           AND q.webhook_id <> ALL ($3)) c
+                                 WHERE c.rank <= c.max_concurrency)
+                 ORDER BY p.next_attempt_at, p.id
+                 LIMIT $1
+                   FOR UPDATE SKIP LOCKED)
+RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.attempts, w.url, w.secret, w.max_concurrency`
+
+// endpointResultQuery count the consecutive failures and disable the webhook at the threshold
+const endpointResultQuery = `UPDATE webhooks
+   SET consecutive_failures = CASE WHEN $2 THEN 0 ELSE consecutive_failures + 1 END,
+       active = active AND ($2 OR consecutive_failures + 1 < $3),
+       disabled_at = CASE WHEN active AND NOT $2 AND consecutive_failures + 1 >= $3 THEN now() ELSE disabled_at END
+ WHERE id = $1
+RETURNING active`
+
+// deliveryTenantScope keep the deliveries of the webhooks of the tenant
+const deliveryTenantScope = "EXISTS (SELECT 1 FROM webhooks WHERE webhooks.id = webhook_deliveries.webhook_id " +
+	"AND webhooks.tenant_id = ?)"
+
+//InitWebhookRepository struct
+type InitWebhookRepository struct {
+	connection *sql.DB
+}
+
+// NewWebhookRepository return new instance of WebhookRepository
+func NewWebhookRepository(connection *sql.DB) WebhookRepository {
+	return &InitWebhookRepository{
+		connection: connection,
+	}
+}
+
+//List func
+func (init *InitWebhookRepository) List(ctx context.Context) (list []*models.Webhook, err error) {
+	list = make([]*models.Webhook, 0)
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return list, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(WebhookColumns...).
+		From(webhookTable).
+		Where(sq.Eq{tenantIDColumn: tenantID}).
+		OrderBy("id ASC")
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var webhook *models.Webhook
+		webhook, err = models.ScanWebhook(rows)
+		if err != nil {
+			return
+		}
+		list = append(list, webhook)
+	}
+
+	return list, rows.Err()
+}
+
+//Find func
+func (init *InitWebhookRepository) Find(ctx context.Context, id int64) (webhook *models.Webhook, err error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return webhook, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select(WebhookColumns...).
+		From(webhookTable).
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return webhook, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		webhook, err = models.ScanWebhook(rows)
+	}
+
+	return webhook, err
+}
+
+//Insert func
+func (init *InitWebhookRepository) Insert(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return webhook, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return webhook, err
+	}
+
+	query := sq.Insert(webhookTable).
+		Columns(webhookURLColumn, webhookEventsColumn, webhookSecretColumn, webhookActiveColumn,
+			webhookMaxConcurrencyColumn, tenantIDColumn).
+		Values(webhook.URL, pq.Array(webhook.Events), webhook.Secret, webhook.Active, webhook.MaxConcurrency,
+			tenantID).
+		Suffix("RETURNING \"id\", \"created_at\"").
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	err = query.QueryRow().Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		trxn.SetError(err)
+		return webhook, err
+	}
+
+	return webhook, err
+}
+
+//Update func
+func (init *InitWebhookRepository) Update(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return webhook, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return webhook, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Update(webhookTable).
+		Set(webhookURLColumn, webhook.URL).
+		Set(webhookEventsColumn, pq.Array(webhook.Events)).
+		Set(webhookActiveColumn, webhook.Active).
+		Set(webhookMaxConcurrencyColumn, webhook.MaxConcurrency).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{idColumn: webhook.ID, tenantIDColumn: tenantID})
+
+	// enabling a webhook give it a fresh failure budget
+	if webhook.Active {
+		builder = builder.
+			Set(webhookConsecutiveFailuresColumn, 0).
+			Set(webhookDisabledAtColumn, nil)
+	}
+	if webhook.Secret != "" {
+		builder = builder.Set(webhookSecretColumn, webhook.Secret)
+	}
+
+	result, err := builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return webhook, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return webhook, ErrWebhookNotFound
+	}
+
+	return webhook, err
+}
+
+//Delete func
+func (init *InitWebhookRepository) Delete(ctx context.Context, id int64) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Delete(webhookTable).
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
+
+	result, err := builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return err
+}
+
+//ListDeliveries func
+func (init *InitWebhookRepository) ListDeliveries(ctx context.Context, webhookID int64, filter *models.DeliveryFilter) (list []*models.Delivery, total int64, err error) {
+	list = make([]*models.Delivery, 0)
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return list, total, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	where := sq.And{
+		sq.Eq{deliveryWebhookIDColumn: webhookID},
+		sq.Expr(deliveryTenantScope, tenantID),
+	}
+	if filter.Status != "" {
+		where = append(where, sq.Eq{deliveryStatusColumn: filter.Status})
+	}
+
+	err = psql.Select("count(*)").From(deliveryTable).Where(where).
+		RunWith(init.connection).
+		QueryRowContext(ctx).
+		Scan(&total)
+	if err != nil || total == 0 {
+		return list, total, err
+	}
+
+	builder := psql.Select(DeliveryColumns...).
+		From(deliveryTable).
+		Where(where).
+		OrderBy(idColumn + " DESC").
+		Limit(filter.PerPage).
+		Offset(filter.Offset())
+
+	rows, err := builder.RunWith(init.connection).QueryContext(ctx)
+	if err != nil {
+		return list, total, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery *models.Delivery
+		delivery, err = models.ScanDelivery(rows)
+		if err != nil {
+			return
+		}
+		list = append(list, delivery)
+	}
+
+	return list, total, rows.Err()
+}
+
+//Fanout func
+func (init *InitWebhookRepository) Fanout(ctx context.Context, event *outbox.Event) error {
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	_, err = trxn.DB.Exec(fanoutQuery, event.ID, event.Type, string(payload), event.TenantID)
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	return nil
+}
+
+//Claim func
+func (init *InitWebhookRepository) Claim(ctx context.Context, limit int, lease time.Duration, busy []int64) ([]*models.Job, error) {
+	jobs := make([]*models.Job, 0, limit)
+
+	rows, err := init.connection.QueryContext(ctx, claimQuery, limit, int64(lease/time.Millisecond), pq.Array(busy))
+	if err != nil {
+		return jobs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job models.Job
+		var payload []byte
+		err = rows.Scan(&job.ID, &job.WebhookID, &job.EventID, &job.EventType, &payload, &job.Attempts,
+			&job.URL, &job.Secret, &job.MaxConcurrency)
+		if err != nil {
+			return jobs, err
+		}
+		job.Payload = payload
+		jobs = append(jobs, &job)
+	}
+
+	return jobs, rows.Err()
+}
+
+//SaveAttempt func
+func (init *InitWebhookRepository) SaveAttempt(ctx context.Context, job *models.Job, attempt *models.Attempt, status string, retryIn time.Duration) error {
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Update(deliveryTable).
+		Set(deliveryStatusColumn, status).
+		Set(deliveryAttemptsColumn, sq.Expr(deliveryAttemptsColumn+" + 1")).
+		Set(deliveryResponseCodeColumn, nullInt(int64(attempt.ResponseCode))).
+		Set(deliveryResponseBodyColumn, nullString(attempt.ResponseBody)).
+		Set(deliveryErrorColumn, nullString(attempt.Error)).
+		Set(deliveryDurationMSColumn, int64(attempt.Duration/time.Millisecond)).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{idColumn: job.ID})
+
+	switch status {
+	case models.DeliverySucceeded:
+		builder = builder.Set(deliveredAtColumn, time.Now())
+	case models.DeliveryPending:
+		builder = builder.Set(deliveryNextAttemptAtColumn, time.Now().Add(retryIn))
+	}
+
+	_, err = builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	return nil
+}
+
+//SaveEndpointResult func return whether the webhook is still active
+func (init *InitWebhookRepository) SaveEndpointResult(ctx context.Context, webhookID int64, succeeded bool, disableAfter int) (bool, error) {
+	trxn, err := dbtrxn.Use(ctx, init.connection)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := trxn.DB.Query(endpointResultQuery, webhookID, succeeded, disableAfter)
+	if err != nil {
+		trxn.SetError(err)
+		return false, err
+	}
+	defer rows.Close()
+
+	// no row when the webhook was deleted during the delivery
+	var active bool
+	if rows.Next() {
+		err = rows.Scan(&active)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		trxn.SetError(err)
+	}
+
+	return active, err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt(i int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: i, Valid: i != 0}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/go-rest-api-boilerplate/server/webhook/models"
+	"github.com/go-rest-api-boilerplate/server/webhook/repository"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+)
+
+// defaultMaxConcurrency is the concurrent deliveries of a webhook without limit
+const defaultMaxConcurrency = 4
+
+// ErrWebhookNotFound is returned when a webhook does not exist
+var ErrWebhookNotFound = repository.ErrWebhookNotFound
+
+//WebhookService interface
+type WebhookService interface {
+	ListWebhook(ctx context.Context) ([]*models.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*models.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListDelivery(ctx context.Context, webhookID int64, filter *models.DeliveryFilter) ([]*models.Delivery, *models.Pagination, error)
+}
+
+//InitWebhookService struct
+type InitWebhookService struct {
+	Repository *InitWebhookRepositoryInterface
+}
+
+//InitWebhookRepositoryInterface struct
+type InitWebhookRepositoryInterface struct {
+	Webhook repository.WebhookRepository
+}
+
+// NewWebhookService return new instance of WebhookService
+func NewWebhookService(webhookRepository repository.WebhookRepository) WebhookService {
+	return &InitWebhookService{
+		Repository: &InitWebhookRepositoryInterface{
+			Webhook: webhookRepository,
+		},
+	}
+}
+
+//ListWebhook func
+func (init *InitWebhookService) ListWebhook(ctx context.Context) ([]*models.Webhook, error) {
+	return init.Repository.Webhook.List(ctx)
+}
+
+//GetWebhook func
+func (init *InitWebhookService) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	return init.Repository.Webhook.Find(ctx, id)
+}
+
+//CreateWebhook func return the webhook with its secret, a missing secret is generated
+func (init *InitWebhookService) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	if webhook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return webhook, err
+		}
+		webhook.Secret = secret
+	}
+	if webhook.MaxConcurrency == 0 {
+		webhook.MaxConcurrency = defaultMaxConcurrency
+	}
+	webhook.Active = true
+
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	webhook, err := init.Repository.Webhook.Insert(ctx, webhook)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return webhook, err
+}
+
+//UpdateWebhook func
+func (init *InitWebhookService) UpdateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	if webhook.MaxConcurrency == 0 {
+		webhook.MaxConcurrency = defaultMaxConcurrency
+	}
+
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	webhook, err := init.Repository.Webhook.Update(ctx, webhook)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return webhook, err
+}
+
+//DeleteWebhook func
+func (init *InitWebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	err := init.Repository.Webhook.Delete(ctx, id)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return err
+}
+
+//ListDelivery func
+func (init *InitWebhookService) ListDelivery(ctx context.Context, webhookID int64, filter *models.DeliveryFilter) ([]*models.Delivery, *models.Pagination, error) {
+	filter.Normalize()
+
+	webhook, err := init.Repository.Webhook.Find(ctx, webhookID)
+	if err == nil && webhook == nil {
+		err = ErrWebhookNotFound
+	}
+	if err != nil {
+		return make([]*models.Delivery, 0), nil, err
+	}
+
+	deliveries, total, err := init.Repository.Webhook.ListDeliveries(ctx, webhookID, filter)
+
+	pagination := &models.Pagination{
+		Page:    filter.Page,
+		PerPage: filter.PerPage,
+		Total:   total,
+	}
+
+	return deliveries, pagination, err
+}
+
+// newSecret return 32 random bytes hex encoded
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
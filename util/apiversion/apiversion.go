@@ -0,0 +1,188 @@
+package apiversion
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Version headers
+const (
+	// HeaderVersion select the version of an unversioned path, the served version is returned in it
+	HeaderVersion     = "API-Version"
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+)
+
+// DefaultVersion serve the unversioned paths when API_DEFAULT_VERSION is not set
+const DefaultVersion = "v1"
+
+// versionSegment match the first path segment of a versioned path
+var versionSegment = regexp.MustCompile(`^/(v[0-9]+)(?:/|$)`)
+
+type (
+	// Routes register the routes of a resource in the group of a version
+	Routes func(g *echo.Group)
+
+	// Version is a group of routes served under /<Name>
+	Version struct {
+		Name string
+		// Deprecation is the date the version was deprecated, zero while it is supported
+		Deprecation time.Time
+		// Sunset is the date the version stops answering, zero when not planned
+		Sunset time.Time
+		// Link documents the migration away from the version
+		Link   string
+		routes []Routes
+	}
+
+	// Registry route the requests to their version
+	Registry struct {
+		versions       map[string]*Version
+		order          []*Version
+		defaultVersion string
+	}
+
+	// errorResponse is the body of a rejected request
+	errorResponse struct {
+		Status  int64  `json:"status"`
+		Message string `json:"message"`
+	}
+)
+
+// NewVersion return a version serving routes
+func NewVersion(name string, routes ...Routes) *Version {
+	return &Version{
+		Name:   name,
+		routes: routes,
+	}
+}
+
+// FromEnv set the retirement of the version from API_<NAME>_DEPRECATION, API_<NAME>_SUNSET as YYYY-MM-DD
+// or RFC 3339 dates, and API_<NAME>_LINK
+func (v *Version) FromEnv() *Version {
+	prefix := "API_" + strings.ToUpper(v.Name) + "_"
+	v.Deprecation = parseDate(os.Getenv(prefix + "DEPRECATION"))
+	v.Sunset = parseDate(os.Getenv(prefix + "SUNSET"))
+	v.Link = os.Getenv(prefix + "LINK")
+	return v
+}
+
+func parseDate(value string) time.Time {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// NewRegistry return a Registry of versions, the unversioned paths are served by defaultVersion
+func NewRegistry(defaultVersion string, versions ...*Version) *Registry {
+	r := &Registry{
+		versions:       make(map[string]*Version, len(versions)),
+		defaultVersion: defaultVersion,
+	}
+	for _, v := range versions {
+		r.versions[v.Name] = v
+		r.order = append(r.order, v)
+	}
+	return r
+}
+
+// DefaultFromEnv return API_DEFAULT_VERSION or DefaultVersion
+func DefaultFromEnv() string {
+	if v := os.Getenv("API_DEFAULT_VERSION"); v != "" {
+		return v
+	}
+	return DefaultVersion
+}
+
+// Register the routes of every version in its group of s. The paths without version are an alias of the
+// version named by the API-Version header or of the default version, but the skipped prefixes
+func (r *Registry) Register(s *echo.Echo, skip ...string) {
+	s.Pre(r.alias(skip))
+
+	for _, v := range r.order {
+		g := s.Group("/"+v.Name, v.middleware())
+		for _, routes := range v.routes {
+			routes(g)
+		}
+	}
+}
+
+// alias rewrite the unversioned paths to their versioned path before routing
+func (r *Registry) alias(skip []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			path := req.URL.Path
+			if versionSegment.MatchString(path) {
+				return next(c)
+			}
+			for _, prefix := range skip {
+				if strings.HasPrefix(path, prefix) {
+					return next(c)
+				}
+			}
+
+			name := req.Header.Get(HeaderVersion)
+			if name == "" {
+				name = r.defaultVersion
+			}
+			if _, ok := r.versions[name]; !ok {
+				return c.JSON(http.StatusBadRequest, &errorResponse{
+					Status:  http.StatusBadRequest,
+					Message: "unknown API version " + strconv.Quote(name) + ", supported versions: " + r.names(),
+				})
+			}
+
+			req.URL.Path = "/" + name + path
+			if req.URL.RawPath != "" {
+				req.URL.RawPath = "/" + name + req.URL.RawPath
+			}
+			return next(c)
+		}
+	}
+}
+
+func (r *Registry) names() string {
+	names := make([]string, 0, len(r.order))
+	for _, v := range r.order {
+		names = append(names, v.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// middleware announce the served version and its retirement, a version past its sunset answers 410
+func (v *Version) middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			header.Set(HeaderVersion, v.Name)
+			if !v.Deprecation.IsZero() {
+				// RFC 9745 structured date
+				header.Set(HeaderDeprecation, "@"+strconv.FormatInt(v.Deprecation.Unix(), 10))
+			}
+			if !v.Sunset.IsZero() {
+				header.Set(HeaderSunset, v.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if v.Link != "" && (!v.Deprecation.IsZero() || !v.Sunset.IsZero()) {
+				header.Add("Link", "<"+v.Link+">; rel=\"deprecation\"")
+			}
+
+			if !v.Sunset.IsZero() && time.Now().After(v.Sunset) {
+				return c.JSON(http.StatusGone, &errorResponse{
+					Status:  http.StatusGone,
+					Message: "API " + v.Name + " was retired on " + v.Sunset.UTC().Format(http.TimeFormat),
+				})
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newServer register versions, /health is served outside of them
+func newServer(versions ...*Version) *echo.Echo {
+	s := echo.New()
+	NewRegistry("v1", versions...).Register(s, "/health")
+	s.GET("/health", func(c echo.Context) error {
+		return c.String(http.StatusOK, "health")
+	})
+	return s
+}
+
+// books answer the name of the version and the id of the book
+func books(name string) Routes {
+	return func(g *echo.Group) {
+		g.GET("/books/:id", func(c echo.Context) error {
+			return c.String(http.StatusOK, name+" "+c.Param("id"))
+		})
+	}
+}
+
+func serve(s *echo.Echo, path, version string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if version != "" {
+		req.Header.Set(HeaderVersion, version)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRegister(t *testing.T) {
+	s := newServer(NewVersion("v1", books("v1")), NewVersion("v2", books("v2")))
+
+	tests := []struct {
+		name    string
+		path    string
+		header  string
+		status  int
+		body    string
+		version string
+	}{
+		{"versioned", "/v2/books/1", "", http.StatusOK, "v2 1", "v2"},
+		{"versioned over header", "/v1/books/1", "v2", http.StatusOK, "v1 1", "v1"},
+		{"default alias", "/books/1", "", http.StatusOK, "v1 1", "v1"},
+		{"header override", "/books/1", "v2", http.StatusOK, "v2 1", "v2"},
+		{"unknown header version", "/books/1", "v9", http.StatusBadRequest, "supported versions: v1, v2", ""},
+		{"unknown path version", "/v9/books/1", "", http.StatusNotFound, "", ""},
+		{"skipped prefix", "/health", "v9", http.StatusOK, "health", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(s, test.path, test.header)
+			if rec.Code != test.status {
+				t.Fatalf("got status %d, want %d", rec.Code, test.status)
+			}
+			if !strings.Contains(rec.Body.String(), test.body) {
+				t.Errorf("got body %q, want %q", rec.Body.String(), test.body)
+			}
+			if got := rec.Header().Get(HeaderVersion); got != test.version {
+				t.Errorf("got %s %q, want %q", HeaderVersion, got, test.version)
+			}
+		})
+	}
+}
+
+func TestDeprecation(t *testing.T) {
+	deprecation := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	v1 := NewVersion("v1", books("v1"))
+	v1.Deprecation, v1.Sunset, v1.Link = deprecation, sunset, "https://example.com/migrate"
+	s := newServer(v1, NewVersion("v2", books("v2")))
+
+	rec := serve(s, "/books/1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want the deprecated version served until its sunset", rec.Code)
+	}
+	headers := map[string]string{
+		HeaderDeprecation: "@1704067200",
+		HeaderSunset:      sunset.Format(http.TimeFormat),
+		"Link":            `<https://example.com/migrate>; rel="deprecation"`,
+	}
+	for name, want := range headers {
+		if got := rec.Header().Get(name); got != want {
+			t.Errorf("got %s %q, want %q", name, got, want)
+		}
+	}
+
+	// the supported version announce no retirement
+	rec = serve(s, "/books/1", "v2")
+	for name := range headers {
+		if got := rec.Header().Get(name); got != "" {
+			t.Errorf("got %s %q on a supported version", name, got)
+		}
+	}
+
+	// a version past its sunset answers 410
+	v1.Sunset = time.Now().Add(-time.Hour)
+	if rec = serve(s, "/v1/books/1", ""); rec.Code != http.StatusGone {
+		t.Errorf("got status %d after the sunset, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{
+		"API_V1_DEPRECATION": "2024-01-01",
+		"API_V1_SUNSET":      "2025-06-30T12:00:00Z",
+		"API_V1_LINK":        "https://example.com/migrate",
+		"API_V2_DEPRECATION": "next year",
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	v1 := NewVersion("v1").FromEnv()
+	if !v1.Deprecation.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got deprecation %v", v1.Deprecation)
+	}
+	if !v1.Sunset.Equal(time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("got sunset %v", v1.Sunset)
+	}
+	if v1.Link != env["API_V1_LINK"] {
+		t.Errorf("got link %q", v1.Link)
+	}
+
+	// an invalid date leave the version supported
+	if v2 := NewVersion("v2").FromEnv(); !v2.Deprecation.IsZero() || !v2.Sunset.IsZero() {
+		t.Errorf("got deprecation %v and sunset %v, want zero", v2.Deprecation, v2.Sunset)
+	}
+}
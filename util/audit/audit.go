@@ -0,0 +1,182 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+)
+
+// Actions of an audit entry
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionImport = "import"
+	ActionSeed   = "seed"
+)
+
+// SystemActor is the actor of mutations made outside of an HTTP request
+const SystemActor = "system"
+
+// Table and columns of the audit log
+const (
+	Table          = "audit_log"
+	IDColumn       = "id"
+	TenantIDColumn = "tenant_id"
+	ActorColumn    = "actor"
+	// ClaimedActorColumn is the unverified actor the client named, never trusted as the actor
+	ClaimedActorColumn = "claimed_actor"
+	RequestIDColumn    = "request_id"
+	ActionColumn       = "action"
+	EntityColumn       = "entity"
+	EntityIDColumn     = "entity_id"
+	DiffColumn         = "diff"
+	CreatedAtColumn    = "created_at"
+)
+
+// Columns of an audit entry in scan order
+var Columns = []string{
+	IDColumn,
+	ActorColumn,
+	ClaimedActorColumn,
+	RequestIDColumn,
+	ActionColumn,
+	EntityColumn,
+	EntityIDColumn,
+	DiffColumn,
+	CreatedAtColumn,
+}
+
+type (
+	// Change of a field, Before is nil on create and After is nil on delete
+	Change struct {
+		Before interface{} `json:"before"`
+		After  interface{} `json:"after"`
+	}
+
+	// Writer record a mutation of an entity
+	Writer interface {
+		Record(ctx context.Context, action, entity, entityID string, before, after interface{}) error
+	}
+
+	// DBWriter write audit entries in the dbtrxn transaction of the mutation
+	DBWriter struct {
+		connection *sql.DB
+	}
+)
+
+// NewWriter return a Writer storing entries in the audit_log table
+func NewWriter(connection *sql.DB) Writer {
+	return &DBWriter{
+		connection: connection,
+	}
+}
+
+// Record insert an entry with the tenant, authenticated actor, claimed actor and request ID of ctx, the
+// entry is rolled back with the mutation when the transaction fails
+func (w *DBWriter) Record(ctx context.Context, action, entity, entityID string, before, after interface{}) error {
+	trxn, err := dbtrxn.Use(ctx, w.connection)
+	if err != nil {
+		return err
+	}
+
+	diff, err := Diff(before, after)
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	// an update that changes nothing is not worth an entry
+	if action == ActionUpdate && len(diff) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(diff)
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	actor := util.SessionActor(ctx)
+	if actor == "" {
+		actor = SystemActor
+	}
+
+	query := sq.Insert(Table).
+		Columns(TenantIDColumn, ActorColumn, ClaimedActorColumn, RequestIDColumn, ActionColumn, EntityColumn,
+			EntityIDColumn, DiffColumn).
+		Values(nullString(tenant.FromContext(ctx)), actor, util.SessionClaimedActor(ctx), util.SessionCid(ctx),
+			action, entity, entityID, string(payload)).
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	_, err = query.Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Diff compare the JSON representation of before and after and return the
+// changed fields, a nil value is an entity without fields
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]Change)
+	for name, value := range beforeFields {
+		if other, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, other) {
+			diff[name] = Change{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			diff[name] = Change{After: value}
+		}
+	}
+
+	return diff, nil
+}
+
+func fields(entity interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if entity == nil || reflect.ValueOf(entity).Kind() == reflect.Ptr && reflect.ValueOf(entity).IsNil() {
+		return result, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	// omitted and null fields are the same absent value
+	for name, value := range result {
+		if value == nil {
+			delete(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+// nullString store the system entries without tenant
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/util/tenant"
+)
+
+var (
+	// ErrUnauthenticated is returned when a request names no principal and authentication is required
+	ErrUnauthenticated = errors.New("auth: unauthenticated")
+	// ErrInvalidToken is returned when a bearer token is malformed, expired or not signed with the secret
+	ErrInvalidToken = errors.New("auth: invalid token")
+)
+
+type (
+	// Config of the authentication of the API
+	Config struct {
+		// TokenSecret verify the HS256 bearer tokens, the tokens are not accepted when empty
+		TokenSecret []byte
+		// SubjectClaim of the tokens naming the principal
+		SubjectClaim string
+		// Required reject the requests without principal, they run as the anonymous actor otherwise
+		Required bool
+	}
+
+	// Authenticator find the principal of a request
+	Authenticator struct {
+		config Config
+	}
+)
+
+// DefaultConfig require a principal named by the "sub" claim of the tokens
+var DefaultConfig = Config{
+	SubjectClaim: "sub",
+	Required:     true,
+}
+
+// ConfigFromEnv override DefaultConfig with AUTH_TOKEN_SECRET, TENANT_TOKEN_SECRET when it is empty,
+// AUTH_SUBJECT_CLAIM and AUTH_REQUIRED
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	secret := os.Getenv("AUTH_TOKEN_SECRET")
+	if secret == "" {
+		// a single token carry the tenant and the subject
+		secret = os.Getenv("TENANT_TOKEN_SECRET")
+	}
+	config.TokenSecret = []byte(secret)
+	if v := os.Getenv("AUTH_SUBJECT_CLAIM"); v != "" {
+		config.SubjectClaim = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("AUTH_REQUIRED")); err == nil {
+		config.Required = v
+	}
+	return config
+}
+
+// NewAuthenticator return an Authenticator of config
+func NewAuthenticator(config Config) *Authenticator {
+	return &Authenticator{
+		config: config,
+	}
+}
+
+// Principal return the subject of the verified client certificate of state, or else of the bearer token of the
+// authorization header. It is empty for an anonymous request when authentication is not required
+func (a *Authenticator) Principal(state *tls.ConnectionState, authorization string) (string, error) {
+	if principal := tlsPrincipal(state); principal != "" {
+		return principal, nil
+	}
+
+	if len(a.config.TokenSecret) > 0 && strings.HasPrefix(authorization, "Bearer ") {
+		subject, err := tenant.ParseToken(a.config.TokenSecret, a.config.SubjectClaim,
+			strings.TrimPrefix(authorization, "Bearer "))
+		if err != nil {
+			return "", ErrInvalidToken
+		}
+		if subject != "" {
+			return subject, nil
+		}
+	}
+
+	if a.config.Required {
+		return "", ErrUnauthenticated
+	}
+	return "", nil
+}
+
+// tlsPrincipal return the subject of the verified client certificate of a connection, empty without one
+func tlsPrincipal(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.String()
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var secret = []byte("test-secret")
+
+func token(t *testing.T, key []byte, claims map[string]string, ttl time.Duration) string {
+	signed, err := tenant.SignClaims(key, claims, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func TestPrincipal(t *testing.T) {
+	certificate := &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "billing"}}}},
+	}
+	unverified := &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "billing"}}},
+	}
+	// the expiry is rounded down to the second
+	expired, err := tenant.SignClaims(secret, map[string]string{"sub": "alice"}, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		required      bool
+		state         *tls.ConnectionState
+		authorization string
+		want          string
+		err           error
+	}{
+		{"certificate", true, certificate, "", "CN=billing", nil},
+		{"certificate before token", true, certificate, token(t, secret, map[string]string{"sub": "alice"}, 0),
+			"CN=billing", nil},
+		{"unverified certificate", true, unverified, "", "", ErrUnauthenticated},
+		{"token", true, nil, token(t, secret, map[string]string{"sub": "alice"}, time.Hour), "alice", nil},
+		{"other secret", false, nil, token(t, []byte("other"), map[string]string{"sub": "alice"}, 0), "",
+			ErrInvalidToken},
+		{"expired token", false, nil, "Bearer " + expired, "", ErrInvalidToken},
+		{"token without subject", true, nil, token(t, secret, map[string]string{"tenant_id": "acme"}, 0), "",
+			ErrUnauthenticated},
+		{"anonymous", true, nil, "", "", ErrUnauthenticated},
+		{"anonymous allowed", false, nil, "", "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.TokenSecret = secret
+			config.Required = test.required
+
+			principal, err := NewAuthenticator(config).Principal(test.state, test.authorization)
+			if err != test.err {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if principal != test.want {
+				t.Errorf("got principal %q, want %q", principal, test.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	util.Log = logrus.New()
+
+	config := DefaultConfig
+	config.TokenSecret = secret
+	e := echo.New()
+	e.Use(util.SessionMiddleware())
+	e.Use(Middleware(NewAuthenticator(config), "/health"))
+	handler := func(c echo.Context) error {
+		ctx := c.Request().Context()
+		return c.String(http.StatusOK, util.SessionActor(ctx)+"|"+util.SessionClaimedActor(ctx))
+	}
+	e.GET("/books", handler)
+	e.GET("/health", handler)
+
+	serve := func(path, authorization, actor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set(echo.HeaderAuthorization, authorization)
+		}
+		req.Header.Set(util.HeaderActor, actor)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/books", token(t, secret, map[string]string{"sub": "alice"}, 0), "root")
+	if rec.Code != http.StatusOK || rec.Body.String() != "alice|root" {
+		t.Errorf("got %d %q, want alice as actor and root as claimed actor", rec.Code, rec.Body.String())
+	}
+
+	rec = serve("/books", "", "root")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get(echo.HeaderWWWAuthenticate) != "Bearer" {
+		t.Errorf("got %d, want an unauthenticated claimed actor rejected", rec.Code)
+	}
+
+	rec = serve("/health", "", "root")
+	if rec.Code != http.StatusOK || rec.Body.String() != util.AnonymousActor+"|root" {
+		t.Errorf("got %d %q, want the skipped path served anonymously", rec.Code, rec.Body.String())
+	}
+}
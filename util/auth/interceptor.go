@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//UnaryInterceptor func make the principal of the call the actor of its session and reject the unauthenticated
+//calls like Middleware, the methods having one of the skipped prefixes are not authenticated
+func UnaryInterceptor(authenticator *Authenticator, skip ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !skipped(info.FullMethod, skip) {
+			if err := authenticator.authenticate(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+//StreamInterceptor func authenticate the streams like UnaryInterceptor
+func StreamInterceptor(authenticator *Authenticator, skip ...string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !skipped(info.FullMethod, skip) {
+			if err := authenticator.authenticate(ss.Context()); err != nil {
+				return err
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// authenticate the session of ctx with the peer certificate or the authorization metadata
+func (a *Authenticator) authenticate(ctx context.Context) error {
+	var state *tls.ConnectionState
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+	}
+
+	var authorization string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+
+	principal, err := a.Principal(state, authorization)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if principal != "" {
+		util.SessionAuthenticate(ctx, principal)
+	}
+	return nil
+}
+
+func skipped(method string, skip []string) bool {
+	for _, prefix := range skip {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	util.Log = logrus.New()
+
+	config := DefaultConfig
+	config.TokenSecret = secret
+	interceptor := UnaryInterceptor(NewAuthenticator(config), "/grpc.health.")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return util.SessionActor(ctx), nil
+	}
+	call := func(ctx context.Context, method string) (interface{}, error) {
+		session := util.NewSession("", util.AnonymousActor)
+		session.ClaimedActor = "root"
+		ctx = context.WithValue(ctx, util.SessionKey, session)
+		return interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	}
+	method := "/book.BookService/GetBook"
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("authorization", token(t, secret, map[string]string{"sub": "alice"}, 0), "x-actor", "root"))
+	if actor, err := call(ctx, method); err != nil || actor != "alice" {
+		t.Errorf("got %v %v, want alice", actor, err)
+	}
+
+	ctx = peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "billing"}}}},
+	}}})
+	if actor, err := call(ctx, method); err != nil || actor != "CN=billing" {
+		t.Errorf("got %v %v, want the certificate subject", actor, err)
+	}
+
+	// the claimed actor does not authenticate
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-actor", "root"))
+	if _, err := call(ctx, method); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("got %v, want Unauthenticated", err)
+	}
+
+	if actor, err := call(ctx, "/grpc.health.v1.Health/Check"); err != nil || actor != util.AnonymousActor {
+		t.Errorf("got %v %v, want the skipped method served anonymously", actor, err)
+	}
+}
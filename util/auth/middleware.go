@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/labstack/echo/v4"
+)
+
+// errorResponse is the body of a rejected request
+type errorResponse struct {
+	Status  int64  `json:"status"`
+	Message string `json:"message"`
+}
+
+//Middleware func make the principal of the request the actor of its session and reject the unauthenticated
+//requests, the paths having one of the skipped prefixes are not authenticated
+func Middleware(authenticator *Authenticator, skip ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			for _, prefix := range skip {
+				if strings.HasPrefix(req.URL.Path, prefix) {
+					return next(c)
+				}
+			}
+
+			principal, err := authenticator.Principal(req.TLS, req.Header.Get(echo.HeaderAuthorization))
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return c.JSON(http.StatusUnauthorized, &errorResponse{Status: http.StatusUnauthorized, Message: err.Error()})
+			}
+			if principal != "" {
+				util.SessionAuthenticate(req.Context(), principal)
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package util
+
+import "time"
+
+//Backoff func return the exponential delay after the given failed attempts
+func Backoff(attempts int, min, max time.Duration) time.Duration {
+	backoff := min
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
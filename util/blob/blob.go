@@ -0,0 +1,68 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrNotFound is returned when a key has no blob
+var ErrNotFound = errors.New("blob: not found")
+
+type (
+	// Store keep blobs by key, keys are slash separated paths
+	Store interface {
+		Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+		Get(ctx context.Context, key string) (io.ReadCloser, *Info, error)
+		Delete(ctx context.Context, key string) error
+		// DeletePrefix remove every blob which key starts with prefix
+		DeletePrefix(ctx context.Context, prefix string) error
+	}
+
+	// Info describe a stored blob
+	Info struct {
+		Key         string
+		Size        int64
+		ContentType string
+		ModTime     time.Time
+	}
+
+	// Config of the blob store
+	Config struct {
+		// Driver is local or s3
+		Driver string
+		// LocalRoot is the directory of the local driver
+		LocalRoot string
+		S3        S3Config
+	}
+)
+
+// NewStore return the store of the configured driver
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStore(cfg.LocalRoot)
+	case "s3":
+		return NewS3Store(cfg.S3)
+	}
+
+	return nil, errors.New("blob: unknown driver " + cfg.Driver)
+}
+
+// ConfigFromEnv read the blob store configuration from the environment
+func ConfigFromEnv() Config {
+	return Config{
+		Driver:    os.Getenv("BLOB_DRIVER"),
+		LocalRoot: os.Getenv("BLOB_LOCAL_ROOT"),
+		S3: S3Config{
+			Endpoint:  os.Getenv("BLOB_S3_ENDPOINT"),
+			Region:    os.Getenv("BLOB_S3_REGION"),
+			Bucket:    os.Getenv("BLOB_S3_BUCKET"),
+			AccessKey: os.Getenv("BLOB_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("BLOB_S3_SECRET_KEY"),
+			UseSSL:    os.Getenv("BLOB_S3_USE_SSL") == "true",
+		},
+	}
+}
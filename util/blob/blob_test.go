@@ -0,0 +1,175 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "blob-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	store, err := NewLocalStore(filepath.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStore(t, store)
+
+	// the keys cannot escape the root
+	if err := store.Put(context.Background(), "../../escaped", strings.NewReader("x"), 1, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped")); !os.IsNotExist(err) {
+		t.Errorf("blob written out of the root: %v", err)
+	}
+}
+
+// TestS3Store run against the S3 compatible server of BLOB_TEST_S3_ENDPOINT, such as a local MinIO started with
+// docker run -p 9000:9000 minio/minio server /data
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("BLOB_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("BLOB_TEST_S3_ENDPOINT is not set")
+	}
+
+	cfg := S3Config{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    fmt.Sprintf("blob-test-%d", time.Now().UnixNano()),
+		AccessKey: envOr("BLOB_TEST_S3_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOr("BLOB_TEST_S3_SECRET_KEY", "minioadmin"),
+		UseSSL:    os.Getenv("BLOB_TEST_S3_USE_SSL") == "true",
+	}
+	store, err := NewS3Store(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		ctx := context.Background()
+		if err := store.DeletePrefix(ctx, ""); err != nil {
+			t.Error(err)
+		}
+		if err := store.client.RemoveBucket(ctx, cfg.Bucket); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	testStore(t, store)
+
+	// the existing bucket is reused
+	if _, err := NewS3Store(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testStore check the behavior every Store share
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	put := func(key, content string) {
+		t.Helper()
+		if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "image/png"); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	exists := func(key string) bool {
+		t.Helper()
+		body, _, err := store.Get(ctx, key)
+		if err == ErrNotFound {
+			return false
+		}
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		body.Close()
+		return true
+	}
+
+	t.Run("put and get", func(t *testing.T) {
+		put("books/1/cover/v1/original", "first")
+		put("books/1/cover/v1/original", "second")
+
+		body, info, err := store.Get(ctx, "books/1/cover/v1/original")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer body.Close()
+
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "second" {
+			t.Errorf("got %q, want the last put", data)
+		}
+		if info.Size != int64(len("second")) || info.ContentType != "image/png" || info.ModTime.IsZero() {
+			t.Errorf("got info %+v", info)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, _, err := store.Get(ctx, "books/1/cover/none/original"); err != ErrNotFound {
+			t.Errorf("got %v, want ErrNotFound", err)
+		}
+		if err := store.Delete(ctx, "books/1/cover/none/original"); err != nil {
+			t.Errorf("delete of a missing blob: %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		put("books/2/cover/v1/original", "x")
+		if err := store.Delete(ctx, "books/2/cover/v1/original"); err != nil {
+			t.Fatal(err)
+		}
+		if exists("books/2/cover/v1/original") {
+			t.Error("blob still exists")
+		}
+	})
+
+	t.Run("delete prefix", func(t *testing.T) {
+		put("books/3/cover/v1/original", "x")
+		put("books/3/cover/v1/small", "x")
+		put("books/3/cover/v2/original", "x")
+		put("books/33/cover/v1/original", "x")
+
+		if err := store.DeletePrefix(ctx, "books/3/cover/v1/"); err != nil {
+			t.Fatal(err)
+		}
+		if exists("books/3/cover/v1/original") || exists("books/3/cover/v1/small") {
+			t.Error("blobs of the prefix still exist")
+		}
+		if !exists("books/3/cover/v2/original") {
+			t.Error("blob of another version deleted")
+		}
+
+		if err := store.DeletePrefix(ctx, "books/3/"); err != nil {
+			t.Fatal(err)
+		}
+		if exists("books/3/cover/v2/original") {
+			t.Error("blob of the book still exists")
+		}
+		if !exists("books/33/cover/v1/original") {
+			t.Error("blob of another book deleted")
+		}
+
+		if err := store.DeletePrefix(ctx, "books/404/"); err != nil {
+			t.Errorf("delete of a missing prefix: %v", err)
+		}
+	})
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,119 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	dirMode  = os.FileMode(0755)
+	fileMode = os.FileMode(0644)
+
+	// contentTypeSuffix is the sidecar file keeping the content type of a blob
+	contentTypeSuffix = ".content-type"
+)
+
+// LocalStore keep blobs as files under a root directory
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore return a store rooted at root, the directory is created if missing
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		root = "storage"
+	}
+	if err := os.MkdirAll(root, dirMode); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{root: root}, nil
+}
+
+// Put write the blob atomically through a temporary file
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	file := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(file), dirMode); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, r); err == nil {
+		err = tmp.Chmod(fileMode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(file+contentTypeSuffix, []byte(contentType), fileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Get open the blob
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, *Info, error) {
+	file := s.path(key)
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if data, err := ioutil.ReadFile(file + contentTypeSuffix); err == nil {
+		contentType = string(data)
+	}
+
+	return f, &Info{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: contentType,
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+// Delete remove the blob, a missing blob is not an error
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	file := s.path(key)
+	os.Remove(file + contentTypeSuffix)
+
+	err := os.Remove(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// DeletePrefix remove the blobs under prefix, prefix must end at a path segment
+func (s *LocalStore) DeletePrefix(ctx context.Context, prefix string) error {
+	return os.RemoveAll(s.path(strings.TrimSuffix(prefix, "/")))
+}
+
+// path map a key into the root, ".." segments cannot escape it
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+key)))
+}
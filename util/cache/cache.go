@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrMiss is returned when a key is not cached or expired
+var ErrMiss = errors.New("cache: miss")
+
+type (
+	// Cache keep values by key for a time to live, a ttl of 0 never expire
+	Cache interface {
+		Get(ctx context.Context, key string) ([]byte, error)
+		Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+		// SetIfAbsent store value unless key is cached, ok is false when it was
+		SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (ok bool, err error)
+		Delete(ctx context.Context, keys ...string) error
+	}
+
+	// Config of the cache
+	Config struct {
+		// Driver is none, memory or redis
+		Driver string
+		TTL    time.Duration
+		// MaxEntries bound the memory driver, the least recently used entries are evicted
+		MaxEntries int
+		Redis      RedisConfig
+	}
+)
+
+// DefaultConfig disable the cache, entries live a minute once enabled
+var DefaultConfig = Config{
+	Driver:     "none",
+	TTL:        time.Minute,
+	MaxEntries: 10000,
+}
+
+// New return the cache of the configured driver, nil when the cache is disabled
+func New(cfg Config) (Cache, error) {
+	switch cfg.Driver {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return NewMemoryCache(cfg.MaxEntries), nil
+	case "redis":
+		return NewRedisCache(cfg.Redis)
+	}
+
+	return nil, errors.New("cache: unknown driver " + cfg.Driver)
+}
+
+// ConfigFromEnv override DefaultConfig with CACHE_DRIVER, CACHE_TTL, CACHE_MAX_ENTRIES and the REDIS_* variables
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig
+	if v := os.Getenv("CACHE_DRIVER"); v != "" {
+		cfg.Driver = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("CACHE_TTL")); err == nil && v > 0 {
+		cfg.TTL = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("CACHE_MAX_ENTRIES")); err == nil && v > 0 {
+		cfg.MaxEntries = v
+	}
+
+	db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+	cfg.Redis = RedisConfig{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+		DB:       db,
+	}
+	return cfg
+}
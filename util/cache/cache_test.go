@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestMemoryCache(t *testing.T) {
+	testCache(t, NewMemoryCache(0), time.Sleep)
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	c := NewMemoryCache(2)
+	ctx := context.Background()
+
+	c.Set(ctx, "a", []byte("a"), 0)
+	c.Set(ctx, "b", []byte("b"), 0)
+	// a is now the most recently used
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	c.Set(ctx, "c", []byte("c"), 0)
+
+	if _, err := c.Get(ctx, "b"); err != ErrMiss {
+		t.Errorf("got %v, want the least recently used entry evicted", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := c.Get(ctx, key); err != nil {
+			t.Errorf("get %s: %v", key, err)
+		}
+	}
+
+	// an update is a use and does not grow the cache
+	c.Set(ctx, "a", []byte("A"), 0)
+	c.Set(ctx, "d", []byte("d"), 0)
+	if c.Len() != 2 {
+		t.Errorf("got %d entries, want 2", c.Len())
+	}
+	if _, err := c.Get(ctx, "c"); err != ErrMiss {
+		t.Errorf("got %v, want c evicted", err)
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	c, err := NewRedisCache(RedisConfig{Addr: server.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the expiration of miniredis follow its clock
+	testCache(t, c, server.FastForward)
+
+	addr := server.Addr()
+	server.Close()
+	if _, err := NewRedisCache(RedisConfig{Addr: addr}); err == nil {
+		t.Error("an unreachable redis is accepted")
+	}
+}
+
+// testCache check the behavior every Cache share, wait let the time pass
+func testCache(t *testing.T, c Cache, wait func(time.Duration)) {
+	ctx := context.Background()
+
+	t.Run("get and set", func(t *testing.T) {
+		if _, err := c.Get(ctx, "missing"); err != ErrMiss {
+			t.Fatalf("got %v, want ErrMiss", err)
+		}
+		if err := c.Set(ctx, "key", []byte("first"), 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Set(ctx, "key", []byte("second"), 0); err != nil {
+			t.Fatal(err)
+		}
+		value, err := c.Get(ctx, "key")
+		if err != nil || string(value) != "second" {
+			t.Fatalf("got %q %v, want the last value", value, err)
+		}
+	})
+
+	t.Run("set if absent", func(t *testing.T) {
+		ok, err := c.SetIfAbsent(ctx, "generation", []byte("1"), 0)
+		if err != nil || !ok {
+			t.Fatalf("got %t %v, want the value stored", ok, err)
+		}
+		ok, err = c.SetIfAbsent(ctx, "generation", []byte("2"), 0)
+		if err != nil || ok {
+			t.Fatalf("got %t %v, want the value kept", ok, err)
+		}
+		if value, _ := c.Get(ctx, "generation"); string(value) != "1" {
+			t.Errorf("got %q, want the first value", value)
+		}
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		if err := c.Set(ctx, "short", []byte("x"), 50*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Get(ctx, "short"); err != nil {
+			t.Fatal(err)
+		}
+		wait(100 * time.Millisecond)
+		if _, err := c.Get(ctx, "short"); err != ErrMiss {
+			t.Errorf("got %v, want the entry expired", err)
+		}
+		// an expired entry is absent
+		if ok, err := c.SetIfAbsent(ctx, "short", []byte("y"), 0); err != nil || !ok {
+			t.Errorf("got %t %v, want the expired entry replaced", ok, err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c.Set(ctx, "a", []byte("a"), 0)
+		c.Set(ctx, "b", []byte("b"), 0)
+		if err := c.Delete(ctx, "a", "b", "missing"); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"a", "b"} {
+			if _, err := c.Get(ctx, key); err != ErrMiss {
+				t.Errorf("got %v, want %s deleted", err, key)
+			}
+		}
+		if err := c.Delete(ctx); err != nil {
+			t.Errorf("delete of no key: %v", err)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// MemoryCache is an in-process LRU cache with expiration
+	MemoryCache struct {
+		sync.Mutex
+		maxEntries int
+		entries    map[string]*list.Element
+		// recency list, the front is the most recently used
+		recency *list.List
+	}
+
+	memoryEntry struct {
+		key       string
+		value     []byte
+		expiresAt time.Time
+	}
+)
+
+// NewMemoryCache return a MemoryCache keeping up to maxEntries, unbounded when 0
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		recency:    list.New(),
+	}
+}
+
+// Get return the value of key, ErrMiss when absent or expired
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, ErrMiss
+	}
+
+	c.recency.MoveToFront(element)
+	return entry.value, nil
+}
+
+// Set store value for ttl and evict the least recently used entry when full
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.set(key, value, ttl)
+	return nil
+}
+
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.recency.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.recency.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	if c.maxEntries > 0 && c.recency.Len() > c.maxEntries {
+		c.remove(c.recency.Back())
+	}
+}
+
+// SetIfAbsent store value unless key has a live entry
+func (c *MemoryCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			return false, nil
+		}
+	}
+	c.set(key, value, ttl)
+	return true, nil
+}
+
+// Delete remove keys
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// Len return the number of entries, expired ones included until they are evicted
+func (c *MemoryCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.recency.Len()
+}
+
+func (c *MemoryCache) remove(element *list.Element) {
+	c.recency.Remove(element)
+	delete(c.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// published is the "cache" expvar, the Stats of every Metrics by name
+	published = expvar.NewMap("cache")
+
+	registry   = make(map[string]*Metrics)
+	registryMu sync.Mutex
+)
+
+type (
+	// Metrics count the lookups of a cache, they are published as expvar and served on /debug/vars of DEBUG_ADDR
+	Metrics struct {
+		hits   int64
+		misses int64
+		errors int64
+	}
+
+	// Stats is a snapshot of Metrics
+	Stats struct {
+		Hits   int64 `json:"hits"`
+		Misses int64 `json:"misses"`
+		Errors int64 `json:"errors"`
+	}
+)
+
+// NewMetrics return the Metrics published under name, the same name return the same Metrics
+func NewMetrics(name string) *Metrics {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if m, ok := registry[name]; ok {
+		return m
+	}
+
+	m := &Metrics{}
+	registry[name] = m
+	published.Set(name, expvar.Func(func() interface{} { return m.Stats() }))
+	return m
+}
+
+// Hit count a value served from the cache
+func (m *Metrics) Hit() {
+	atomic.AddInt64(&m.hits, 1)
+}
+
+// Miss count a value loaded from the source
+func (m *Metrics) Miss() {
+	atomic.AddInt64(&m.misses, 1)
+}
+
+// Error count a failed cache operation, the source is used instead
+func (m *Metrics) Error() {
+	atomic.AddInt64(&m.errors, 1)
+}
+
+// Stats return the counters
+func (m *Metrics) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadInt64(&m.hits),
+		Misses: atomic.LoadInt64(&m.misses),
+		Errors: atomic.LoadInt64(&m.errors),
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type (
+	// RedisConfig of the redis driver
+	RedisConfig struct {
+		Addr     string
+		Password string
+		DB       int
+	}
+
+	// RedisCache keep values in redis, shared by every instance
+	RedisCache struct {
+		client *redis.Client
+	}
+)
+
+// NewRedisCache return a RedisCache, the connection is checked with a ping
+func NewRedisCache(cfg RedisConfig) (*RedisCache, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("cache: redis address is required")
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &RedisCache{client: client}, nil
+}
+
+// Get return the value of key, ErrMiss when absent
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set store value for ttl
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetIfAbsent store value unless key exists
+func (c *RedisCache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// Delete remove keys
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// Close the connections
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,361 @@
+package compress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+)
+
+// Content encodings
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+type (
+	// Config of the response compression
+	Config struct {
+		// Encodings are offered in order of preference, the compression is off when empty
+		Encodings []string
+		// MinSize is the smallest body compressed, smaller bodies are sent as is
+		MinSize     int
+		GzipLevel   int
+		BrotliLevel int
+	}
+
+	// encoder compress into a writer, it is reset for every response
+	encoder interface {
+		io.WriteCloser
+		Flush() error
+		Reset(w io.Writer)
+	}
+)
+
+// DefaultConfig prefer brotli over gzip and compress the bodies of 1KB and more
+var DefaultConfig = Config{
+	Encodings:   []string{EncodingBrotli, EncodingGzip},
+	MinSize:     1024,
+	GzipLevel:   gzip.DefaultCompression,
+	BrotliLevel: brotli.DefaultCompression,
+}
+
+// ConfigFromEnv override DefaultConfig with COMPRESS_ENCODINGS, COMPRESS_MIN_SIZE, COMPRESS_GZIP_LEVEL
+// and COMPRESS_BROTLI_LEVEL, COMPRESS_ENCODINGS=none turn the compression off
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if v := os.Getenv("COMPRESS_ENCODINGS"); v != "" {
+		config.Encodings = nil
+		for _, encoding := range strings.Split(v, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding == EncodingBrotli || encoding == EncodingGzip {
+				config.Encodings = append(config.Encodings, encoding)
+			}
+		}
+	}
+	if v, err := strconv.Atoi(os.Getenv("COMPRESS_MIN_SIZE")); err == nil && v >= 0 {
+		config.MinSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("COMPRESS_GZIP_LEVEL")); err == nil &&
+		v >= gzip.HuffmanOnly && v <= gzip.BestCompression {
+		config.GzipLevel = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("COMPRESS_BROTLI_LEVEL")); err == nil &&
+		v >= brotli.BestSpeed && v <= brotli.BestCompression {
+		config.BrotliLevel = v
+	}
+	return config
+}
+
+//Middleware func compress the responses in the preferred encoding the client accepts. The strong ETags of
+//the compressed responses get the encoding as suffix, it is removed from If-None-Match so the handlers
+//compare their own tags. WebSocket upgrades and event streams are not compressed
+func Middleware(config Config) echo.MiddlewareFunc {
+	pools := map[string]*sync.Pool{
+		EncodingGzip: {New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(nil, config.GzipLevel)
+			return w
+		}},
+		EncodingBrotli: {New: func() interface{} {
+			return brotli.NewWriterLevel(nil, config.BrotliLevel)
+		}},
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if len(config.Encodings) == 0 || req.Header.Get("Upgrade") != "" {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			encoding := Negotiate(req.Header.Get(echo.HeaderAcceptEncoding), config.Encodings)
+
+			// a 304 repeat the tag the client cached
+			stripped := ""
+			if inm := req.Header.Get("If-None-Match"); inm != "" {
+				inm, stripped = stripSuffixes(inm, config.Encodings)
+				req.Header.Set("If-None-Match", inm)
+			}
+
+			if encoding == "" || req.Method == http.MethodHead {
+				return next(c)
+			}
+
+			w := &writer{
+				ResponseWriter: res.Writer,
+				encoding:       encoding,
+				pool:           pools[encoding],
+				minSize:        config.MinSize,
+				notModifiedTag: stripped == encoding,
+			}
+			res.Writer = w
+			defer func() {
+				if err := w.close(); err != nil {
+					c.Logger().Errorf("compress response: %v", err)
+				}
+				res.Writer = w.ResponseWriter
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// Negotiate return the first of offers accepted by an Accept-Encoding header, empty when none is
+func Negotiate(acceptEncoding string, offers []string) string {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, q := parseCoding(part)
+		if coding != "" {
+			accepted[coding] = q
+		}
+	}
+
+	for _, offer := range offers {
+		q, ok := accepted[offer]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return offer
+		}
+	}
+	return ""
+}
+
+// parseCoding return the coding and the weight of an Accept-Encoding element
+func parseCoding(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	coding := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return coding, q
+}
+
+// stripSuffixes remove the encoding suffixes added to the strong ETags of an If-None-Match header, and
+// return the encoding of the last suffix removed
+func stripSuffixes(inm string, encodings []string) (string, string) {
+	var stripped string
+	tags := strings.Split(inm, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		for _, encoding := range encodings {
+			if !strings.HasPrefix(tag, "W/") && strings.HasSuffix(tag, "-"+encoding+"\"") {
+				tag = strings.TrimSuffix(tag, "-"+encoding+"\"") + "\""
+				stripped = encoding
+				break
+			}
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, ", "), stripped
+}
+
+// PlainETag return the strong ETag of a compressed response without its encoding suffix, the tag of the
+// uncompressed representation
+func PlainETag(etag string) string {
+	for _, encoding := range []string{EncodingBrotli, EncodingGzip} {
+		if !strings.HasPrefix(etag, "W/") && strings.HasSuffix(etag, "-"+encoding+"\"") {
+			return strings.TrimSuffix(etag, "-"+encoding+"\"") + "\""
+		}
+	}
+	return etag
+}
+
+// compressible tell whether a content type gains from compression
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case mediaType == "text/event-stream":
+		// the events must reach the client as soon as they are flushed
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case echo.MIMEApplicationJSON, echo.MIMEApplicationXML, echo.MIMEApplicationJavaScript,
+		"application/x-ndjson", "application/yaml", "application/msgpack":
+		return true
+	}
+	return false
+}
+
+// writer buffer the beginning of a body and compress it once it reaches minSize, the smaller bodies and
+// the responses that can not be compressed are written as is
+type writer struct {
+	http.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+	minSize  int
+	// notModifiedTag suffix the ETag of a 304, the client revalidated a compressed response
+	notModifiedTag bool
+
+	status  int
+	buf     []byte
+	encoder encoder
+	// plain is set once the body is written without compression
+	plain bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+
+	header := w.Header()
+	if code == http.StatusNotModified && w.notModifiedTag {
+		w.suffixETag()
+	}
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified ||
+		header.Get(echo.HeaderContentEncoding) != "" || !compressible(header.Get(echo.HeaderContentType)) {
+		w.plain = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.plain {
+		return w.ResponseWriter.Write(p)
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush send the buffered body, compressed when it reached minSize
+func (w *writer) Flush() {
+	if w.status != 0 && !w.plain && w.encoder == nil {
+		var err error
+		if len(w.buf) >= w.minSize {
+			err = w.start()
+		} else {
+			err = w.writePlain()
+		}
+		if err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack the connection of the response
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("compress: the response writer can not be hijacked")
+	}
+	return hijacker.Hijack()
+}
+
+// start compress the response and write the buffered body
+func (w *writer) start() error {
+	header := w.Header()
+	header.Set(echo.HeaderContentEncoding, w.encoding)
+	header.Del(echo.HeaderContentLength)
+	w.suffixETag()
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.encoder = w.pool.Get().(encoder)
+	w.encoder.Reset(w.ResponseWriter)
+	buf := w.buf
+	w.buf = nil
+	_, err := w.encoder.Write(buf)
+	return err
+}
+
+// suffixETag give the compressed representation its own strong tag
+func (w *writer) suffixETag() {
+	header := w.Header()
+	if etag := header.Get("ETag"); strings.HasSuffix(etag, "\"") && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", strings.TrimSuffix(etag, "\"")+"-"+w.encoding+"\"")
+	}
+}
+
+// writePlain send the response without compression
+func (w *writer) writePlain() error {
+	w.plain = true
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// close end the compressed stream, or send the body too small to be compressed
+func (w *writer) close() error {
+	switch {
+	case w.encoder != nil:
+		err := w.encoder.Close()
+		w.encoder.Reset(nil)
+		w.pool.Put(w.encoder)
+		w.encoder = nil
+		return err
+	case w.status != 0 && !w.plain:
+		return w.writePlain()
+	}
+	return nil
+}
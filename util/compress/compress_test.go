@@ -0,0 +1,150 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-rest-api-boilerplate/util/httpcache"
+	"github.com/labstack/echo/v4"
+)
+
+// serve compress a JSON body of size bytes, tagged by httpcache
+func serve(t *testing.T, size int, headers map[string]string) *httptest.ResponseRecorder {
+	body := `"` + strings.Repeat("a", size-2) + `"`
+	handler := Middleware(DefaultConfig)(httpcache.Middleware(httpcache.DefaultCacheControl)(func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, []byte(body))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/books", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	if err := handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+// decode return the body of rec decoded from its Content-Encoding
+func decode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	var body []byte
+	var err error
+	switch rec.Header().Get(echo.HeaderContentEncoding) {
+	case EncodingGzip:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(rec.Body); err == nil {
+			body, err = ioutil.ReadAll(r)
+		}
+	case EncodingBrotli:
+		body, err = ioutil.ReadAll(brotli.NewReader(rec.Body))
+	default:
+		body = rec.Body.Bytes()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMiddlewareThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		accept   string
+		encoding string
+	}{
+		{"below threshold", 1023, "gzip, br", ""},
+		{"at threshold", 1024, "gzip", EncodingGzip},
+		{"brotli preferred", 1024, "gzip, br", EncodingBrotli},
+		{"brotli refused", 4096, "br;q=0, *", EncodingGzip},
+		{"identity", 4096, "identity", ""},
+		{"no accept", 4096, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(t, test.size, map[string]string{echo.HeaderAcceptEncoding: test.accept})
+			if got := rec.Header().Get(echo.HeaderContentEncoding); got != test.encoding {
+				t.Errorf("got Content-Encoding %q, want %q", got, test.encoding)
+			}
+			if got := rec.Header().Get(echo.HeaderVary); got != echo.HeaderAcceptEncoding {
+				t.Errorf("got Vary %q, want %q", got, echo.HeaderAcceptEncoding)
+			}
+			if body := decode(t, rec); len(body) != test.size {
+				t.Errorf("got a body of %d bytes, want %d", len(body), test.size)
+			}
+
+			// the compressed representation has its own strong tag
+			etag := rec.Header().Get("ETag")
+			if test.encoding != "" && !strings.HasSuffix(etag, "-"+test.encoding+`"`) {
+				t.Errorf("got ETag %q, want the %s suffix", etag, test.encoding)
+			}
+			if PlainETag(etag) != serve(t, test.size, nil).Header().Get("ETag") {
+				t.Errorf("got plain ETag %q, want the tag of the uncompressed body", PlainETag(etag))
+			}
+		})
+	}
+}
+
+// a 304 repeat the tag the client cached, suffixed only when the client cached the negotiated encoding
+func TestMiddlewareNotModified(t *testing.T) {
+	etag := serve(t, 2048, map[string]string{echo.HeaderAcceptEncoding: "gzip"}).Header().Get("ETag")
+
+	tests := []struct {
+		name   string
+		inm    string
+		accept string
+		status int
+		etag   string
+	}{
+		{"compressed tag", etag, "gzip", http.StatusNotModified, etag},
+		{"weak compressed tag", "W/" + PlainETag(etag), "gzip", http.StatusNotModified, PlainETag(etag)},
+		{"plain tag", PlainETag(etag), "", http.StatusNotModified, PlainETag(etag)},
+		{"other encoding", etag, "br", http.StatusNotModified, PlainETag(etag)},
+		{"stale tag", `"stale-gzip"`, "gzip", http.StatusOK, etag},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(t, 2048, map[string]string{
+				echo.HeaderAcceptEncoding: test.accept,
+				"If-None-Match":           test.inm,
+			})
+			if rec.Code != test.status {
+				t.Fatalf("got status %d, want %d", rec.Code, test.status)
+			}
+			if got := rec.Header().Get("ETag"); got != test.etag {
+				t.Errorf("got ETag %q, want %q", got, test.etag)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{EncodingBrotli, EncodingGzip}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", EncodingGzip},
+		{"GZIP;q=0.5, deflate", EncodingGzip},
+		{"gzip, br", EncodingBrotli},
+		{"br;q=0, gzip;q=0.1", EncodingGzip},
+		{"*", EncodingBrotli},
+		{"*;q=0", ""},
+		{"deflate", ""},
+	}
+
+	for _, test := range tests {
+		if got := Negotiate(test.accept, offers); got != test.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
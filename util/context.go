@@ -9,8 +9,14 @@ const SessionKey = 1
 
 //Session struct
 type Session struct {
-	CID    string
-	Logger Logger
+	CID string
+	// Actor is the authenticated principal, AnonymousActor until the request is authenticated
+	Actor string
+	// Principal is the subject of the verified client certificate or bearer token, empty when anonymous
+	Principal string
+	// ClaimedActor is the actor named by the client, it is recorded but never trusted
+	ClaimedActor string
+	Logger       Logger
 }
 
 //SessionCid func
@@ -25,6 +31,58 @@ func SessionCid(ctx context.Context) string {
 	return session.CID
 }
 
+//SessionActor func
+func SessionActor(ctx context.Context) string {
+	session, ok := ctx.Value(SessionKey).(*Session)
+
+	// Handle if session middleware is not used
+	if !ok {
+		return ""
+	}
+
+	return session.Actor
+}
+
+//SessionPrincipal func
+func SessionPrincipal(ctx context.Context) string {
+	session, ok := ctx.Value(SessionKey).(*Session)
+
+	// Handle if session middleware is not used
+	if !ok {
+		return ""
+	}
+
+	return session.Principal
+}
+
+//SessionClaimedActor func
+func SessionClaimedActor(ctx context.Context) string {
+	session, ok := ctx.Value(SessionKey).(*Session)
+
+	// Handle if session middleware is not used
+	if !ok {
+		return ""
+	}
+
+	return session.ClaimedActor
+}
+
+//SessionAuthenticate func make the authenticated principal the actor of the session of ctx
+func SessionAuthenticate(ctx context.Context, principal string) {
+	session, ok := ctx.Value(SessionKey).(*Session)
+
+	// Handle if session middleware is not used
+	if !ok {
+		return
+	}
+
+	session.Principal = principal
+	session.Actor = principal
+	if session.Logger != nil {
+		session.Logger = session.Logger.WithField("actor", principal)
+	}
+}
+
 //SessionLogger func
 func SessionLogger(ctx context.Context) Logger {
 	session, ok := ctx.Value(SessionKey).(*Session)
@@ -40,8 +98,8 @@ func SessionLogger(ctx context.Context) Logger {
 //NewSessionCtx func
 func NewSessionCtx(cid string, log Logger) context.Context {
 	session := Session{
-		cid,
-		log,
+		CID:    cid,
+		Logger: log,
 	}
 	return context.WithValue(context.Background(), SessionKey, &session)
 }
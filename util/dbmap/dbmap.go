@@ -0,0 +1,285 @@
+package dbmap
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tag is the struct tag of the mapped fields, db:"column[,readonly][,nullzero]". The fields without tag are not
+// mapped, except the embedded structs whose fields are mapped as fields of the outer struct
+const Tag = "db"
+
+// Tag options
+const (
+	// OptionReadonly exclude the column from the inserts and updates, it is set by the database
+	OptionReadonly = "readonly"
+	// OptionNullZero write the zero value as NULL and read NULL as the zero value
+	OptionNullZero = "nullzero"
+)
+
+// mappings cache the Mapping of every struct type
+var mappings sync.Map
+
+type (
+	// Mapping is the columns of a struct type and the way its fields are read and written
+	Mapping struct {
+		typ      reflect.Type
+		fields   []*field
+		columns  []string
+		writable []string
+	}
+
+	// Scanner is a row, *sql.Row or *sql.Rows
+	Scanner interface {
+		Scan(dest ...interface{}) error
+	}
+
+	field struct {
+		column   string
+		index    []int
+		readonly bool
+		nullZero bool
+	}
+
+	// nullZero scan NULL into the zero value of the field
+	nullZero struct {
+		field reflect.Value
+	}
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+//Of func return the mapping of v, a struct or a pointer to a struct. It panics when the tags of the struct are
+//invalid, like regexp.MustCompile it is meant to initialize package variables
+func Of(v interface{}) *Mapping {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("dbmap: %T is not a struct", v))
+	}
+
+	if m, ok := mappings.Load(t); ok {
+		return m.(*Mapping)
+	}
+
+	m := &Mapping{typ: t}
+	seen := map[string]bool{}
+	m.add(t, nil, seen)
+	for _, f := range m.fields {
+		m.columns = append(m.columns, f.column)
+		if !f.readonly {
+			m.writable = append(m.writable, f.column)
+		}
+	}
+
+	actual, _ := mappings.LoadOrStore(t, m)
+	return actual.(*Mapping)
+}
+
+// add the fields of t, index is the path of t in the mapped struct
+func (m *Mapping) add(t reflect.Type, index []int, seen map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		path := append(append([]int{}, index...), i)
+		tag, tagged := sf.Tag.Lookup(Tag)
+		if tag == "-" {
+			continue
+		}
+
+		if !tagged {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if sf.Anonymous && embedded.Kind() == reflect.Struct {
+				// the unexported embedded pointers cannot be allocated on scan
+				if sf.PkgPath != "" && sf.Type.Kind() == reflect.Ptr {
+					panic(fmt.Sprintf("dbmap: embedded %s of %s is unexported", sf.Type, m.typ))
+				}
+				m.add(embedded, path, seen)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			panic(fmt.Sprintf("dbmap: %s.%s is unexported", m.typ, sf.Name))
+		}
+
+		options := strings.Split(tag, ",")
+		f := &field{column: options[0], index: path}
+		if f.column == "" {
+			panic(fmt.Sprintf("dbmap: %s.%s has no column", m.typ, sf.Name))
+		}
+		if seen[f.column] {
+			panic(fmt.Sprintf("dbmap: column %q of %s is mapped twice", f.column, m.typ))
+		}
+		seen[f.column] = true
+
+		for _, option := range options[1:] {
+			switch option {
+			case OptionReadonly:
+				f.readonly = true
+			case OptionNullZero:
+				f.nullZero = true
+			default:
+				panic(fmt.Sprintf("dbmap: unknown option %q of %s.%s", option, m.typ, sf.Name))
+			}
+		}
+		m.fields = append(m.fields, f)
+	}
+}
+
+//Columns func return every mapped column in the order of the fields, it must not be modified
+func (m *Mapping) Columns() []string {
+	return m.columns
+}
+
+//WritableColumns func return the columns without the readonly option, it must not be modified
+func (m *Mapping) WritableColumns() []string {
+	return m.writable
+}
+
+//Values func return the values of WritableColumns of v, a pointer to the struct
+func (m *Mapping) Values(v interface{}) []interface{} {
+	s := m.value(v)
+	values := make([]interface{}, 0, len(m.writable))
+	for _, f := range m.fields {
+		if !f.readonly {
+			values = append(values, f.get(s))
+		}
+	}
+	return values
+}
+
+//SetMap func return the values of WritableColumns of v by column, the set of an update
+func (m *Mapping) SetMap(v interface{}) map[string]interface{} {
+	values := m.Values(v)
+	setMap := make(map[string]interface{}, len(values))
+	for i, column := range m.writable {
+		setMap[column] = values[i]
+	}
+	return setMap
+}
+
+//Destinations func return the scan destinations of Columns in the fields of v, a pointer to the struct. The nil
+//embedded pointers are allocated
+func (m *Mapping) Destinations(v interface{}) []interface{} {
+	s := m.value(v)
+	dest := make([]interface{}, len(m.fields))
+	for i, f := range m.fields {
+		fv := fieldAlloc(s, f.index)
+		if f.nullZero {
+			dest[i] = &nullZero{field: fv}
+			continue
+		}
+		dest[i] = fv.Addr().Interface()
+	}
+	return dest
+}
+
+//Scan func scan a row selected with Columns into v, a pointer to the struct
+func (m *Mapping) Scan(row Scanner, v interface{}) error {
+	return row.Scan(m.Destinations(v)...)
+}
+
+// value return the struct v point to
+func (m *Mapping) value(v interface{}) reflect.Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != m.typ {
+		panic(fmt.Sprintf("dbmap: %T is not a pointer to %s", v, m.typ))
+	}
+	return rv.Elem()
+}
+
+// get the value of the field, nil behind a nil embedded pointer
+func (f *field) get(s reflect.Value) interface{} {
+	fv := s
+	for _, i := range f.index {
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				return nil
+			}
+			fv = fv.Elem()
+		}
+		fv = fv.Field(i)
+	}
+
+	if f.nullZero && fv.IsZero() {
+		return nil
+	}
+	return fv.Interface()
+}
+
+// fieldAlloc return the field at index, allocating the nil pointers on its path
+func fieldAlloc(s reflect.Value, index []int) reflect.Value {
+	fv := s
+	for _, i := range index {
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		fv = fv.Field(i)
+	}
+	return fv
+}
+
+// Scan implement sql.Scanner
+func (n *nullZero) Scan(src interface{}) error {
+	if src == nil {
+		n.field.Set(reflect.Zero(n.field.Type()))
+		return nil
+	}
+	if n.field.Addr().Type().Implements(scannerType) {
+		return n.field.Addr().Interface().(sql.Scanner).Scan(src)
+	}
+
+	// the sql.Null types convert the value like a scan into the field would
+	var (
+		value reflect.Value
+		err   error
+	)
+	switch kind := n.field.Kind(); {
+	case n.field.Type() == timeType:
+		var v sql.NullTime
+		err = v.Scan(src)
+		value = reflect.ValueOf(v.Time)
+	case kind == reflect.String:
+		var v sql.NullString
+		err = v.Scan(src)
+		value = reflect.ValueOf(v.String)
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		var v sql.NullInt64
+		err = v.Scan(src)
+		value = reflect.ValueOf(v.Int64)
+	case kind >= reflect.Uint && kind <= reflect.Uint64:
+		var v sql.NullInt64
+		err = v.Scan(src)
+		value = reflect.ValueOf(v.Int64)
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		var v sql.NullFloat64
+		err = v.Scan(src)
+		value = reflect.ValueOf(v.Float64)
+	case kind == reflect.Bool:
+		var v sql.NullBool
+		err = v.Scan(src)
+		value = reflect.ValueOf(v.Bool)
+	default:
+		return fmt.Errorf("dbmap: nullzero is not supported on %s", n.field.Type())
+	}
+	if err != nil {
+		return err
+	}
+
+	n.field.Set(value.Convert(n.field.Type()))
+	return nil
+}
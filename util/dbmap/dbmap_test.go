@@ -0,0 +1,218 @@
+package dbmap
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type (
+	Base struct {
+		ID        int       `db:"id,readonly"`
+		CreatedAt time.Time `db:"created_at,readonly"`
+	}
+
+	Extra struct {
+		Note string `db:"note,nullzero"`
+	}
+
+	record struct {
+		Base
+		*Extra
+		Title     string          `db:"title"`
+		Count     int             `db:"count,nullzero"`
+		Rank      uint8           `db:"rank,nullzero"`
+		Published time.Time       `db:"published,nullzero"`
+		Price     sql.NullFloat64 `db:"price,nullzero"`
+		Ignored   string          `db:"-"`
+		Untagged  string
+	}
+
+	inner struct {
+		Name string `db:"name"`
+	}
+)
+
+// row scan its values like database/sql, the sql.Scanner destinations scan them and the others are set
+type row []interface{}
+
+func (r row) Scan(dest ...interface{}) error {
+	if len(dest) != len(r) {
+		return errors.New("wrong number of destinations")
+	}
+	for i, d := range dest {
+		if scanner, ok := d.(sql.Scanner); ok {
+			if err := scanner.Scan(r[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+func TestOf(t *testing.T) {
+	m := Of(&record{})
+	columns := []string{"id", "created_at", "note", "title", "count", "rank", "published", "price"}
+	if !reflect.DeepEqual(m.Columns(), columns) {
+		t.Errorf("got columns %v, want %v", m.Columns(), columns)
+	}
+	writable := []string{"note", "title", "count", "rank", "published", "price"}
+	if !reflect.DeepEqual(m.WritableColumns(), writable) {
+		t.Errorf("got writable columns %v, want %v", m.WritableColumns(), writable)
+	}
+
+	if Of(record{}) != m {
+		t.Error("the mapping of the struct is not the cached mapping of its pointer")
+	}
+}
+
+func TestOfPanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     interface{}
+		panic string
+	}{
+		{"not a struct", new(int), "is not a struct"},
+		{"nil", nil, "is not a struct"},
+		{"unexported", &struct {
+			name string `db:"name"`
+		}{}, "is unexported"},
+		{"no column", &struct {
+			Name string `db:",nullzero"`
+		}{}, "has no column"},
+		{"mapped twice", &struct {
+			inner
+			Name string `db:"name"`
+		}{}, "is mapped twice"},
+		{"unknown option", &struct {
+			Name string `db:"name,omitempty"`
+		}{}, "unknown option"},
+		{"unexported embedded pointer", &struct {
+			*inner
+		}{}, "is unexported"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				r, _ := recover().(string)
+				if !strings.Contains(r, test.panic) {
+					t.Errorf("got panic %q, want %q", r, test.panic)
+				}
+			}()
+			Of(test.v)
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	m := Of(&record{})
+	published := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	price := sql.NullFloat64{Float64: 9.5, Valid: true}
+
+	r := &record{
+		Base:      Base{ID: 1, CreatedAt: published},
+		Title:     "title",
+		Count:     3,
+		Rank:      2,
+		Published: published,
+		Price:     price,
+		Ignored:   "ignored",
+		Untagged:  "untagged",
+	}
+	want := []interface{}{nil, "title", 3, uint8(2), published, price}
+	if got := m.Values(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("got values %v, want %v", got, want)
+	}
+
+	r.Extra = &Extra{Note: "note"}
+	setMap := map[string]interface{}{
+		"note": "note", "title": "title", "count": 3, "rank": uint8(2), "published": published, "price": price,
+	}
+	if got := m.SetMap(r); !reflect.DeepEqual(got, setMap) {
+		t.Errorf("got set map %v, want %v", got, setMap)
+	}
+
+	// nullzero write the zero values as NULL, the others are written as they are
+	zero := &record{Extra: &Extra{}}
+	want = []interface{}{nil, "", nil, nil, nil, nil}
+	if got := m.Values(zero); !reflect.DeepEqual(got, want) {
+		t.Errorf("got zero values %v, want %v", got, want)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the values of another type did not panic")
+			}
+		}()
+		m.Values(&Extra{})
+	}()
+}
+
+func TestScan(t *testing.T) {
+	m := Of(&record{})
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	r := new(record)
+	err := m.Scan(row{1, created, "note", "title", int64(3), int64(2), created, 9.5}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Extra == nil {
+		t.Fatal("the embedded pointer is not allocated")
+	}
+	want := record{
+		Base:      Base{ID: 1, CreatedAt: created},
+		Extra:     &Extra{Note: "note"},
+		Title:     "title",
+		Count:     3,
+		Rank:      2,
+		Published: created,
+		Price:     sql.NullFloat64{Float64: 9.5, Valid: true},
+	}
+	if !reflect.DeepEqual(*r, want) {
+		t.Errorf("got %+v, want %+v", *r, want)
+	}
+
+	// nullzero read NULL as the zero value, over the previous values
+	err = m.Scan(row{2, created, nil, "", nil, nil, nil, nil}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Note != "" || r.Count != 0 || r.Rank != 0 || !r.Published.IsZero() || r.Price.Valid {
+		t.Errorf("got %+v, want the nullzero fields zero", *r)
+	}
+
+	// the bytes of the driver are converted like a scan into the field
+	err = m.Scan(row{3, created, []byte("note"), "", []byte("7"), "4", created, []byte("1.5")}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Note != "note" || r.Count != 7 || r.Rank != 4 || r.Price.Float64 != 1.5 {
+		t.Errorf("got %+v, want the converted values", *r)
+	}
+
+	if err := m.Scan(row{4, created, nil, "", "three", nil, nil, nil}, r); err == nil {
+		t.Error("a value not converted to the field did not fail")
+	}
+}
+
+func TestNullZeroUnsupported(t *testing.T) {
+	v := &struct {
+		Tags []string `db:"tags,nullzero"`
+	}{}
+	m := Of(v)
+
+	if err := m.Scan(row{nil}, v); err != nil {
+		t.Errorf("got %v scanning NULL", err)
+	}
+	if err := m.Scan(row{"a"}, v); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("got %v, want nullzero not supported", err)
+	}
+}
@@ -0,0 +1,150 @@
+package dbrouter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// HeaderReadYourWrites enable read-your-writes for a request, the reads following a write go to the primary
+const HeaderReadYourWrites = "X-Read-Your-Writes"
+
+// The write of a read-your-writes request pin the next requests of its client to the primary until every healthy
+// replica replayed it. The HTTP clients get the end of the pin in CookieReadYourWrites, the gRPC clients in the
+// HeaderPinnedUntil metadata they send back, both in unix milliseconds
+const (
+	CookieReadYourWrites = "read_your_writes"
+	HeaderPinnedUntil    = "X-Read-Your-Writes-Until"
+)
+
+type (
+	pinKey struct{}
+
+	// pin record the write of a read-your-writes request
+	pin struct {
+		pinned int32
+		wrote  int32
+	}
+)
+
+// WithReadYourWrites return a context whose reads go to the primary once it wrote
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(pinKey{}).(*pin); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, pinKey{}, &pin{})
+}
+
+// WithPrimary return a context whose reads go to the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pinKey{}, &pin{pinned: 1})
+}
+
+func pinned(ctx context.Context) bool {
+	p, ok := ctx.Value(pinKey{}).(*pin)
+	return ok && atomic.LoadInt32(&p.pinned) == 1
+}
+
+// wrote report whether a read-your-writes context wrote
+func wrote(ctx context.Context) bool {
+	p, ok := ctx.Value(pinKey{}).(*pin)
+	return ok && atomic.LoadInt32(&p.wrote) == 1
+}
+
+// window is the time a replica may take to replay a write, it lag up to MaxLag until the next check evict it
+func (c Config) window() time.Duration {
+	return c.MaxLag + c.CheckInterval
+}
+
+// pinnedUntil report whether the end of a pin is to come, the ends past the window of a new pin are forged
+func pinnedUntil(value string, config Config) bool {
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	return until > unixMilli(now) && until <= unixMilli(now.Add(config.window()))
+}
+
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// scope return the context of a request enabling read-your-writes, pinned by an earlier write of its client
+func scope(ctx context.Context, enabled bool, until string, config Config) context.Context {
+	if enabled {
+		ctx = WithReadYourWrites(ctx)
+	}
+	if pinnedUntil(until, config) {
+		ctx = WithPrimary(ctx)
+	}
+	return ctx
+}
+
+//Middleware func enable read-your-writes for the requests sending HeaderReadYourWrites. Their writes pin the
+//next requests of the client to the primary with CookieReadYourWrites, for the window of the replica checks
+func Middleware(config Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			enabled, _ := strconv.ParseBool(req.Header.Get(HeaderReadYourWrites))
+			until := ""
+			if cookie, err := req.Cookie(CookieReadYourWrites); err == nil {
+				until = cookie.Value
+			}
+			ctx := scope(req.Context(), enabled, until, config)
+			c.SetRequest(req.WithContext(ctx))
+
+			if enabled {
+				// the write happen before the response is written
+				c.Response().Before(func() {
+					if wrote(ctx) {
+						expires := time.Now().Add(config.window())
+						c.SetCookie(&http.Cookie{
+							Name:     CookieReadYourWrites,
+							Value:    strconv.FormatInt(unixMilli(expires), 10),
+							Path:     "/",
+							Expires:  expires,
+							HttpOnly: true,
+							SameSite: http.SameSiteLaxMode,
+						})
+					}
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+//UnaryInterceptor func enable read-your-writes for the calls sending the x-read-your-writes metadata. Their
+//writes return the end of the pin in the x-read-your-writes-until header, the next calls sending it back are
+//served by the primary
+func UnaryInterceptor(config Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		enabled := false
+		if values := md.Get(HeaderReadYourWrites); len(values) > 0 {
+			enabled, _ = strconv.ParseBool(values[0])
+		}
+		until := ""
+		if values := md.Get(HeaderPinnedUntil); len(values) > 0 {
+			until = values[0]
+		}
+		ctx = scope(ctx, enabled, until, config)
+
+		resp, err := handler(ctx, req)
+		if enabled && wrote(ctx) {
+			expires := unixMilli(time.Now().Add(config.window()))
+			// the header is lost when the handler already sent it
+			_ = grpc.SetHeader(ctx, metadata.Pairs(HeaderPinnedUntil, strconv.FormatInt(expires, 10)))
+		}
+		return resp, err
+	}
+}
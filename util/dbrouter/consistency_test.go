@@ -0,0 +1,145 @@
+package dbrouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// newPinRouter return a router of a primary and a healthy replica
+func newPinRouter(t *testing.T) *Router {
+	util.Log = logrus.New()
+	lags.set("pin-replica", 0)
+	router := New(open(t, "pin-primary"), []*Replica{{Name: "replica", DB: open(t, "pin-replica")}}, DefaultConfig)
+	router.check()
+	return router
+}
+
+// served name the connection of a read
+func served(router *Router, ctx context.Context) string {
+	if router.Read(ctx) == router.Primary() {
+		return "primary"
+	}
+	return "replica"
+}
+
+func TestMiddlewarePin(t *testing.T) {
+	router := newPinRouter(t)
+	s := echo.New()
+	s.Use(Middleware(router.Config()))
+	s.POST("/books", func(c echo.Context) error {
+		router.Write(c.Request().Context())
+		return c.String(http.StatusCreated, served(router, c.Request().Context()))
+	})
+	s.GET("/books", func(c echo.Context) error {
+		return c.String(http.StatusOK, served(router, c.Request().Context()))
+	})
+
+	serve := func(method string, header string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/books", nil)
+		if header != "" {
+			req.Header.Set(HeaderReadYourWrites, header)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "", nil); len(rec.Result().Cookies()) != 0 {
+		t.Error("got a pin without read-your-writes")
+	}
+	rec := serve(http.MethodPost, "true", nil)
+	if rec.Body.String() != "primary" {
+		t.Errorf("got the %s after the write", rec.Body.String())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CookieReadYourWrites || !cookies[0].HttpOnly {
+		t.Fatalf("got cookies %v, want the pin", cookies)
+	}
+	if window := time.Until(cookies[0].Expires); window <= 0 || window > DefaultConfig.MaxLag+DefaultConfig.CheckInterval {
+		t.Errorf("got a pin of %v", window)
+	}
+
+	until := func(d time.Duration) *http.Cookie {
+		return &http.Cookie{Name: CookieReadYourWrites, Value: strconv.FormatInt(unixMilli(time.Now().Add(d)), 10)}
+	}
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		want   string
+	}{
+		{"without pin", nil, "replica"},
+		{"pinned", cookies[0], "primary"},
+		{"expired pin", until(-time.Second), "replica"},
+		{"forged pin", until(time.Hour), "replica"},
+		{"malformed pin", &http.Cookie{Name: CookieReadYourWrites, Value: "forever"}, "replica"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serve(http.MethodGet, "", test.cookie).Body.String(); got != test.want {
+				t.Errorf("got the %s, want the %s", got, test.want)
+			}
+		})
+	}
+}
+
+// headerStream keep the header set by the calls
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestUnaryInterceptorPin(t *testing.T) {
+	router := newPinRouter(t)
+	interceptor := UnaryInterceptor(router.Config())
+	info := &grpc.UnaryServerInfo{FullMethod: "/book.BookService/CreateBook"}
+
+	call := func(write bool, md metadata.MD) (string, metadata.MD) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(context.Background(), md), stream)
+		resp, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			if write {
+				router.Write(ctx)
+			}
+			return served(router, ctx), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.(string), stream.header
+	}
+
+	if _, header := call(true, metadata.MD{}); len(header) != 0 {
+		t.Errorf("got header %v without read-your-writes", header)
+	}
+	_, header := call(true, metadata.Pairs(HeaderReadYourWrites, "true"))
+	values := header.Get(HeaderPinnedUntil)
+	if len(values) != 1 {
+		t.Fatalf("got header %v, want the end of the pin", header)
+	}
+
+	if got, _ := call(false, metadata.MD{}); got != "replica" {
+		t.Errorf("got the %s without pin", got)
+	}
+	if got, _ := call(false, metadata.Pairs(HeaderPinnedUntil, values[0])); got != "primary" {
+		t.Errorf("got the %s for a pinned call", got)
+	}
+}
@@ -0,0 +1,204 @@
+package dbrouter
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+)
+
+// lagQuery return the replay lag of a replica in seconds, 0 when it replayed everything it received
+const lagQuery = `SELECT CASE
+         WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+         ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+       END`
+
+type (
+	// Config of the replica health checks. A write of a read-your-writes request pin the next requests of its
+	// client to the primary for MaxLag and CheckInterval, the time a healthy replica may take to replay it
+	Config struct {
+		// MaxLag evict a replica replaying further behind the primary
+		MaxLag time.Duration
+		// CheckInterval between two lag checks
+		CheckInterval time.Duration
+	}
+
+	// Router send the reads to a healthy replica in round-robin and everything else to the primary
+	Router struct {
+		primary  *sql.DB
+		replicas []*Replica
+		config   Config
+		next     uint32
+		stop     chan bool
+		done     chan bool
+	}
+
+	// Replica is a read-only connection, healthy when reachable and not lagging
+	Replica struct {
+		Name string
+		DB   *sql.DB
+
+		sync.Mutex
+		healthy bool
+		lag     time.Duration
+	}
+)
+
+// DefaultConfig evict the replicas lagging more than 10 seconds, checked every 5 seconds
+var DefaultConfig = Config{
+	MaxLag:        10 * time.Second,
+	CheckInterval: 5 * time.Second,
+}
+
+// New return a Router of primary and replicas, without replicas every query go to the primary
+func New(primary *sql.DB, replicas []*Replica, config Config) *Router {
+	return &Router{
+		primary:  primary,
+		replicas: replicas,
+		config:   config,
+		stop:     make(chan bool),
+		done:     make(chan bool),
+	}
+}
+
+// Primary return the primary connection
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+// Write return the primary connection and pin the next reads of a read-your-writes request to it
+func (r *Router) Write(ctx context.Context) *sql.DB {
+	if p, ok := ctx.Value(pinKey{}).(*pin); ok {
+		atomic.StoreInt32(&p.pinned, 1)
+		atomic.StoreInt32(&p.wrote, 1)
+	}
+	return r.primary
+}
+
+// Read return a healthy replica, or the primary inside a transaction, after a write of a
+// read-your-writes request or when no replica is healthy
+func (r *Router) Read(ctx context.Context) *sql.DB {
+	if len(r.replicas) == 0 || dbtrxn.Retrieve(ctx) != nil || pinned(ctx) {
+		return r.primary
+	}
+
+	n := len(r.replicas)
+	start := int(atomic.AddUint32(&r.next, 1))
+	for i := 0; i < n; i++ {
+		replica := r.replicas[(start+i)%n]
+		if replica.Healthy() {
+			return replica.DB
+		}
+	}
+	return r.primary
+}
+
+// Config return the replica checks of the router
+func (r *Router) Config() Config {
+	return r.config
+}
+
+// Replicas return the replicas
+func (r *Router) Replicas() []*Replica {
+	return r.replicas
+}
+
+// Start checking the replicas in the background, they are healthy once checked
+func (r *Router) Start() {
+	if len(r.replicas) == 0 {
+		close(r.done)
+		return
+	}
+
+	r.check()
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.config.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.check()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop checking and close the replicas
+func (r *Router) Stop() error {
+	if len(r.replicas) > 0 {
+		r.stop <- true
+	}
+	<-r.done
+
+	for _, replica := range r.replicas {
+		replica.DB.Close()
+	}
+	return nil
+}
+
+// check the lag of every replica
+func (r *Router) check() {
+	logger := util.Log.WithField("context", "dbrouter")
+
+	var wg sync.WaitGroup
+	for _, replica := range r.replicas {
+		wg.Add(1)
+		go func(replica *Replica) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), r.config.CheckInterval)
+			defer cancel()
+
+			var seconds float64
+			err := replica.DB.QueryRowContext(ctx, lagQuery).Scan(&seconds)
+			lag := time.Duration(seconds * float64(time.Second))
+			healthy := err == nil && lag <= r.config.MaxLag
+
+			if was := replica.set(healthy, lag); was != healthy {
+				switch {
+				case healthy:
+					logger.Infof("replica %s is back, lag %v", replica.Name, lag)
+				case err != nil:
+					logger.Warnf("replica %s evicted: %v", replica.Name, err)
+				default:
+					logger.Warnf("replica %s evicted, lag %v over %v", replica.Name, lag, r.config.MaxLag)
+				}
+			}
+		}(replica)
+	}
+	wg.Wait()
+}
+
+// Healthy report whether the replica serve reads
+func (r *Replica) Healthy() bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.healthy
+}
+
+// Lag return the replay lag of the last check
+func (r *Replica) Lag() time.Duration {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.lag
+}
+
+// set the health of the replica and return the previous one
+func (r *Replica) set(healthy bool, lag time.Duration) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	was := r.healthy
+	r.healthy, r.lag = healthy, lag
+	return was
+}
@@ -0,0 +1,190 @@
+package dbrouter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/sirupsen/logrus"
+)
+
+// lagDriver answer every query of a connection with the lag in seconds of its data source name
+type lagDriver struct {
+	sync.Mutex
+	lags map[string]float64
+}
+
+var lags = &lagDriver{lags: map[string]float64{}}
+
+func init() {
+	sql.Register("dbrouter-lag", lags)
+}
+
+func (d *lagDriver) Open(name string) (driver.Conn, error) {
+	return &lagConn{name: name}, nil
+}
+
+func (d *lagDriver) set(name string, lag float64) {
+	d.Lock()
+	defer d.Unlock()
+	d.lags[name] = lag
+}
+
+type lagConn struct {
+	name string
+}
+
+func (c *lagConn) Prepare(query string) (driver.Stmt, error) {
+	return &lagStmt{name: c.name}, nil
+}
+
+func (c *lagConn) Close() error {
+	return nil
+}
+
+func (c *lagConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type lagStmt struct {
+	name string
+}
+
+func (s *lagStmt) Close() error {
+	return nil
+}
+
+func (s *lagStmt) NumInput() int {
+	return -1
+}
+
+func (s *lagStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *lagStmt) Query(args []driver.Value) (driver.Rows, error) {
+	lags.Lock()
+	defer lags.Unlock()
+	// a negative lag is an unreachable replica
+	lag, ok := lags.lags[s.name]
+	if !ok || lag < 0 {
+		return nil, errors.New("connection refused")
+	}
+	return &lagRows{lag: lag}, nil
+}
+
+type lagRows struct {
+	lag  float64
+	done bool
+}
+
+func (r *lagRows) Columns() []string {
+	return []string{"lag"}
+}
+
+func (r *lagRows) Close() error {
+	return nil
+}
+
+func (r *lagRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.lag
+	return nil
+}
+
+func open(t *testing.T, name string) *sql.DB {
+	db, err := sql.Open("dbrouter-lag", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestRead(t *testing.T) {
+	util.Log = logrus.New()
+
+	primary := open(t, "primary")
+	defer primary.Close()
+	if db := New(primary, nil, DefaultConfig).Read(context.Background()); db != primary {
+		t.Error("got a replica, want the primary without replicas")
+	}
+
+	lags.set("replica-1", 0)
+	lags.set("replica-2", 2)
+	lags.set("replica-3", 30)
+	replicas := []*Replica{
+		{Name: "replica-1", DB: open(t, "replica-1")},
+		{Name: "replica-2", DB: open(t, "replica-2")},
+		{Name: "replica-3", DB: open(t, "replica-3")},
+		{Name: "replica-4", DB: open(t, "replica-4")},
+	}
+	router := New(primary, replicas, Config{MaxLag: 10 * time.Second, CheckInterval: time.Hour})
+	ctx := context.Background()
+
+	// the replicas are healthy once checked
+	if db := router.Read(ctx); db != primary {
+		t.Error("got an unchecked replica, want the primary")
+	}
+	router.Start()
+	defer router.Stop()
+
+	if !replicas[0].Healthy() || !replicas[1].Healthy() || replicas[1].Lag() != 2*time.Second {
+		t.Error("want the replicas replaying under the max lag healthy")
+	}
+	if replicas[2].Healthy() || replicas[3].Healthy() {
+		t.Error("want the lagging and unreachable replicas evicted")
+	}
+	served := map[*sql.DB]int{}
+	for i := 0; i < 10; i++ {
+		served[router.Read(ctx)]++
+	}
+	if len(served) != 2 || served[replicas[0].DB] == 0 || served[replicas[1].DB] == 0 {
+		t.Errorf("got %v, want the reads spread over the healthy replicas only", served)
+	}
+
+	// the primary serve the transactions and the reads following a write
+	txCtx := ctx
+	dbtrxn.Begin(&txCtx)
+	if db := router.Read(txCtx); db != primary {
+		t.Error("got a replica inside a transaction")
+	}
+	if db := router.Read(WithPrimary(ctx)); db != primary {
+		t.Error("got a replica for a primary read")
+	}
+	ryw := WithReadYourWrites(ctx)
+	if db := router.Read(ryw); db == primary {
+		t.Error("got the primary before the write")
+	}
+	if db := router.Write(ryw); db != primary {
+		t.Error("got a replica for a write")
+	}
+	if db := router.Read(ryw); db != primary {
+		t.Error("got a replica after the write")
+	}
+	if db := router.Read(ctx); db == primary {
+		t.Error("got the primary for another request")
+	}
+
+	// the reads fall back to the primary once every replica is evicted
+	lags.set("replica-1", -1)
+	lags.set("replica-2", 60)
+	router.check()
+	if db := router.Read(ctx); db != primary {
+		t.Error("got an evicted replica, want the primary")
+	}
+	lags.set("replica-2", 0)
+	router.check()
+	if db := router.Read(ctx); db != replicas[1].DB {
+		t.Error("want the recovered replica serving again")
+	}
+}
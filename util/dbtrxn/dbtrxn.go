@@ -16,8 +16,9 @@ type (
 	key int
 	// Context of transaction
 	Context struct {
-		Tx  Tx
-		Err error
+		Tx       Tx
+		Err      error
+		onCommit []func()
 	}
 	// CommitFn is commit function to close the transaction
 	CommitFn func() error
@@ -26,14 +27,28 @@ type (
 		DB      sq.BaseRunner
 		Context *Context
 	}
+	// contextDB run the statements of a Handler outside of transactions with its context
+	contextDB struct {
+		ctx context.Context
+		db  *sql.DB
+	}
 	// Tx is interface for database transaction
 	Tx interface {
 		sq.BaseRunner
+		Prepare(query string) (*sql.Stmt, error)
 		Rollback() error
 		Commit() error
 	}
 )
 
+// beginHooks run in every transaction right after it begins
+var beginHooks []func(ctx context.Context, tx Tx) error
+
+// OnBegin register fn to run at the start of every transaction, it is not safe to call once transactions run
+func OnBegin(fn func(ctx context.Context, tx Tx) error) {
+	beginHooks = append(beginHooks, fn)
+}
+
 // Begin transaction
 func Begin(parent *context.Context) CommitFn {
 	c := &Context{}
@@ -49,9 +64,9 @@ func Use(ctx context.Context, db *sql.DB) (*Handler, error) {
 		return nil, errors.New("dbtxn: missing context.Context")
 	}
 
-	// NOTE: not transactional
+	// NOTE: not transactional, the statements run with ctx
 	if c == nil {
-		return &Handler{DB: db}, nil
+		return &Handler{DB: &contextDB{ctx: ctx, db: db}}, nil
 	}
 
 	if c.Tx == nil {
@@ -60,6 +75,13 @@ func Use(ctx context.Context, db *sql.DB) (*Handler, error) {
 			c.Err = fmt.Errorf("dbtxn: %w", err)
 			return nil, c.Err
 		}
+		for _, hook := range beginHooks {
+			if err := hook(ctx, tx); err != nil {
+				tx.Rollback()
+				c.Err = fmt.Errorf("dbtxn: %w", err)
+				return nil, c.Err
+			}
+		}
 		c.Tx = tx
 	}
 
@@ -76,6 +98,15 @@ func Retrieve(ctx context.Context) *Context {
 	return c
 }
 
+// OnCommit run fn once the transaction is committed, right away when ctx has no transaction
+func OnCommit(ctx context.Context, fn func()) {
+	if c := Retrieve(ctx); c != nil {
+		c.onCommit = append(c.onCommit, fn)
+		return
+	}
+	fn()
+}
+
 // Error of transaction
 func Error(ctx context.Context) error {
 	if c := Retrieve(ctx); c != nil {
@@ -88,15 +119,24 @@ func Error(ctx context.Context) error {
 // Context
 //
 
-// Commit if no error
+// Commit if no error, the OnCommit functions run after a successful commit
 func (c *Context) Commit() error {
-	if c.Tx == nil {
-		return nil
-	}
 	if c.Err != nil {
+		if c.Tx == nil {
+			return nil
+		}
 		return c.Tx.Rollback()
 	}
-	return c.Tx.Commit()
+	if c.Tx != nil {
+		if err := c.Tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	for _, fn := range c.onCommit {
+		fn()
+	}
+	return nil
 }
 
 //
@@ -112,3 +152,19 @@ func (t *Handler) SetError(err error) bool {
 	t.Context.Err = err
 	return true
 }
+
+//
+// contextDB
+//
+
+func (c *contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c *contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c *contextDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(c.ctx, query, args...)
+}
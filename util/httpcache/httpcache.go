@@ -0,0 +1,134 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultCacheControl let clients keep the responses of a tenant but revalidate them before every use
+const DefaultCacheControl = "private, no-cache"
+
+// CacheControl return the Cache-Control of a route from HTTP_CACHE_CONTROL_<ROUTE>, fallback when it is unset
+func CacheControl(route, fallback string) string {
+	if v := os.Getenv("HTTP_CACHE_CONTROL_" + strings.ToUpper(route)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// SetLastModified set the Last-Modified header of the response, If-Modified-Since is only honored on the
+// responses having one
+func SetLastModified(c echo.Context, t time.Time) {
+	if !t.IsZero() {
+		c.Response().Header().Set(echo.HeaderLastModified, t.UTC().Format(http.TimeFormat))
+	}
+}
+
+//Middleware func tag the successful GET and HEAD responses of a route with a strong ETag of their body
+//and cacheControl, and answer 304 to the requests whose If-None-Match or If-Modified-Since still match
+func Middleware(cacheControl string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return next(c)
+			}
+
+			res := c.Response()
+			w := &bufferWriter{ResponseWriter: res.Writer}
+			res.Writer = w
+			err := next(c)
+			res.Writer = w.ResponseWriter
+
+			// errors and responses never written are left to the error handler
+			if w.status == 0 {
+				return err
+			}
+			if w.status != http.StatusOK || err != nil {
+				w.ResponseWriter.WriteHeader(w.status)
+				if _, writeErr := w.ResponseWriter.Write(w.buf.Bytes()); writeErr != nil {
+					return writeErr
+				}
+				return err
+			}
+
+			header := res.Header()
+			if header.Get("ETag") == "" {
+				sum := sha256.Sum256(w.buf.Bytes())
+				header.Set("ETag", "\""+hex.EncodeToString(sum[:16])+"\"")
+			}
+			if header.Get("Cache-Control") == "" {
+				header.Set("Cache-Control", cacheControl)
+			}
+
+			if notModified(req, header) {
+				header.Del(echo.HeaderContentType)
+				header.Del(echo.HeaderContentLength)
+				res.Status = http.StatusNotModified
+				w.ResponseWriter.WriteHeader(http.StatusNotModified)
+				return nil
+			}
+
+			w.ResponseWriter.WriteHeader(http.StatusOK)
+			_, err = w.ResponseWriter.Write(w.buf.Bytes())
+			return err
+		}
+	}
+}
+
+// notModified evaluate the conditional headers of a request against the validators of its response,
+// If-None-Match take precedence over If-Modified-Since
+func notModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, header.Get("ETag"))
+	}
+
+	ims, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(header.Get(echo.HeaderLastModified))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(ims)
+}
+
+// matchETag compare the tags of an If-None-Match header with the weak comparison
+func matchETag(inm, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(inm, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferWriter hold the status and the body of a response until its validators are computed
+type bufferWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(p)
+}
@@ -0,0 +1,136 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var modified = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// serve run the middleware over a handler answering status, with Last-Modified on the successful responses
+func serve(t *testing.T, method string, status int, headers map[string]string) *httptest.ResponseRecorder {
+	handler := Middleware("private, max-age=60")(func(c echo.Context) error {
+		if status == http.StatusOK {
+			SetLastModified(c, modified)
+		}
+		return c.JSON(status, map[string]string{"title": "Refactoring"})
+	})
+
+	req := httptest.NewRequest(method, "/books/1", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	if err := handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	etag := serve(t, http.MethodGet, http.StatusOK, nil).Header().Get("ETag")
+	if len(etag) != 34 || etag[0] != '"' {
+		t.Fatalf("got ETag %q, want a strong tag", etag)
+	}
+	lastModified := modified.Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"unconditional", nil, http.StatusOK},
+		{"etag match", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak etag match", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"etag in list", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{"any etag", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"etag mismatch", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"modified since", map[string]string{
+			"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat),
+		}, http.StatusOK},
+		{"invalid date", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"etag over date", map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": lastModified,
+		}, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(t, http.MethodGet, http.StatusOK, test.headers)
+			if rec.Code != test.status {
+				t.Fatalf("got status %d, want %d", rec.Code, test.status)
+			}
+			if got := rec.Header().Get("ETag"); got != etag {
+				t.Errorf("got ETag %q, want %q", got, etag)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "private, max-age=60" {
+				t.Errorf("got Cache-Control %q", got)
+			}
+			if got := rec.Header().Get(echo.HeaderLastModified); got != lastModified {
+				t.Errorf("got Last-Modified %q, want %q", got, lastModified)
+			}
+
+			if test.status == http.StatusNotModified {
+				if rec.Body.Len() != 0 || rec.Header().Get(echo.HeaderContentType) != "" {
+					t.Errorf("got a body %q or a content type on a 304", rec.Body.String())
+				}
+			} else if rec.Body.Len() == 0 {
+				t.Error("got no body")
+			}
+		})
+	}
+}
+
+func TestMiddlewareSkip(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		status int
+	}{
+		{"not found", http.MethodGet, http.StatusNotFound},
+		{"created", http.MethodPost, http.StatusCreated},
+		{"post", http.MethodPost, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(t, test.method, test.status, map[string]string{"If-None-Match": "*"})
+			if rec.Code != test.status {
+				t.Errorf("got status %d, want %d", rec.Code, test.status)
+			}
+			if rec.Header().Get("ETag") != "" || rec.Header().Get("Cache-Control") != "" {
+				t.Errorf("got the validators of a cached response: %v", rec.Header())
+			}
+			if rec.Body.Len() == 0 {
+				t.Error("got no body")
+			}
+		})
+	}
+}
+
+func TestMiddlewareKeepHandlerHeaders(t *testing.T) {
+	handler := Middleware(DefaultCacheControl)(func(c echo.Context) error {
+		c.Response().Header().Set("ETag", `W/"v2"`)
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.String(http.StatusOK, "body")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"v2"`)
+	rec := httptest.NewRecorder()
+	if err := handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("got status %d, want the weak tag of the handler matched", rec.Code)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("got Cache-Control %q, want the one of the handler", got)
+	}
+}
@@ -0,0 +1,94 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// CORSConfig is the cross-origin policy of the API, cross-origin requests are refused when AllowOrigins is empty
+type CORSConfig struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	AllowCredentials bool
+	ExposeHeaders    []string
+	// MaxAge let browsers cache the preflight responses
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig allow the methods and headers of the API once origins are configured
+var DefaultCORSConfig = CORSConfig{
+	AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete},
+	AllowHeaders: []string{echo.HeaderAuthorization, echo.HeaderContentType, echo.HeaderAcceptEncoding,
+		"Accept-Language", "API-Version", "Idempotency-Key", "If-None-Match", "If-Modified-Since",
+		echo.HeaderXRequestID},
+	ExposeHeaders: []string{"API-Version", "Deprecation", "Sunset", "Link", "ETag", "Last-Modified",
+		"Idempotent-Replayed", "Retry-After", echo.HeaderXRequestID, echo.HeaderLocation},
+	MaxAge: 10 * time.Minute,
+}
+
+// CORSConfigFromEnv override DefaultCORSConfig with CORS_ALLOW_ORIGINS, CORS_ALLOW_METHODS, CORS_ALLOW_HEADERS,
+// CORS_ALLOW_CREDENTIALS, CORS_EXPOSE_HEADERS and CORS_MAX_AGE
+func CORSConfigFromEnv() (CORSConfig, error) {
+	config := DefaultCORSConfig
+	config.AllowOrigins = list(os.Getenv("CORS_ALLOW_ORIGINS"))
+	if v := list(os.Getenv("CORS_ALLOW_METHODS")); v != nil {
+		config.AllowMethods = v
+	}
+	if v := list(os.Getenv("CORS_ALLOW_HEADERS")); v != nil {
+		config.AllowHeaders = v
+	}
+	if v := list(os.Getenv("CORS_EXPOSE_HEADERS")); v != nil {
+		config.ExposeHeaders = v
+	}
+	config.AllowCredentials, _ = strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS"))
+	if v, err := time.ParseDuration(os.Getenv("CORS_MAX_AGE")); err == nil && v >= 0 {
+		config.MaxAge = v
+	}
+
+	if config.AllowCredentials {
+		for _, origin := range config.AllowOrigins {
+			if origin == "*" {
+				return config, errors.New("httpserver: CORS_ALLOW_CREDENTIALS require explicit CORS_ALLOW_ORIGINS")
+			}
+		}
+	}
+	return config, nil
+}
+
+//CORS func answer the preflight requests and allow the cross-origin requests of the policy, it must run before
+//the middlewares rejecting the requests without tenant as preflight requests carry no credentials
+func CORS(config CORSConfig) echo.MiddlewareFunc {
+	if len(config.AllowOrigins) == 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     config.AllowOrigins,
+		AllowMethods:     config.AllowMethods,
+		AllowHeaders:     config.AllowHeaders,
+		AllowCredentials: config.AllowCredentials,
+		ExposeHeaders:    config.ExposeHeaders,
+		MaxAge:           int(config.MaxAge / time.Second),
+	})
+}
+
+// list split a comma separated variable, nil when empty
+func list(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCORSConfigFromEnv(t *testing.T) {
+	setenv(t, map[string]string{
+		"CORS_ALLOW_ORIGINS":     " https://app.example.com, ,https://admin.example.com",
+		"CORS_ALLOW_METHODS":     "GET",
+		"CORS_ALLOW_CREDENTIALS": "maybe",
+		"CORS_MAX_AGE":           "an hour",
+	})
+
+	config, err := CORSConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultCORSConfig
+	want.AllowOrigins = []string{"https://app.example.com", "https://admin.example.com"}
+	want.AllowMethods = []string{http.MethodGet}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got %+v, want %+v with the invalid values ignored", config, want)
+	}
+}
+
+func TestCORSConfigFromEnvCredentials(t *testing.T) {
+	setenv(t, map[string]string{"CORS_ALLOW_ORIGINS": "*", "CORS_ALLOW_CREDENTIALS": "true"})
+	if _, err := CORSConfigFromEnv(); err == nil {
+		t.Error("got no error allowing the credentials of any origin")
+	}
+
+	setenv(t, map[string]string{"CORS_ALLOW_ORIGINS": "https://app.example.com", "CORS_MAX_AGE": "1h"})
+	config, err := CORSConfigFromEnv()
+	if err != nil || !config.AllowCredentials || config.MaxAge != time.Hour {
+		t.Errorf("got %+v, %v, want the credentials of an explicit origin", config, err)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	config := DefaultCORSConfig
+	config.AllowOrigins = []string{"https://app.example.com"}
+
+	tests := []struct {
+		name    string
+		config  CORSConfig
+		method  string
+		origin  string
+		status  int
+		allowed string
+	}{
+		{"preflight", config, http.MethodOptions, "https://app.example.com", http.StatusNoContent,
+			"https://app.example.com"},
+		{"preflight of another origin", config, http.MethodOptions, "https://evil.example.com",
+			http.StatusNoContent, ""},
+		{"request", config, http.MethodGet, "https://app.example.com", http.StatusOK, "https://app.example.com"},
+		{"no origins", DefaultCORSConfig, http.MethodOptions, "https://app.example.com",
+			http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := echo.New()
+			s.Use(CORS(test.config))
+			// the preflight requests have no credentials, they must not reach the handler
+			s.GET("/books", func(c echo.Context) error {
+				if c.Request().Method == http.MethodOptions {
+					t.Error("the preflight request reached the handler")
+				}
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(test.method, "/books", nil)
+			req.Header.Set(echo.HeaderOrigin, test.origin)
+			req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodGet)
+			req.Header.Set(echo.HeaderAccessControlRequestHeaders, "Authorization, Idempotency-Key")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Errorf("got status %d, want %d", rec.Code, test.status)
+			}
+			if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != test.allowed {
+				t.Errorf("got Access-Control-Allow-Origin %q, want %q", got, test.allowed)
+			}
+			if test.allowed == "" {
+				return
+			}
+			if test.method == http.MethodOptions {
+				if got := rec.Header().Get(echo.HeaderAccessControlMaxAge); got != "600" {
+					t.Errorf("got Access-Control-Max-Age %q, want 600", got)
+				}
+				if got := rec.Header().Get(echo.HeaderAccessControlAllowHeaders); got == "" {
+					t.Error("got no Access-Control-Allow-Headers")
+				}
+			} else if got := rec.Header().Get(echo.HeaderAccessControlExposeHeaders); got == "" {
+				t.Error("got no Access-Control-Expose-Headers")
+			}
+		})
+	}
+}
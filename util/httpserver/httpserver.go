@@ -0,0 +1,125 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Config of the HTTP server of the API
+type Config struct {
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout bound the reading of a whole request, uploads included
+	ReadTimeout time.Duration
+	// WriteTimeout bound a whole response, the event streams are cut after it and resume on reconnect
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	MaxHeaderBytes int
+	// BodyLimit is the largest request body in bytes, unlimited when 0
+	BodyLimit int64
+	// BodyLimits override BodyLimit by route path, the paths include the version like /v1/book/import
+	BodyLimits map[string]int64
+}
+
+// DefaultConfig bound the requests to 1MB, but the book imports and covers
+var DefaultConfig = Config{
+	ReadHeaderTimeout: 10 * time.Second,
+	ReadTimeout:       5 * time.Minute,
+	WriteTimeout:      10 * time.Minute,
+	IdleTimeout:       2 * time.Minute,
+	MaxHeaderBytes:    64 << 10,
+	BodyLimit:         1 << 20,
+	BodyLimits: map[string]int64{
+		"/v1/book/import":    64 << 20,
+		"/v1/book/:id/cover": 8 << 20,
+	},
+}
+
+// errorResponse is the body of a rejected request
+type errorResponse struct {
+	Status  int64  `json:"status"`
+	Message string `json:"message"`
+}
+
+// ConfigFromEnv override DefaultConfig with HTTP_READ_HEADER_TIMEOUT, HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT,
+// HTTP_IDLE_TIMEOUT, HTTP_MAX_HEADER_BYTES, HTTP_BODY_LIMIT and HTTP_BODY_LIMITS as path=bytes pairs
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	durations := map[string]*time.Duration{
+		"HTTP_READ_HEADER_TIMEOUT": &config.ReadHeaderTimeout,
+		"HTTP_READ_TIMEOUT":        &config.ReadTimeout,
+		"HTTP_WRITE_TIMEOUT":       &config.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        &config.IdleTimeout,
+	}
+	for key, duration := range durations {
+		if v, err := time.ParseDuration(os.Getenv(key)); err == nil && v >= 0 {
+			*duration = v
+		}
+	}
+	if v, err := strconv.Atoi(os.Getenv("HTTP_MAX_HEADER_BYTES")); err == nil && v > 0 {
+		config.MaxHeaderBytes = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("HTTP_BODY_LIMIT"), 10, 64); err == nil && v >= 0 {
+		config.BodyLimit = v
+	}
+
+	if v := os.Getenv("HTTP_BODY_LIMITS"); v != "" {
+		config.BodyLimits = make(map[string]int64)
+		for _, pair := range strings.Split(v, ",") {
+			parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(parts) != 2 {
+				return config, fmt.Errorf("httpserver: invalid HTTP_BODY_LIMITS pair %q", pair)
+			}
+			limit, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil || limit < 0 {
+				return config, fmt.Errorf("httpserver: invalid HTTP_BODY_LIMITS size %q", parts[1])
+			}
+			config.BodyLimits[parts[0]] = limit
+		}
+	}
+	return config, nil
+}
+
+// Server return an http.Server listening on addr with the timeouts of the config
+func (c Config) Server(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		ReadHeaderTimeout: c.ReadHeaderTimeout,
+		ReadTimeout:       c.ReadTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+		MaxHeaderBytes:    c.MaxHeaderBytes,
+	}
+}
+
+//BodyLimit func answer 413 to the requests whose body is larger than the limit of their route
+func BodyLimit(config Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			limit := config.BodyLimit
+			if v, ok := config.BodyLimits[c.Path()]; ok {
+				limit = v
+			}
+			if limit <= 0 {
+				return next(c)
+			}
+
+			req := c.Request()
+			if req.ContentLength > limit {
+				return c.JSON(http.StatusRequestEntityTooLarge, &errorResponse{
+					Status:  http.StatusRequestEntityTooLarge,
+					Message: fmt.Sprintf("request body larger than %d bytes", limit),
+				})
+			}
+
+			// a chunked body fail to read past the limit
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package httpserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// setenv set the variables for the test
+func setenv(t *testing.T, env map[string]string) {
+	for key, value := range env {
+		os.Setenv(key, value)
+		key := key
+		t.Cleanup(func() { os.Unsetenv(key) })
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	setenv(t, map[string]string{
+		"HTTP_READ_TIMEOUT":     "30s",
+		"HTTP_WRITE_TIMEOUT":    "soon",
+		"HTTP_IDLE_TIMEOUT":     "-1s",
+		"HTTP_MAX_HEADER_BYTES": "0",
+		"HTTP_BODY_LIMIT":       "2048",
+		"HTTP_BODY_LIMITS":      "/v1/book/import=4096, /v1/book/:id/cover=0",
+	})
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig
+	want.ReadTimeout = 30 * time.Second
+	want.BodyLimit = 2048
+	want.BodyLimits = map[string]int64{"/v1/book/import": 4096, "/v1/book/:id/cover": 0}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got %+v, want %+v with the invalid values ignored", config, want)
+	}
+}
+
+func TestConfigFromEnvInvalidBodyLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{"missing size", "/v1/book/import", "invalid HTTP_BODY_LIMITS pair"},
+		{"not a size", "/v1/book/import=64MB", "invalid HTTP_BODY_LIMITS size"},
+		{"negative size", "/v1/book/import=-1", "invalid HTTP_BODY_LIMITS size"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setenv(t, map[string]string{"HTTP_BODY_LIMITS": test.value})
+			if _, err := ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	config := Config{BodyLimit: 8, BodyLimits: map[string]int64{"/import": 16, "/stream": 0}}
+	s := echo.New()
+	s.Use(BodyLimit(config))
+	// the body read past the limit of a chunked request fail
+	read := func(c echo.Context) error {
+		body, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return c.String(http.StatusRequestEntityTooLarge, err.Error())
+		}
+		return c.String(http.StatusOK, string(body))
+	}
+	s.POST("/books", read)
+	s.POST("/import", read)
+	s.POST("/stream", read)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"under the limit", "/books", "12345678", false, http.StatusOK},
+		{"over the limit", "/books", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"chunked over the limit", "/books", "123456789", true, http.StatusRequestEntityTooLarge},
+		{"route limit", "/import", "123456789", false, http.StatusOK},
+		{"over the route limit", "/import", strings.Repeat("1", 17), false, http.StatusRequestEntityTooLarge},
+		{"unlimited route", "/stream", strings.Repeat("1", 64), false, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body))
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != test.status {
+				t.Errorf("got status %d, want %d: %s", rec.Code, test.status, rec.Body.String())
+			}
+		})
+	}
+}
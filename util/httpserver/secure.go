@@ -0,0 +1,131 @@
+package httpserver
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SecureConfig is the security headers of the responses, an empty value omit its header
+type SecureConfig struct {
+	// HSTSMaxAge is sent on the HTTPS responses only, HSTS is off when 0
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	ContentSecurityPolicy string
+	// DocsContentSecurityPolicy replace ContentSecurityPolicy on the docs pages, they run scripts and styles
+	DocsContentSecurityPolicy string
+	ContentTypeNosniff        bool
+	FrameOptions              string
+	ReferrerPolicy            string
+}
+
+// SecurePresets are the security headers of each APP_ENV. The development preset has no HSTS nor CSP so the
+// API can be served over plain HTTP and the GraphiQL playground load its scripts
+var SecurePresets = map[string]SecureConfig{
+	"development": {
+		ContentTypeNosniff: true,
+		FrameOptions:       "SAMEORIGIN",
+		ReferrerPolicy:     "strict-origin-when-cross-origin",
+	},
+	"production": {
+		HSTSMaxAge:                365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:     true,
+		ContentSecurityPolicy:     "default-src 'none'; frame-ancestors 'none'",
+		DocsContentSecurityPolicy: "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'",
+		ContentTypeNosniff:        true,
+		FrameOptions:              "DENY",
+		ReferrerPolicy:            "no-referrer",
+	},
+}
+
+// DefaultSecurePreset apply to the environments without preset
+const DefaultSecurePreset = "production"
+
+// SecureConfigFromEnv return the preset of SECURE_HEADERS_PRESET or APP_ENV, overridden with SECURE_HSTS_MAX_AGE,
+// SECURE_HSTS_INCLUDE_SUBDOMAINS, SECURE_HSTS_PRELOAD, SECURE_CSP, SECURE_DOCS_CSP, SECURE_NOSNIFF,
+// SECURE_FRAME_OPTIONS and SECURE_REFERRER_POLICY
+func SecureConfigFromEnv() SecureConfig {
+	name := os.Getenv("SECURE_HEADERS_PRESET")
+	if name == "" {
+		name = os.Getenv("APP_ENV")
+	}
+	config, ok := SecurePresets[name]
+	if !ok {
+		config = SecurePresets[DefaultSecurePreset]
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("SECURE_HSTS_MAX_AGE")); err == nil && v >= 0 {
+		config.HSTSMaxAge = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("SECURE_HSTS_INCLUDE_SUBDOMAINS")); err == nil {
+		config.HSTSIncludeSubdomains = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("SECURE_HSTS_PRELOAD")); err == nil {
+		config.HSTSPreload = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("SECURE_NOSNIFF")); err == nil {
+		config.ContentTypeNosniff = v
+	}
+	// the headers can be removed with an empty value
+	values := map[string]*string{
+		"SECURE_CSP":             &config.ContentSecurityPolicy,
+		"SECURE_DOCS_CSP":        &config.DocsContentSecurityPolicy,
+		"SECURE_FRAME_OPTIONS":   &config.FrameOptions,
+		"SECURE_REFERRER_POLICY": &config.ReferrerPolicy,
+	}
+	for key, value := range values {
+		if v, ok := os.LookupEnv(key); ok {
+			*value = v
+		}
+	}
+	return config
+}
+
+//Secure func set the security headers of config on every response, the paths having one of the docs prefixes
+//get the DocsContentSecurityPolicy
+func Secure(config SecureConfig, docs ...string) echo.MiddlewareFunc {
+	hsts := ""
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(config.HSTSMaxAge/time.Second), 10)
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			if hsts != "" && (c.IsTLS() || c.Request().Header.Get(echo.HeaderXForwardedProto) == "https") {
+				header.Set(echo.HeaderStrictTransportSecurity, hsts)
+			}
+
+			csp := config.ContentSecurityPolicy
+			for _, prefix := range docs {
+				if strings.HasPrefix(c.Request().URL.Path, prefix) {
+					csp = config.DocsContentSecurityPolicy
+					break
+				}
+			}
+			if csp != "" {
+				header.Set(echo.HeaderContentSecurityPolicy, csp)
+			}
+			if config.ContentTypeNosniff {
+				header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+			}
+			if config.FrameOptions != "" {
+				header.Set(echo.HeaderXFrameOptions, config.FrameOptions)
+			}
+			if config.ReferrerPolicy != "" {
+				header.Set("Referrer-Policy", config.ReferrerPolicy)
+			}
+			return next(c)
+		}
+	}
+}
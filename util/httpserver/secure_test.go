@@ -0,0 +1,88 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestSecure(t *testing.T) {
+	production := SecurePresets["production"]
+	production.HSTSPreload = true
+
+	tests := []struct {
+		name    string
+		config  SecureConfig
+		path    string
+		proto   string
+		headers map[string]string
+	}{
+		{"production", production, "/v1/book", "https", map[string]string{
+			echo.HeaderStrictTransportSecurity: "max-age=31536000; includeSubDomains; preload",
+			echo.HeaderContentSecurityPolicy:   production.ContentSecurityPolicy,
+			echo.HeaderXContentTypeOptions:     "nosniff",
+			echo.HeaderXFrameOptions:           "DENY",
+			"Referrer-Policy":                  "no-referrer",
+		}},
+		{"plain http", production, "/v1/book", "", map[string]string{
+			echo.HeaderStrictTransportSecurity: "",
+			echo.HeaderContentSecurityPolicy:   production.ContentSecurityPolicy,
+		}},
+		{"docs", production, "/swagger/index.html", "https", map[string]string{
+			echo.HeaderContentSecurityPolicy: production.DocsContentSecurityPolicy,
+		}},
+		{"development", SecurePresets["development"], "/v1/book", "https", map[string]string{
+			echo.HeaderStrictTransportSecurity: "",
+			echo.HeaderContentSecurityPolicy:   "",
+			echo.HeaderXContentTypeOptions:     "nosniff",
+			echo.HeaderXFrameOptions:           "SAMEORIGIN",
+			"Referrer-Policy":                  "strict-origin-when-cross-origin",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Secure(test.config, "/swagger")(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set(echo.HeaderXForwardedProto, test.proto)
+			rec := httptest.NewRecorder()
+			if err := handler(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, want := range test.headers {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("got %s %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecureConfigFromEnv(t *testing.T) {
+	setenv(t, map[string]string{
+		"APP_ENV":              "development",
+		"SECURE_HSTS_MAX_AGE":  "1h",
+		"SECURE_NOSNIFF":       "perhaps",
+		"SECURE_FRAME_OPTIONS": "",
+	})
+
+	config := SecureConfigFromEnv()
+	want := SecurePresets["development"]
+	want.HSTSMaxAge = time.Hour
+	want.FrameOptions = ""
+	if config != want {
+		t.Errorf("got %+v, want %+v", config, want)
+	}
+
+	// an unknown environment get the default preset
+	setenv(t, map[string]string{"APP_ENV": "staging", "SECURE_HSTS_MAX_AGE": "forever"})
+	if config := SecureConfigFromEnv(); config.HSTSMaxAge != SecurePresets[DefaultSecurePreset].HSTSMaxAge {
+		t.Errorf("got HSTS max age %v, want the one of the default preset", config.HSTSMaxAge)
+	}
+}
@@ -0,0 +1,95 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Headers of the idempotent requests
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	// MaxKeyLength is the longest accepted key
+	MaxKeyLength = 255
+)
+
+// ErrNotClaimed is returned when a key is completed or released by a request not owning it
+var ErrNotClaimed = errors.New("idempotency: key not claimed")
+
+type (
+	// Config of the idempotent requests
+	Config struct {
+		// Methods are the request methods honoring the Idempotency-Key header
+		Methods []string
+		// TTL is the time a key is remembered after its first use
+		TTL time.Duration
+		// LockTimeout release the keys of the requests which never completed, like a crashed instance
+		LockTimeout time.Duration
+		// PurgeInterval is the period of the deletion of the expired keys
+		PurgeInterval time.Duration
+	}
+
+	// Response is the stored response of a completed request
+	Response struct {
+		Status int
+		Header http.Header
+		Body   []byte
+	}
+
+	// Record is the state of a key claimed by an earlier request
+	Record struct {
+		Fingerprint string
+		// Response is nil while the first request is in flight
+		Response *Response
+	}
+
+	// Store remember the keys of a tenant until they expire
+	Store interface {
+		// Claim lock a new or expired key for the request fingerprint, the record of the key is
+		// returned instead when an earlier request holds it
+		Claim(ctx context.Context, tenantID, key, fingerprint string) (*Record, error)
+		// Complete store the response of the claimed key
+		Complete(ctx context.Context, tenantID, key, fingerprint string, response *Response) error
+		// Release forget the claimed key, the request can be retried
+		Release(ctx context.Context, tenantID, key, fingerprint string) error
+		// Purge delete the expired keys
+		Purge(ctx context.Context) (int64, error)
+	}
+)
+
+// DefaultConfig remember the POST requests for a day
+var DefaultConfig = Config{
+	Methods:       []string{http.MethodPost},
+	TTL:           24 * time.Hour,
+	LockTimeout:   5 * time.Minute,
+	PurgeInterval: time.Hour,
+}
+
+// ConfigFromEnv override DefaultConfig with IDEMPOTENCY_METHODS, IDEMPOTENCY_TTL, IDEMPOTENCY_LOCK_TIMEOUT
+// and IDEMPOTENCY_PURGE_INTERVAL
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if v := os.Getenv("IDEMPOTENCY_METHODS"); v != "" {
+		config.Methods = nil
+		for _, method := range strings.Split(v, ",") {
+			if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+				config.Methods = append(config.Methods, method)
+			}
+		}
+	}
+	if v, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL")); err == nil && v > 0 {
+		config.TTL = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_LOCK_TIMEOUT")); err == nil && v > 0 {
+		config.LockTimeout = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_PURGE_INTERVAL")); err == nil && v > 0 {
+		config.PurgeInterval = v
+	}
+	return config
+}
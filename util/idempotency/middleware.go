@@ -0,0 +1,215 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/compress"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/labstack/echo/v4"
+)
+
+// maxMemory is the largest request body kept in memory, larger bodies like imports are spooled to a file
+const maxMemory = 1 << 20
+
+// excludedHeaders belong to the response of one request and are not replayed. The body is recorded before
+// the compression, the replay is compressed again for the encodings its request accept
+var excludedHeaders = map[string]bool{
+	util.HeaderRequestID:       true,
+	echo.HeaderContentLength:   true,
+	echo.HeaderContentEncoding: true,
+	echo.HeaderVary:            true,
+	"Date":                     true,
+	HeaderReplayed:             true,
+}
+
+// errorResponse is the body of a rejected request
+type errorResponse struct {
+	Status  int64  `json:"status"`
+	Message string `json:"message"`
+}
+
+//Middleware func let the clients retry the requests having an Idempotency-Key header. The first request
+//is processed and its response replayed to the repeats of the key until it expires, a repeat with another
+//method, path or body is rejected with 409 and a repeat of a request still in flight with 425
+func Middleware(store Store, config Config) echo.MiddlewareFunc {
+	methods := make(map[string]bool, len(config.Methods))
+	for _, method := range config.Methods {
+		methods[method] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderKey)
+			if key == "" || !methods[req.Method] {
+				return next(c)
+			}
+			if len(key) > MaxKeyLength {
+				return reject(c, http.StatusBadRequest, "Idempotency-Key is longer than 255 characters")
+			}
+
+			ctx := req.Context()
+			logger := util.SessionLogger(ctx)
+			tenantID, err := tenant.Require(ctx)
+			if err != nil {
+				return reject(c, http.StatusBadRequest, err.Error())
+			}
+
+			body, fingerprint, err := spool(req)
+			if err != nil {
+				logger.Errorf("idempotency: read body: %v", err)
+				return reject(c, http.StatusBadRequest, "failed to read the request body")
+			}
+			defer body.Close()
+			req.Body = body
+
+			record, err := store.Claim(ctx, tenantID, key, fingerprint)
+			if err != nil {
+				logger.Errorf("idempotency: claim %q: %v", key, err)
+				return reject(c, http.StatusInternalServerError, "failed")
+			}
+			if record != nil {
+				switch {
+				case record.Fingerprint != fingerprint:
+					return reject(c, http.StatusConflict, "Idempotency-Key was used by another request")
+				case record.Response == nil:
+					c.Response().Header().Set("Retry-After", "1")
+					return reject(c, http.StatusTooEarly, "a request with this Idempotency-Key is in progress")
+				}
+				return replay(c, record.Response)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			// the error is rendered here so its response is recorded
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			// the outcome is stored even when the client went away, its retry is replayed
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError {
+				// server errors are not final, the request may succeed when retried
+				err = store.Release(context.Background(), tenantID, key, fingerprint)
+			} else {
+				err = store.Complete(context.Background(), tenantID, key, fingerprint, &Response{
+					Status: status,
+					Header: replayedHeader(c.Response().Header()),
+					Body:   recorder.body.Bytes(),
+				})
+			}
+			if err != nil {
+				logger.Errorf("idempotency: store %q: %v", key, err)
+			}
+
+			return nil
+		}
+	}
+}
+
+func reject(c echo.Context, status int, message string) error {
+	return c.JSON(status, &errorResponse{Status: int64(status), Message: message})
+}
+
+// replay write a stored response
+func replay(c echo.Context, response *Response) error {
+	header := c.Response().Header()
+	for name, values := range response.Header {
+		header[name] = values
+	}
+	header.Set(HeaderReplayed, "true")
+
+	c.Response().WriteHeader(response.Status)
+	_, err := c.Response().Write(response.Body)
+	return err
+}
+
+func replayedHeader(header http.Header) http.Header {
+	replayed := make(http.Header, len(header))
+	for name, values := range header {
+		if !excludedHeaders[name] {
+			replayed[name] = values
+		}
+	}
+	if etag := replayed.Get("ETag"); etag != "" {
+		replayed.Set("ETag", compress.PlainETag(etag))
+	}
+	return replayed
+}
+
+// spool read the request body to fingerprint it, the returned body reads it again
+func spool(req *http.Request) (io.ReadCloser, string, error) {
+	fingerprint := sha256.New()
+	io.WriteString(fingerprint, req.Method+" "+req.URL.RequestURI()+"\n")
+	if req.Body == nil {
+		return ioutil.NopCloser(bytes.NewReader(nil)), hex.EncodeToString(fingerprint.Sum(nil)), nil
+	}
+
+	var buf bytes.Buffer
+	_, err := io.Copy(io.MultiWriter(&buf, fingerprint), io.LimitReader(req.Body, maxMemory+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if buf.Len() <= maxMemory {
+		return ioutil.NopCloser(&buf), hex.EncodeToString(fingerprint.Sum(nil)), nil
+	}
+
+	file, err := spoolFile(&buf, req.Body, fingerprint)
+	if err != nil {
+		return nil, "", err
+	}
+	return file, hex.EncodeToString(fingerprint.Sum(nil)), nil
+}
+
+// spoolFile copy the head read in memory and the rest of body to a temporary file removed on Close
+func spoolFile(head io.Reader, body io.Reader, fingerprint hash.Hash) (io.ReadCloser, error) {
+	file, err := ioutil.TempFile("", "idempotency-")
+	if err != nil {
+		return nil, err
+	}
+	spooled := &tempFile{file}
+
+	_, err = io.Copy(file, head)
+	if err == nil {
+		_, err = io.Copy(io.MultiWriter(file, fingerprint), body)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	return spooled, nil
+}
+
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// responseRecorder keep a copy of the response body
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,328 @@
+package idempotency
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/compress"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryStore keep the records in memory, the keys never expire
+type memoryStore struct {
+	sync.Mutex
+	records map[string]*Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]*Record)}
+}
+
+func (s *memoryStore) Claim(ctx context.Context, tenantID, key, fingerprint string) (*Record, error) {
+	s.Lock()
+	defer s.Unlock()
+	if record, ok := s.records[tenantID+"/"+key]; ok {
+		copied := *record
+		return &copied, nil
+	}
+	s.records[tenantID+"/"+key] = &Record{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, tenantID, key, fingerprint string, response *Response) error {
+	s.Lock()
+	defer s.Unlock()
+	record, ok := s.records[tenantID+"/"+key]
+	if !ok || record.Fingerprint != fingerprint || record.Response != nil {
+		return ErrNotClaimed
+	}
+	record.Response = response
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, tenantID, key, fingerprint string) error {
+	s.Lock()
+	defer s.Unlock()
+	if record, ok := s.records[tenantID+"/"+key]; ok && record.Fingerprint == fingerprint && record.Response == nil {
+		delete(s.records, tenantID+"/"+key)
+	}
+	return nil
+}
+
+func (s *memoryStore) Purge(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// server count the processed requests, the handler of /slow wait for release
+type server struct {
+	*echo.Echo
+	mu      sync.Mutex
+	calls   int
+	status  int
+	started chan bool
+	release chan bool
+}
+
+func newServer() *server {
+	util.Log = logrus.New()
+
+	s := &server{Echo: echo.New(), status: http.StatusCreated}
+	s.Use(withTenant)
+	s.Use(Middleware(newMemoryStore(), DefaultConfig))
+	handler := func(c echo.Context) error {
+		body, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.calls++
+		calls, status := s.calls, s.status
+		s.mu.Unlock()
+		c.Response().Header().Set("X-Calls", strings.Repeat("+", calls))
+		return c.String(status, string(body))
+	}
+	s.POST("/books", handler)
+	s.GET("/books", handler)
+	s.POST("/slow", func(c echo.Context) error {
+		s.started <- true
+		<-s.release
+		return handler(c)
+	})
+	return s
+}
+
+// withTenant scope the request to the tenant of its header
+func withTenant(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if id := c.Request().Header.Get(tenant.DefaultHeader); id != "" {
+			c.SetRequest(c.Request().WithContext(tenant.With(c.Request().Context(), id)))
+		}
+		return next(c)
+	}
+}
+
+func (s *server) serve(method, path, tenantID, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if tenantID != "" {
+		req.Header.Set(tenant.DefaultHeader, tenantID)
+	}
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareReplay(t *testing.T) {
+	s := newServer()
+
+	first := s.serve(http.MethodPost, "/books", "acme", "key-1", `{"title":"Dune"}`)
+	if first.Code != http.StatusCreated || first.Header().Get(HeaderReplayed) != "" {
+		t.Fatalf("got %d, want the first request processed", first.Code)
+	}
+
+	repeat := s.serve(http.MethodPost, "/books", "acme", "key-1", `{"title":"Dune"}`)
+	if repeat.Code != http.StatusCreated || repeat.Header().Get(HeaderReplayed) != "true" {
+		t.Errorf("got %d, want the response replayed", repeat.Code)
+	}
+	if repeat.Body.String() != first.Body.String() || repeat.Header().Get("X-Calls") != "+" {
+		t.Errorf("got %q %q, want the first response", repeat.Body.String(), repeat.Header().Get("X-Calls"))
+	}
+	if s.calls != 1 {
+		t.Errorf("got %d calls, want the repeat not processed", s.calls)
+	}
+
+	// the keys are scoped to the tenant and the configured methods
+	s.serve(http.MethodPost, "/books", "globex", "key-1", `{"title":"Dune"}`)
+	s.serve(http.MethodGet, "/books", "acme", "key-1", "")
+	s.serve(http.MethodGet, "/books", "acme", "key-1", "")
+	if s.calls != 4 {
+		t.Errorf("got %d calls, want every other request processed", s.calls)
+	}
+}
+
+func TestMiddlewareReplayCompressed(t *testing.T) {
+	util.Log = logrus.New()
+
+	// the compression run before the recording like in the server
+	e := echo.New()
+	e.Use(compress.Middleware(compress.DefaultConfig))
+	e.Use(withTenant)
+	e.Use(Middleware(newMemoryStore(), DefaultConfig))
+	body := `{"d":"` + strings.Repeat("x", 2048) + `"}`
+	e.POST("/books", func(c echo.Context) error {
+		c.Response().Header().Set("ETag", `"v1"`)
+		return c.JSONBlob(http.StatusCreated, []byte(body))
+	})
+
+	serve := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(body))
+		req.Header.Set(tenant.DefaultHeader, "acme")
+		req.Header.Set(HeaderKey, "key-1")
+		if acceptEncoding != "" {
+			req.Header.Set(echo.HeaderAcceptEncoding, acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := serve(compress.EncodingGzip)
+	if first.Header().Get(echo.HeaderContentEncoding) != compress.EncodingGzip || first.Header().Get("ETag") != `"v1-gzip"` {
+		t.Fatalf("got %q %q, want the first response compressed", first.Header().Get(echo.HeaderContentEncoding),
+			first.Header().Get("ETag"))
+	}
+
+	plain := serve("")
+	if plain.Header().Get(HeaderReplayed) != "true" {
+		t.Fatal("the repeat is not replayed")
+	}
+	if encoding := plain.Header().Get(echo.HeaderContentEncoding); encoding != "" {
+		t.Errorf("got Content-Encoding %q, want the replay without encoding", encoding)
+	}
+	if plain.Body.String() != body || plain.Header().Get("ETag") != `"v1"` {
+		t.Errorf("got %d bytes tagged %q, want the plain body", plain.Body.Len(), plain.Header().Get("ETag"))
+	}
+
+	compressed := serve(compress.EncodingGzip)
+	if compressed.Header().Get(echo.HeaderContentEncoding) != compress.EncodingGzip ||
+		compressed.Header().Get("ETag") != `"v1-gzip"` {
+		t.Fatalf("got %q %q, want the replay compressed again", compressed.Header().Get(echo.HeaderContentEncoding),
+			compressed.Header().Get("ETag"))
+	}
+	r, err := gzip.NewReader(compressed.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := ioutil.ReadAll(r); err != nil || string(decoded) != body {
+		t.Errorf("got %d bytes, %v, want the body", len(decoded), err)
+	}
+	if vary := compressed.Header().Values(echo.HeaderVary); len(vary) != 1 {
+		t.Errorf("got Vary %q, want Accept-Encoding once", vary)
+	}
+}
+
+func TestMiddlewareConflict(t *testing.T) {
+	s := newServer()
+	s.serve(http.MethodPost, "/books", "acme", "key-1", `{"title":"Dune"}`)
+
+	for _, test := range []struct {
+		name string
+		path string
+		body string
+	}{
+		{"other body", "/books", `{"title":"Emma"}`},
+		{"other path", "/books?dry_run=true", `{"title":"Dune"}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rec := s.serve(http.MethodPost, test.path, "acme", "key-1", test.body)
+			if rec.Code != http.StatusConflict {
+				t.Errorf("got %d, want %d", rec.Code, http.StatusConflict)
+			}
+		})
+	}
+	if s.calls != 1 {
+		t.Errorf("got %d calls, want the conflicts not processed", s.calls)
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	s := newServer()
+	s.started = make(chan bool)
+	s.release = make(chan bool)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- s.serve(http.MethodPost, "/slow", "acme", "key-1", "x")
+	}()
+	<-s.started
+
+	rec := s.serve(http.MethodPost, "/slow", "acme", "key-1", "x")
+	if rec.Code != http.StatusTooEarly || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("got %d, want %d with Retry-After", rec.Code, http.StatusTooEarly)
+	}
+
+	close(s.release)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Errorf("got %d, want the first request processed", rec.Code)
+	}
+	if rec := s.serve(http.MethodPost, "/slow", "acme", "key-1", "x"); rec.Header().Get(HeaderReplayed) != "true" {
+		t.Errorf("got %d, want the completed request replayed", rec.Code)
+	}
+}
+
+func TestMiddlewareServerError(t *testing.T) {
+	s := newServer()
+	s.status = http.StatusServiceUnavailable
+	if rec := s.serve(http.MethodPost, "/books", "acme", "key-1", "x"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d", rec.Code)
+	}
+
+	// a server error is released and the retry processed
+	s.status = http.StatusCreated
+	rec := s.serve(http.MethodPost, "/books", "acme", "key-1", "x")
+	if rec.Code != http.StatusCreated || rec.Header().Get(HeaderReplayed) != "" || s.calls != 2 {
+		t.Errorf("got %d after %d calls, want the retry processed", rec.Code, s.calls)
+	}
+}
+
+func TestMiddlewareRejected(t *testing.T) {
+	s := newServer()
+
+	if rec := s.serve(http.MethodPost, "/books", "", "key-1", "x"); rec.Code != http.StatusBadRequest {
+		t.Errorf("got %d without tenant, want %d", rec.Code, http.StatusBadRequest)
+	}
+	key := strings.Repeat("k", MaxKeyLength+1)
+	if rec := s.serve(http.MethodPost, "/books", "acme", key, "x"); rec.Code != http.StatusBadRequest {
+		t.Errorf("got %d for a long key, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if s.calls != 0 {
+		t.Errorf("got %d calls, want the rejected requests not processed", s.calls)
+	}
+}
+
+func TestMiddlewareLargeBody(t *testing.T) {
+	s := newServer()
+	body := strings.Repeat("a", maxMemory) + strings.Repeat("b", 1024)
+
+	rec := s.serve(http.MethodPost, "/books", "acme", "key-1", body)
+	if rec.Code != http.StatusCreated || rec.Body.Len() != len(body) {
+		t.Fatalf("got %d with %d bytes, want the spooled body read whole", rec.Code, rec.Body.Len())
+	}
+	rec = s.serve(http.MethodPost, "/books", "acme", "key-1", body)
+	if rec.Header().Get(HeaderReplayed) != "true" {
+		t.Errorf("got %d, want the same large body replayed", rec.Code)
+	}
+	// the tail of a spooled body is fingerprinted
+	rec = s.serve(http.MethodPost, "/books", "acme", "key-1", strings.Repeat("a", maxMemory)+strings.Repeat("c", 1024))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d, want another large body rejected", rec.Code)
+	}
+}
+
+func TestSpool(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/books", bytes.NewReader([]byte("x")))
+	_, a, err := spool(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest(http.MethodPut, "/books", bytes.NewReader([]byte("x")))
+	_, b, err := spool(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("got the same fingerprint for another method")
+	}
+}
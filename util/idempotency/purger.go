@@ -0,0 +1,61 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+)
+
+// Purger delete the expired keys periodically
+type Purger struct {
+	store    Store
+	interval time.Duration
+	stop     chan bool
+	done     chan bool
+}
+
+// NewPurger return a Purger of store
+func NewPurger(store Store, interval time.Duration) *Purger {
+	return &Purger{
+		store:    store,
+		interval: interval,
+		stop:     make(chan bool),
+		done:     make(chan bool),
+	}
+}
+
+// Start purging in the background
+func (p *Purger) Start() error {
+	go p.run()
+	return nil
+}
+
+// Stop purging and wait for the purge in progress
+func (p *Purger) Stop() error {
+	p.stop <- true
+	<-p.done
+	return nil
+}
+
+func (p *Purger) run() {
+	defer close(p.done)
+
+	logger := util.Log.WithField("context", "idempotency")
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(p.interval):
+		}
+
+		count, err := p.store.Purge(context.Background())
+		if err != nil {
+			logger.Errorf("purge expired keys: %v", err)
+			continue
+		}
+		if count > 0 {
+			logger.Infof("purged %d expired keys", count)
+		}
+	}
+}
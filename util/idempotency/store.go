@@ -0,0 +1,145 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+// claimQuery insert the key, or take over an expired key or the stale lock of an unfinished request
+const claimQuery = `INSERT INTO idempotency_keys (tenant_id, idempotency_key, fingerprint, expires_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP + make_interval(secs => $4))
+ON CONFLICT (tenant_id, idempotency_key) DO UPDATE
+   SET fingerprint = EXCLUDED.fingerprint,
+       status = 'processing',
+       response_status = NULL,
+       response_header = NULL,
+       response_body = NULL,
+       locked_at = CURRENT_TIMESTAMP,
+       expires_at = EXCLUDED.expires_at,
+       created_at = CURRENT_TIMESTAMP
+ WHERE idempotency_keys.expires_at < CURRENT_TIMESTAMP
+    OR (idempotency_keys.status = 'processing'
+        AND idempotency_keys.locked_at < CURRENT_TIMESTAMP - make_interval(secs => $5))
+RETURNING fingerprint`
+
+const recordQuery = `SELECT fingerprint, status, response_status, response_header, response_body
+  FROM idempotency_keys
+ WHERE tenant_id = $1
+   AND idempotency_key = $2`
+
+const completeQuery = `UPDATE idempotency_keys
+   SET status = 'completed',
+       response_status = $4,
+       response_header = $5,
+       response_body = $6
+ WHERE tenant_id = $1
+   AND idempotency_key = $2
+   AND fingerprint = $3
+   AND status = 'processing'`
+
+const releaseQuery = `DELETE FROM idempotency_keys
+ WHERE tenant_id = $1
+   AND idempotency_key = $2
+   AND fingerprint = $3
+   AND status = 'processing'`
+
+const purgeQuery = `DELETE FROM idempotency_keys WHERE expires_at < CURRENT_TIMESTAMP`
+
+// DBStore keep the keys in the idempotency_keys table, shared by every instance
+type DBStore struct {
+	connection *sql.DB
+	config     Config
+}
+
+// NewStore return a Store of the idempotency_keys table
+func NewStore(connection *sql.DB, config Config) *DBStore {
+	return &DBStore{
+		connection: connection,
+		config:     config,
+	}
+}
+
+// Claim implement Store
+func (s *DBStore) Claim(ctx context.Context, tenantID, key, fingerprint string) (*Record, error) {
+	// the record of a key deleted between both queries is claimed again
+	for attempt := 0; attempt < 2; attempt++ {
+		var claimed string
+		err := s.connection.QueryRowContext(ctx, claimQuery, tenantID, key, fingerprint, s.config.TTL.Seconds(),
+			s.config.LockTimeout.Seconds()).Scan(&claimed)
+		if err == nil {
+			return nil, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		record, err := s.record(ctx, tenantID, key)
+		if err != sql.ErrNoRows {
+			return record, err
+		}
+	}
+
+	return nil, ErrNotClaimed
+}
+
+func (s *DBStore) record(ctx context.Context, tenantID, key string) (*Record, error) {
+	var record Record
+	var status string
+	var responseStatus sql.NullInt64
+	var header, body []byte
+	err := s.connection.QueryRowContext(ctx, recordQuery, tenantID, key).
+		Scan(&record.Fingerprint, &status, &responseStatus, &header, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "completed" {
+		record.Response = &Response{
+			Status: int(responseStatus.Int64),
+			Header: make(http.Header),
+			Body:   body,
+		}
+		if len(header) > 0 {
+			if err := json.Unmarshal(header, &record.Response.Header); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &record, nil
+}
+
+// Complete implement Store
+func (s *DBStore) Complete(ctx context.Context, tenantID, key, fingerprint string, response *Response) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.connection.ExecContext(ctx, completeQuery, tenantID, key, fingerprint, response.Status,
+		string(header), response.Body)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotClaimed
+	}
+	return nil
+}
+
+// Release implement Store
+func (s *DBStore) Release(ctx context.Context, tenantID, key, fingerprint string) error {
+	_, err := s.connection.ExecContext(ctx, releaseQuery, tenantID, key, fingerprint)
+	return err
+}
+
+// Purge implement Store
+func (s *DBStore) Purge(ctx context.Context) (int64, error) {
+	result, err := s.connection.ExecContext(ctx, purgeQuery)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
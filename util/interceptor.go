@@ -0,0 +1,141 @@
+package util
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Session metadata keys of gRPC requests, the lowercase session headers
+var (
+	MetadataRequestID = strings.ToLower(HeaderRequestID)
+	MetadataActor     = strings.ToLower(HeaderActor)
+)
+
+// sessionStream override the context of a server stream
+type sessionStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *sessionStream) Context() context.Context {
+	return s.ctx
+}
+
+//UnaryInterceptors func return the session, logger and recover interceptors, in the order of the echo middlewares
+func UnaryInterceptors() grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(UnarySessionInterceptor(), UnaryLoggerInterceptor(), UnaryRecoverInterceptor())
+}
+
+//StreamInterceptors func return the session, logger and recover interceptors of streams
+func StreamInterceptors() grpc.ServerOption {
+	return grpc.ChainStreamInterceptor(StreamSessionInterceptor(), StreamLoggerInterceptor(), StreamRecoverInterceptor())
+}
+
+//UnarySessionInterceptor func identify the request id and the claimed actor like SessionMiddleware, the call is
+//anonymous until an authentication interceptor sets its principal
+func UnarySessionInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = sessionContext(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, SessionCid(ctx)))
+
+		return handler(ctx, req)
+	}
+}
+
+//StreamSessionInterceptor func identify the actor and request id of a stream like SessionMiddleware
+func StreamSessionInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := sessionContext(ss.Context())
+		ss.SetHeader(metadata.Pairs(MetadataRequestID, SessionCid(ctx)))
+
+		return handler(srv, &sessionStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func sessionContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	session := NewSession(firstMetadata(md, MetadataRequestID), AnonymousActor)
+	session.ClaimedActor = firstMetadata(md, MetadataActor)
+
+	return context.WithValue(ctx, SessionKey, session)
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+//UnaryLoggerInterceptor func log every call with its status code and latency
+func UnaryLoggerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+//StreamLoggerInterceptor func log every stream with its status code and duration
+func StreamLoggerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	logger := SessionLogger(ctx).
+		WithField("method", method).
+		WithField("code", status.Code(err).String()).
+		WithField("latency", time.Since(start).String())
+
+	if err != nil {
+		logger.WithField("error", err.Error()).Warn("grpc call failed")
+		return
+	}
+	logger.Info("grpc call")
+}
+
+//UnaryRecoverInterceptor func turn a panic into an INTERNAL status like the echo Recover middleware
+func UnaryRecoverInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+//StreamRecoverInterceptor func turn a panic of a stream into an INTERNAL status
+func StreamRecoverInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), r)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, r interface{}) error {
+	SessionLogger(ctx).Errorf("[PANIC RECOVER] %v %s", r, debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
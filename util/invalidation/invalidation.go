@@ -0,0 +1,179 @@
+package invalidation
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/lib/pq"
+)
+
+// Channel carry the invalidated entity keys
+const Channel = "cache_invalidation"
+
+// maxPayload keep a notification under the 8000 bytes limit of postgres
+const maxPayload = 7000
+
+// pingInterval detect a dead connection while the channel is quiet
+const pingInterval = 90 * time.Second
+
+// origin identify this process, it skips its own notifications
+var origin = newOrigin()
+
+type (
+	// Message is the payload of a notification
+	Message struct {
+		Origin string   `json:"origin"`
+		Keys   []string `json:"keys"`
+	}
+
+	// Publisher notify the other processes of invalidated entity keys, such as "book:42"
+	Publisher interface {
+		Publish(ctx context.Context, keys ...string) error
+	}
+
+	// Handler drop the cached entries of the keys invalidated by another process,
+	// Flush drop every entry when notifications may have been missed
+	Handler interface {
+		Invalidate(ctx context.Context, keys []string)
+		Flush(ctx context.Context)
+	}
+
+	// DBPublisher publish with pg_notify
+	DBPublisher struct {
+		connection *sql.DB
+	}
+
+	// Bus dispatch the notifications of Channel to its handlers
+	Bus struct {
+		listener *pq.Listener
+		handlers []Handler
+		stop     chan bool
+		done     chan bool
+	}
+)
+
+// NewPublisher return a Publisher notifying through connection
+func NewPublisher(connection *sql.DB) Publisher {
+	return &DBPublisher{connection: connection}
+}
+
+// Publish notify keys, inside a transaction the notification is sent on commit and dropped on rollback
+func (p *DBPublisher) Publish(ctx context.Context, keys ...string) error {
+	trxn, err := dbtrxn.Use(ctx, p.connection)
+	if err != nil {
+		return err
+	}
+
+	for _, payload := range payloads(keys) {
+		if _, err := trxn.DB.Exec("SELECT pg_notify($1, $2)", Channel, payload); err != nil {
+			trxn.SetError(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// payloads split keys in notifications under maxPayload
+func payloads(keys []string) []string {
+	var (
+		list  []string
+		batch []string
+		size  int
+	)
+	flush := func() {
+		data, _ := json.Marshal(&Message{Origin: origin, Keys: batch})
+		list = append(list, string(data))
+		batch, size = nil, 0
+	}
+
+	for _, key := range keys {
+		if size+len(key) > maxPayload && len(batch) > 0 {
+			flush()
+		}
+		batch = append(batch, key)
+		size += len(key) + 3
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+	return list
+}
+
+// NewBus return a Bus listening on listener, the listener is owned by the caller
+func NewBus(listener *pq.Listener) *Bus {
+	return &Bus{
+		listener: listener,
+		stop:     make(chan bool),
+		done:     make(chan bool),
+	}
+}
+
+// Subscribe handler to the notifications, before Start
+func (b *Bus) Subscribe(handler Handler) {
+	b.handlers = append(b.handlers, handler)
+}
+
+// Start listening in the background
+func (b *Bus) Start() error {
+	if err := b.listener.Listen(Channel); err != nil {
+		return err
+	}
+
+	go b.run()
+	return nil
+}
+
+// Stop listening
+func (b *Bus) Stop() error {
+	b.stop <- true
+	<-b.done
+	return b.listener.Unlisten(Channel)
+}
+
+func (b *Bus) run() {
+	defer close(b.done)
+
+	logger := util.Log.WithField("context", "invalidation")
+	ctx := context.Background()
+	for {
+		select {
+		case notification := <-b.listener.Notify:
+			// nil after a reconnection, the notifications sent meanwhile are lost
+			if notification == nil {
+				logger.Info("listener reconnected, flush every cache")
+				for _, handler := range b.handlers {
+					handler.Flush(ctx)
+				}
+				continue
+			}
+
+			message := new(Message)
+			if err := json.Unmarshal([]byte(notification.Extra), message); err != nil {
+				logger.Warnf("invalid notification %q: %v", notification.Extra, err)
+				continue
+			}
+			if message.Origin == origin {
+				continue
+			}
+			for _, handler := range b.handlers {
+				handler.Invalidate(ctx, message.Keys)
+			}
+		case <-time.After(pingInterval):
+			go b.listener.Ping()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+func newOrigin() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,90 @@
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPayloads(t *testing.T) {
+	var keys []string
+	for i := 0; i < 2000; i++ {
+		keys = append(keys, fmt.Sprintf("book:acme:%d", i))
+	}
+
+	list := payloads(keys)
+	if len(list) < 2 {
+		t.Fatalf("got %d payloads, want the keys split", len(list))
+	}
+	var got []string
+	for _, payload := range list {
+		if len(payload) > maxPayload+len(origin)+64 {
+			t.Errorf("got a payload of %d bytes", len(payload))
+		}
+		message := new(Message)
+		if err := json.Unmarshal([]byte(payload), message); err != nil {
+			t.Fatal(err)
+		}
+		if message.Origin != origin {
+			t.Errorf("got origin %q, want %q", message.Origin, origin)
+		}
+		got = append(got, message.Keys...)
+	}
+	if !reflect.DeepEqual(got, keys) {
+		t.Error("the payloads do not carry every key in order")
+	}
+
+	if list := payloads(nil); len(list) != 0 {
+		t.Errorf("got %d payloads of no key", len(list))
+	}
+}
+
+// recordingHandler record the invalidated keys and the flushes
+type recordingHandler struct {
+	keys    []string
+	flushes int
+}
+
+func (h *recordingHandler) Invalidate(ctx context.Context, keys []string) {
+	h.keys = append(h.keys, keys...)
+}
+
+func (h *recordingHandler) Flush(ctx context.Context) {
+	h.flushes++
+}
+
+func TestBus(t *testing.T) {
+	util.Log = logrus.New()
+
+	notify := make(chan *pq.Notification)
+	bus := NewBus(&pq.Listener{Notify: notify})
+	handler := &recordingHandler{}
+	bus.Subscribe(handler)
+	go bus.run()
+
+	message := func(origin string, keys ...string) *pq.Notification {
+		data, _ := json.Marshal(&Message{Origin: origin, Keys: keys})
+		return &pq.Notification{Channel: Channel, Extra: string(data)}
+	}
+	notify <- message("other", "book:acme:1", "book:acme:2")
+	// the notifications of this process are skipped
+	notify <- message(origin, "book:acme:3")
+	notify <- &pq.Notification{Channel: Channel, Extra: "{"}
+	// a reconnection may have lost notifications
+	notify <- nil
+	bus.stop <- true
+	<-bus.done
+
+	if !reflect.DeepEqual(handler.keys, []string{"book:acme:1", "book:acme:2"}) {
+		t.Errorf("got invalidated keys %v", handler.keys)
+	}
+	if handler.flushes != 1 {
+		t.Errorf("got %d flushes, want 1", handler.flushes)
+	}
+}
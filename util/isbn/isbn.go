@@ -0,0 +1,144 @@
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotConvertible is returned when an ISBN-13 has no ISBN-10 equivalent
+var ErrNotConvertible = errors.New("isbn: only 978 prefixed ISBN-13 can be converted to ISBN-10")
+
+// Normalize remove hyphens and spaces and uppercase the check digit
+func Normalize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == 'x' || r == 'X':
+			b.WriteRune('X')
+		case r == '-' || r == ' ':
+		default:
+			// keep invalid characters so validation fails
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Valid10 report whether s is a valid ISBN-10, hyphens and spaces are ignored
+func Valid10(s string) bool {
+	s = Normalize(s)
+	if len(s) != 10 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var digit int
+		switch {
+		case s[i] >= '0' && s[i] <= '9':
+			digit = int(s[i] - '0')
+		case s[i] == 'X' && i == 9:
+			digit = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * digit
+	}
+
+	return sum%11 == 0
+}
+
+// Valid13 report whether s is a valid ISBN-13, hyphens and spaces are ignored
+func Valid13(s string) bool {
+	s = Normalize(s)
+	if !digits(s, 13) || !(strings.HasPrefix(s, "978") || strings.HasPrefix(s, "979")) {
+		return false
+	}
+
+	return checkDigit13(s[:12]) == s[12]
+}
+
+// To13 convert a valid ISBN-10 to its ISBN-13
+func To13(s string) (string, error) {
+	if !Valid10(s) {
+		return "", errors.New("isbn: invalid ISBN-10")
+	}
+
+	body := "978" + Normalize(s)[:9]
+	return body + string(checkDigit13(body)), nil
+}
+
+// To10 convert a valid 978 prefixed ISBN-13 to its ISBN-10
+func To10(s string) (string, error) {
+	if !Valid13(s) {
+		return "", errors.New("isbn: invalid ISBN-13")
+	}
+
+	s = Normalize(s)
+	if !strings.HasPrefix(s, "978") {
+		return "", ErrNotConvertible
+	}
+
+	body := s[3:12]
+	return body + string(checkDigit10(body)), nil
+}
+
+// New13 append the check digit to a 12 digit ISBN-13 body, it return "" when body is not 12 digits
+func New13(body string) string {
+	if !digits(body, 12) {
+		return ""
+	}
+	return body + string(checkDigit13(body))
+}
+
+// digits report whether s is n decimal digits
+func digits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDigit10 return the check digit of a 9 digit body, 0 when body is not 9 digits
+func checkDigit10(body string) byte {
+	if !digits(body, 9) {
+		return 0
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(body[i]-'0')
+	}
+
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// checkDigit13 return the check digit of a 12 digit body, 0 when body is not 12 digits
+func checkDigit13(body string) byte {
+	if !digits(body, 12) {
+		return 0
+	}
+
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(body[i]-'0')
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
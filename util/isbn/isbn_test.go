@@ -0,0 +1,103 @@
+package isbn
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		isbn    string
+		valid10 bool
+		valid13 bool
+	}{
+		{"isbn-10", "0-306-40615-2", true, false},
+		{"isbn-10 check digit X", "0-8044-2957-x", true, false},
+		{"isbn-10 wrong checksum", "0306406153", false, false},
+		{"isbn-10 X not last", "03064X6152", false, false},
+		{"isbn-13", "978-0-306-40615-7", false, true},
+		{"isbn-13 979", "979-10-90636-07-1", false, true},
+		{"isbn-13 wrong checksum", "9780306406158", false, false},
+		{"isbn-13 other prefix", "9770306406157", false, false},
+		{"isbn-13 with X", "978030640615X", false, false},
+		{"short", "978", false, false},
+		{"empty", "", false, false},
+		{"letters", "abcdefghij", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Valid10(test.isbn); got != test.valid10 {
+				t.Errorf("Valid10(%q) = %v, want %v", test.isbn, got, test.valid10)
+			}
+			if got := Valid13(test.isbn); got != test.valid13 {
+				t.Errorf("Valid13(%q) = %v, want %v", test.isbn, got, test.valid13)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		isbn10 string
+		isbn13 string
+	}{
+		{"0306406152", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"0198526636", "9780198526636"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.isbn10, func(t *testing.T) {
+			isbn13, err := To13(test.isbn10)
+			if err != nil || isbn13 != test.isbn13 {
+				t.Errorf("To13(%q) = %q, %v, want %q", test.isbn10, isbn13, err, test.isbn13)
+			}
+			isbn10, err := To10(test.isbn13)
+			if err != nil || isbn10 != test.isbn10 {
+				t.Errorf("To10(%q) = %q, %v, want %q", test.isbn13, isbn10, err, test.isbn10)
+			}
+		})
+	}
+
+	if _, err := To10("9791090636071"); err != ErrNotConvertible {
+		t.Errorf("got %v converting a 979 ISBN-13, want %v", err, ErrNotConvertible)
+	}
+	if _, err := To13("0306406153"); err == nil {
+		t.Error("an invalid ISBN-10 is converted")
+	}
+	if _, err := To10("978"); err == nil {
+		t.Error("a short ISBN-13 is converted")
+	}
+}
+
+func TestNew13(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"978030640615", "9780306406157"},
+		{"979109063607", "9791090636071"},
+		{"97803064061", ""},
+		{"9780306406155", ""},
+		{"97803064061X", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := New13(test.body); got != test.want {
+			t.Errorf("New13(%q) = %q, want %q", test.body, got, test.want)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	for _, body := range []string{"", "12", "03064061", "03064061a"} {
+		if got := checkDigit10(body); got != 0 {
+			t.Errorf("checkDigit10(%q) = %q, want 0", body, got)
+		}
+	}
+	for _, body := range []string{"", "978", "97803064061", "97803064061a"} {
+		if got := checkDigit13(body); got != 0 {
+			t.Errorf("checkDigit13(%q) = %q, want 0", body, got)
+		}
+	}
+}
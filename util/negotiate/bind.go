@@ -0,0 +1,89 @@
+package negotiate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vmihailenco/msgpack/v4"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Bind decode the request body of c in i. MessagePack and YAML bodies are decoded like their JSON
+// equivalent, the other media types are bound by echo
+func Bind(c echo.Context, i interface{}) error {
+	req := c.Request()
+	format := FormatOf(req.Header.Get(echo.HeaderContentType))
+	if format != FormatMsgPack && format != FormatYAML {
+		return c.Bind(i)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	var value interface{}
+	if format == FormatMsgPack {
+		value, err = msgpack.NewDecoder(bytes.NewReader(body)).DecodeInterface()
+	} else {
+		err = yaml.Unmarshal(body, &value)
+	}
+	if err == nil {
+		value, err = stringKeys(value)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, i)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// stringKeys convert the decoded maps to JSON objects
+func stringKeys(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("object key %v is not a string", key)
+			}
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = converted
+		}
+		return object, nil
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,182 @@
+package negotiate
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Supported formats
+const (
+	FormatJSON    = "json"
+	FormatXML     = "xml"
+	FormatCSV     = "csv"
+	FormatMsgPack = "msgpack"
+	FormatYAML    = "yaml"
+)
+
+// Formats are the formats of every representation, CSV is only offered by the lists
+var Formats = []string{FormatJSON, FormatXML, FormatMsgPack, FormatYAML}
+
+// ListFormats are the formats of the lists
+var ListFormats = []string{FormatJSON, FormatXML, FormatCSV, FormatMsgPack, FormatYAML}
+
+// contextKey hold the negotiated format in the echo context
+const contextKey = "negotiate.format"
+
+var (
+	// ErrNotAcceptable is returned when no offered format is accepted
+	ErrNotAcceptable = errors.New("none of the accepted media types can be produced")
+	// ErrUnknownFormat is returned for an unknown ?format= value
+	ErrUnknownFormat = errors.New("unknown format")
+)
+
+// mediaTypes of the formats, the first one is produced
+var mediaTypes = map[string][]string{
+	FormatJSON:    {"application/json"},
+	FormatXML:     {"application/xml", "text/xml"},
+	FormatCSV:     {"text/csv"},
+	FormatMsgPack: {"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+	FormatYAML:    {"application/yaml", "application/x-yaml", "text/yaml"},
+}
+
+// ContentType return the produced media type of format
+func ContentType(format string) string {
+	switch format {
+	case FormatJSON, FormatXML, FormatCSV, FormatYAML:
+		return mediaTypes[format][0] + "; charset=utf-8"
+	}
+	return mediaTypes[format][0]
+}
+
+// FormatOf return the format of a media type, empty when unsupported
+func FormatOf(mediaType string) string {
+	mediaType, _, _ = mime.ParseMediaType(mediaType)
+	for format, types := range mediaTypes {
+		for _, t := range types {
+			if t == mediaType {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+// mediaRange is a media range of an Accept header
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// Negotiate return the offered format requested by the ?format= parameter or else preferred by the Accept
+// header, the first offer when any is accepted
+func Negotiate(req *http.Request, offers ...string) (string, error) {
+	if format := req.URL.Query().Get("format"); format != "" {
+		for _, offer := range offers {
+			if offer == format {
+				return format, nil
+			}
+		}
+		if _, ok := mediaTypes[format]; ok {
+			return "", ErrNotAcceptable
+		}
+		return "", ErrUnknownFormat
+	}
+
+	accept := req.Header.Get(echo.HeaderAccept)
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], nil
+	}
+
+	for _, r := range parseAccept(accept) {
+		for _, offer := range offers {
+			if r.matches(offer) {
+				return offer, nil
+			}
+		}
+	}
+	return "", ErrNotAcceptable
+}
+
+// parseAccept return the accepted media ranges by decreasing quality
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+
+	// the client order is kept between equal qualities
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// matches tell whether a media type of format is in the range
+func (r mediaRange) matches(format string) bool {
+	for _, t := range mediaTypes[format] {
+		switch {
+		case r.mediaType == "*/*", r.mediaType == t:
+			return true
+		case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(t, strings.TrimSuffix(r.mediaType, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware func negotiate the response format of a route among offers, the first one is the default.
+// The requests accepting none of them are rejected with 406
+func Middleware(offers ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+
+			format, err := Negotiate(c.Request(), offers...)
+			if err != nil {
+				status := http.StatusNotAcceptable
+				if err == ErrUnknownFormat {
+					status = http.StatusBadRequest
+				}
+				return c.JSON(status, &errorResponse{
+					Status:  int64(status),
+					Message: err.Error() + ", supported formats: " + strings.Join(offers, ", "),
+				})
+			}
+
+			c.Set(contextKey, format)
+			return next(c)
+		}
+	}
+}
+
+// Format return the negotiated format of c, JSON when the route does not negotiate
+func Format(c echo.Context) string {
+	if format, ok := c.Get(contextKey).(string); ok {
+		return format
+	}
+	return FormatJSON
+}
+
+// errorResponse is the body of a rejected request
+type errorResponse struct {
+	Status  int64  `json:"status"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,154 @@
+package negotiate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/server/book/models"
+	"github.com/go-rest-api-boilerplate/server/book/transfer"
+	"github.com/labstack/echo/v4"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		query  string
+		want   string
+		err    error
+	}{
+		{"no accept", "", "", FormatJSON, nil},
+		{"exact", "application/xml", "", FormatXML, nil},
+		{"alias", "text/yaml", "", FormatYAML, nil},
+		{"q-value order", "application/json;q=0.5, application/xml;q=0.9", "", FormatXML, nil},
+		{"client order between equal q-values", "text/csv, application/xml", "", FormatCSV, nil},
+		{"default q-value", "application/xml;q=0.8, application/x-msgpack", "", FormatMsgPack, nil},
+		{"zero q-value", "application/xml;q=0, */*;q=0.1", "", FormatJSON, nil},
+		{"invalid q-value ignored", "application/xml;q=high, application/yaml;q=0.2", "", FormatYAML, nil},
+		{"subtype wildcard", "text/*", "", FormatXML, nil},
+		{"any", "image/png, */*;q=0.1", "", FormatJSON, nil},
+		{"not acceptable", "image/png", "", "", ErrNotAcceptable},
+		{"only refused", "application/json;q=0", "", "", ErrNotAcceptable},
+		{"parameter over accept", "application/json", "?format=yaml", FormatYAML, nil},
+		{"parameter not offered", "", "?format=pdf", "", ErrUnknownFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/books"+test.query, nil)
+			req.Header.Set(echo.HeaderAccept, test.accept)
+
+			got, err := Negotiate(req, ListFormats...)
+			if got != test.want || err != test.err {
+				t.Errorf("got %q, %v, want %q, %v", got, err, test.want, test.err)
+			}
+		})
+	}
+
+	// a known format is not acceptable when the route does not offer it
+	req := httptest.NewRequest(http.MethodGet, "/books/1?format=csv", nil)
+	if _, err := Negotiate(req, Formats...); err != ErrNotAcceptable {
+		t.Errorf("got %v, want %v", err, ErrNotAcceptable)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	handler := Middleware(Formats...)(func(c echo.Context) error {
+		return Render(c, http.StatusOK, map[string]string{"format": Format(c)})
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		status int
+	}{
+		{"accepted", "/books/1", "application/json", http.StatusOK},
+		{"not acceptable", "/books/1", "text/csv", http.StatusNotAcceptable},
+		{"unknown format", "/books/1?format=pdf", "", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			req.Header.Set(echo.HeaderAccept, test.accept)
+			rec := httptest.NewRecorder()
+			if err := handler(e.NewContext(req, rec)); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != test.status {
+				t.Errorf("got status %d, want %d", rec.Code, test.status)
+			}
+			if vary := rec.Header().Get(echo.HeaderVary); vary != echo.HeaderAccept {
+				t.Errorf("got Vary %q, want %q", vary, echo.HeaderAccept)
+			}
+			if test.status == http.StatusOK {
+				return
+			}
+
+			var body errorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != int64(test.status) || !strings.HasSuffix(body.Message, "json, xml, msgpack, yaml") {
+				t.Errorf("got body %+v, want the status and the supported formats", body)
+			}
+		})
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	e := echo.New()
+	books := transfer.Table{
+		{ID: 1, Title: "The Go Programming Language", Author: "Alan Donovan"},
+		{ID: 2, Title: "Refactoring, 2nd edition", Author: "Martin Fowler"},
+	}
+
+	render := func(data interface{}) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/books", nil)
+		req.Header.Set(echo.HeaderAccept, "text/csv")
+		rec := httptest.NewRecorder()
+		handler := Middleware(ListFormats...)(func(c echo.Context) error {
+			return Render(c, http.StatusOK, data)
+		})
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	rec := render(books)
+	if got := rec.Header().Get(echo.HeaderContentType); got != "text/csv; charset=utf-8" {
+		t.Errorf("got Content-Type %q, want CSV", got)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want the header and 2 books", len(records))
+	}
+	header := strings.Join(records[0][:6], ",")
+	if header != "id,isbn10,isbn13,title,subtitle,author" {
+		t.Errorf("got header %q", header)
+	}
+	for i, book := range books {
+		record := records[i+1]
+		if record[0] != strconv.FormatInt(book.ID, 10) || record[3] != book.Title || record[5] != book.Author {
+			t.Errorf("got record %q, want %+v", record[:6], book)
+		}
+	}
+
+	// the data without table are rendered as JSON
+	rec = render([]*models.Book{})
+	if got := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(got, echo.MIMEApplicationJSON) {
+		t.Errorf("got Content-Type %q, want JSON", got)
+	}
+}
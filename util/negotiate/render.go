@@ -0,0 +1,153 @@
+package negotiate
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vmihailenco/msgpack/v4"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// xmlRoot is the root element of the XML responses
+var xmlRoot = xml.StartElement{Name: xml.Name{Local: "response"}}
+
+// Table is a response renderable as CSV
+type Table interface {
+	WriteCSV(w io.Writer) error
+}
+
+// Render write data in the negotiated format of c. MessagePack and YAML are rendered from the JSON
+// encoding of data so every format has the same fields. CSV is only rendered for a Table, JSON otherwise
+func Render(c echo.Context, status int, data interface{}) error {
+	format := Format(c)
+	switch format {
+	case FormatXML:
+		var buf bytes.Buffer
+		buf.WriteString(xml.Header)
+		if err := xml.NewEncoder(&buf).EncodeElement(data, xmlRoot); err != nil {
+			return err
+		}
+		return c.Blob(status, ContentType(format), buf.Bytes())
+	case FormatCSV:
+		table, ok := data.(Table)
+		if !ok {
+			break
+		}
+		c.Response().Header().Set(echo.HeaderContentType, ContentType(format))
+		c.Response().WriteHeader(status)
+		return table.WriteCSV(c.Response())
+	case FormatMsgPack:
+		value, err := ordered(data)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := encodeMsgPack(msgpack.NewEncoder(&buf).UseCompactEncoding(true), value); err != nil {
+			return err
+		}
+		return c.Blob(status, ContentType(format), buf.Bytes())
+	case FormatYAML:
+		value, err := ordered(data)
+		if err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		return c.Blob(status, ContentType(format), out)
+	}
+
+	return c.JSON(status, data)
+}
+
+// ordered return the JSON encoding of v decoded with the key order of its objects
+func ordered(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeOrdered(decoder)
+}
+
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			object := make(yaml.MapSlice, 0)
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, yaml.MapItem{Key: key, Value: value})
+			}
+			_, err = decoder.Token()
+			return object, err
+		}
+
+		array := make([]interface{}, 0)
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+
+	return token, nil
+}
+
+// encodeMsgPack write a value decoded by ordered as a MessagePack map, array or scalar
+func encodeMsgPack(encoder *msgpack.Encoder, value interface{}) error {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		if err := encoder.EncodeMapLen(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := encoder.EncodeString(item.Key.(string)); err != nil {
+				return err
+			}
+			if err := encodeMsgPack(encoder, item.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if err := encoder.EncodeArrayLen(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := encodeMsgPack(encoder, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return encoder.Encode(value)
+}
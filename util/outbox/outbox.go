@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+	"github.com/go-rest-api-boilerplate/util/tenant"
+)
+
+// Statuses of an outbox event
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+// Table and columns of the outbox
+const (
+	table               = "outbox"
+	idColumn            = "id"
+	tenantIDColumn      = "tenant_id"
+	eventTypeColumn     = "event_type"
+	aggregateTypeColumn = "aggregate_type"
+	aggregateIDColumn   = "aggregate_id"
+	schemaVersionColumn = "schema_version"
+	requestIDColumn     = "request_id"
+	payloadColumn       = "payload"
+	statusColumn        = "status"
+	attemptsColumn      = "attempts"
+	publishedColumn     = "published"
+	lastErrorColumn     = "last_error"
+	nextAttemptAtColumn = "next_attempt_at"
+	sentAtColumn        = "sent_at"
+)
+
+type (
+	// Event is a change to publish, Attempts count the failed publications
+	Event struct {
+		ID            int64           `json:"id"`
+		TenantID      string          `json:"tenant_id,omitempty"`
+		Type          string          `json:"type"`
+		AggregateType string          `json:"aggregate_type"`
+		AggregateID   string          `json:"aggregate_id"`
+		SchemaVersion int             `json:"schema_version"`
+		RequestID     string          `json:"request_id,omitempty"`
+		Payload       json.RawMessage `json:"payload"`
+		Attempts      int             `json:"-"`
+		// Published is the bitmask of the publishers of a MultiPublisher having the event
+		Published int       `json:"-"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// Writer store an event to publish once the transaction commits
+	Writer interface {
+		Write(ctx context.Context, eventType, aggregateType, aggregateID string, version int, payload interface{}) error
+	}
+
+	// DBWriter write events in the dbtrxn transaction of the change
+	DBWriter struct {
+		connection *sql.DB
+	}
+)
+
+// NewWriter return a Writer storing events in the outbox table
+func NewWriter(connection *sql.DB) Writer {
+	return &DBWriter{
+		connection: connection,
+	}
+}
+
+// Write insert an event with the tenant and request ID of ctx, the event is
+// rolled back with the change when the transaction fails
+func (w *DBWriter) Write(ctx context.Context, eventType, aggregateType, aggregateID string, version int, payload interface{}) error {
+	trxn, err := dbtrxn.Use(ctx, w.connection)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	query := sq.Insert(table).
+		Columns(tenantIDColumn, eventTypeColumn, aggregateTypeColumn, aggregateIDColumn, schemaVersionColumn,
+			requestIDColumn, payloadColumn).
+		Values(nullString(tenant.FromContext(ctx)), eventType, aggregateType, aggregateID, version,
+			util.SessionCid(ctx), string(data)).
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	_, err = query.Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanEvent scan the columns of pendingQuery
+func scanEvent(rows *sql.Rows) (*Event, error) {
+	var event Event
+	var payload []byte
+	var tenantID sql.NullString
+	err := rows.Scan(&event.ID, &tenantID, &event.Type, &event.AggregateType, &event.AggregateID,
+		&event.SchemaVersion, &event.RequestID, &payload, &event.Attempts, &event.Published, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	event.Payload = payload
+	event.TenantID = tenantID.String
+	return &event, nil
+}
+
+// nullString store the system events without tenant
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
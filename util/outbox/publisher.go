@@ -0,0 +1,234 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+)
+
+// Publisher drivers
+const (
+	DriverLog    = "log"
+	DriverFile   = "file"
+	DriverHTTP   = "http"
+	DriverMemory = "memory"
+)
+
+type (
+	// Publisher deliver an event downstream, an event can be delivered more than
+	// once so consumers deduplicate on Event.ID
+	Publisher interface {
+		Publish(ctx context.Context, event *Event) error
+	}
+
+	// PublisherConfig select and configure a Publisher
+	PublisherConfig struct {
+		Driver      string
+		FilePath    string
+		HTTPURL     string
+		HTTPTimeout time.Duration
+	}
+
+	// LogPublisher write events to the logger
+	LogPublisher struct {
+		Logger util.Logger
+	}
+
+	// FilePublisher append events to a file as newline delimited JSON
+	FilePublisher struct {
+		sync.Mutex
+		file *os.File
+	}
+
+	// HTTPPublisher POST events as JSON, any status other than 2xx is a failure
+	HTTPPublisher struct {
+		URL    string
+		Client *http.Client
+	}
+
+	// MultiPublisher publish every event to each publisher in order, the failed
+	// publishers do not stop the others. Event.Published track the publishers
+	// having the event so a retry only runs the failed ones, at most 31 publishers
+	MultiPublisher []Publisher
+
+	// MemoryPublisher keep events in memory, set Err to fail the publications
+	MemoryPublisher struct {
+		sync.Mutex
+		Err    error
+		events []*Event
+	}
+)
+
+// NewPublisher return the Publisher of the config driver
+func NewPublisher(config PublisherConfig) (Publisher, error) {
+	switch config.Driver {
+	case "", DriverLog:
+		return &LogPublisher{Logger: util.Log.WithField("context", "outbox")}, nil
+	case DriverFile:
+		return NewFilePublisher(config.FilePath)
+	case DriverHTTP:
+		if config.HTTPURL == "" {
+			return nil, fmt.Errorf("outbox: missing http publisher url")
+		}
+		return &HTTPPublisher{URL: config.HTTPURL, Client: &http.Client{Timeout: config.HTTPTimeout}}, nil
+	case DriverMemory:
+		return &MemoryPublisher{}, nil
+	}
+	return nil, fmt.Errorf("outbox: unknown publisher driver %q", config.Driver)
+}
+
+// PublisherConfigFromEnv read OUTBOX_PUBLISHER, OUTBOX_FILE_PATH, OUTBOX_HTTP_URL and OUTBOX_HTTP_TIMEOUT
+func PublisherConfigFromEnv() PublisherConfig {
+	timeout, err := time.ParseDuration(os.Getenv("OUTBOX_HTTP_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return PublisherConfig{
+		Driver:      os.Getenv("OUTBOX_PUBLISHER"),
+		FilePath:    os.Getenv("OUTBOX_FILE_PATH"),
+		HTTPURL:     os.Getenv("OUTBOX_HTTP_URL"),
+		HTTPTimeout: timeout,
+	}
+}
+
+//Publish func
+func (p *LogPublisher) Publish(ctx context.Context, event *Event) error {
+	p.Logger.WithField("event_id", event.ID).
+		WithField("aggregate_id", event.AggregateID).
+		Infof("%s: %s", event.Type, event.Payload)
+	return nil
+}
+
+// NewFilePublisher open path for appending, its directory is created if missing
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	if path == "" {
+		return nil, fmt.Errorf("outbox: missing file publisher path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePublisher{file: file}, nil
+}
+
+//Publish func
+func (p *FilePublisher) Publish(ctx context.Context, event *Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+// Close the file
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+//Publish func
+func (p *HTTPPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", fmt.Sprint(event.ID))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// drain the body so the connection is reused
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("outbox: %s responded %s", p.URL, res.Status)
+	}
+	return nil
+}
+
+//Publish func
+func (p *MemoryPublisher) Publish(ctx context.Context, event *Event) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.Err != nil {
+		return p.Err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events return the published events in order
+func (p *MemoryPublisher) Events() []*Event {
+	p.Lock()
+	defer p.Unlock()
+
+	events := make([]*Event, len(p.events))
+	copy(events, p.events)
+	return events
+}
+
+//Publish func return the error of the first failed publisher
+func (p MultiPublisher) Publish(ctx context.Context, event *Event) error {
+	var err error
+	for i, publisher := range p {
+		bit := 1 << uint(i)
+		if event.Published&bit != 0 {
+			continue
+		}
+		if publishErr := publisher.Publish(ctx, event); publishErr != nil {
+			if err == nil {
+				err = publishErr
+			}
+			continue
+		}
+		event.Published |= bit
+	}
+	return err
+}
+
+// Close the publishers that are io.Closer
+func (p MultiPublisher) Close() error {
+	var err error
+	for _, publisher := range p {
+		if closer, ok := publisher.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+	return err
+}
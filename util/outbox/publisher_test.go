@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMultiPublisherRetryFailedOnly(t *testing.T) {
+	first, second, third := &MemoryPublisher{}, &MemoryPublisher{Err: errors.New("unavailable")}, &MemoryPublisher{}
+	publisher := MultiPublisher{first, second, third}
+	ctx := context.Background()
+	event := &Event{ID: 1, Type: "book.created"}
+
+	if err := publisher.Publish(ctx, event); err != second.Err {
+		t.Fatalf("got %v, want the error of the failed publisher", err)
+	}
+	if len(first.Events()) != 1 || len(third.Events()) != 1 {
+		t.Fatal("a failed publisher stopped the others")
+	}
+	if event.Published != 1|4 {
+		t.Fatalf("got published %b, want the first and third publishers", event.Published)
+	}
+
+	// the relay store Published and the retry skips the publishers having the event
+	second.Err = nil
+	if err := publisher.Publish(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Events()) != 1 || len(second.Events()) != 1 || len(third.Events()) != 1 {
+		t.Errorf("got %d, %d and %d events, want one per publisher", len(first.Events()), len(second.Events()),
+			len(third.Events()))
+	}
+	if event.Published != 1|2|4 {
+		t.Errorf("got published %b, want every publisher", event.Published)
+	}
+}
@@ -0,0 +1,227 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+)
+
+// maxErrorLength truncate the stored publication error
+const maxErrorLength = 1024
+
+// pendingQuery lock the publishable events, an event waits until the earlier
+// pending events of its aggregate are published so consumers see them in order
+const pendingQuery = `SELECT o.id, o.tenant_id, o.event_type, o.aggregate_type, o.aggregate_id, o.schema_version,
+       o.request_id, o.payload, o.attempts, o.published, o.created_at
+  FROM outbox o
+ WHERE o.status = 'pending'
+   AND o.next_attempt_at <= now()
+   AND NOT EXISTS (SELECT 1
+                     FROM outbox p
+                    WHERE p.status = 'pending'
+                      AND p.aggregate_type = o.aggregate_type
+                      AND p.aggregate_id = o.aggregate_id
+                      AND p.id < o.id)
+ ORDER BY o.id
+ LIMIT $1
+   FOR UPDATE SKIP LOCKED`
+
+type (
+	// RelayConfig tune the polling and the retries of a Relay
+	RelayConfig struct {
+		Interval    time.Duration
+		BatchSize   int
+		MaxAttempts int
+		MinBackoff  time.Duration
+		MaxBackoff  time.Duration
+	}
+
+	// Relay publish the pending outbox events, several relays can run at once
+	Relay struct {
+		connection *sql.DB
+		publisher  Publisher
+		config     RelayConfig
+		stop       chan bool
+		done       chan bool
+	}
+)
+
+// DefaultRelayConfig poll every second and dead-letter an event after 10 failures
+var DefaultRelayConfig = RelayConfig{
+	Interval:    time.Second,
+	BatchSize:   100,
+	MaxAttempts: 10,
+	MinBackoff:  time.Second,
+	MaxBackoff:  time.Hour,
+}
+
+// RelayConfigFromEnv override DefaultRelayConfig with OUTBOX_POLL_INTERVAL, OUTBOX_BATCH_SIZE and OUTBOX_MAX_ATTEMPTS
+func RelayConfigFromEnv() RelayConfig {
+	config := DefaultRelayConfig
+	if v, err := time.ParseDuration(os.Getenv("OUTBOX_POLL_INTERVAL")); err == nil && v > 0 {
+		config.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_BATCH_SIZE")); err == nil && v > 0 {
+		config.BatchSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS")); err == nil && v > 0 {
+		config.MaxAttempts = v
+	}
+	return config
+}
+
+// NewRelay return a Relay publishing the events of the outbox table
+func NewRelay(connection *sql.DB, publisher Publisher, config RelayConfig) *Relay {
+	return &Relay{
+		connection: connection,
+		publisher:  publisher,
+		config:     config,
+		stop:       make(chan bool),
+		done:       make(chan bool),
+	}
+}
+
+// Start polling in the background
+func (r *Relay) Start() error {
+	go r.run()
+	return nil
+}
+
+// Stop polling and wait for the batch in progress
+func (r *Relay) Stop() error {
+	r.stop <- true
+	<-r.done
+	return nil
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+
+	logger := util.Log.WithField("context", "outbox")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for {
+		count, err := r.RelayBatch(ctx)
+		if err != nil {
+			logger.Errorf("relay batch: %v", err)
+		}
+
+		// a full batch means more events are waiting
+		if err == nil && count == r.config.BatchSize {
+			select {
+			case <-r.stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(r.config.Interval):
+		}
+	}
+}
+
+// RelayBatch publish one batch of pending events and return the number of events handled
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	//start transaction
+	commit := dbtrxn.Begin(&ctx)
+
+	count, err := r.relay(ctx)
+	if err != nil {
+		dbtrxn.Retrieve(ctx).Err = err
+	}
+
+	//transaction commit or rollback if error
+	if commitErr := commit(); err == nil {
+		err = commitErr
+	}
+
+	return count, err
+}
+
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	trxn, err := dbtrxn.Use(ctx, r.connection)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := trxn.DB.Query(pendingQuery, r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	events := make([]*Event, 0, r.config.BatchSize)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	for _, event := range events {
+		builder := psql.Update(table).
+			Set(attemptsColumn, sq.Expr(attemptsColumn+" + 1")).
+			Where(sq.Eq{idColumn: event.ID})
+
+		err := r.publisher.Publish(ctx, event)
+		// a retry skips the publishers of a MultiPublisher having the event
+		builder = builder.Set(publishedColumn, event.Published)
+		if err != nil {
+			builder = r.retry(builder, event, err)
+		} else {
+			builder = builder.
+				Set(statusColumn, StatusSent).
+				Set(sentAtColumn, sq.Expr("now()")).
+				Set(lastErrorColumn, nil)
+		}
+
+		if _, err := builder.RunWith(trxn.DB).Exec(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), nil
+}
+
+// retry schedule the next attempt with an exponential backoff, or dead-letter the event
+func (r *Relay) retry(builder sq.UpdateBuilder, event *Event, err error) sq.UpdateBuilder {
+	message := err.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	builder = builder.Set(lastErrorColumn, message)
+
+	attempts := event.Attempts + 1
+	logger := util.Log.WithField("context", "outbox").WithField("event_id", event.ID)
+	if attempts >= r.config.MaxAttempts {
+		logger.Errorf("%s dead-lettered after %d attempts: %v", event.Type, attempts, err)
+		return builder.Set(statusColumn, StatusDead)
+	}
+
+	logger.Warnf("%s attempt %d failed: %v", event.Type, attempts, err)
+	return builder.Set(nextAttemptAtColumn,
+		sq.Expr(fmt.Sprintf("now() + interval '%d milliseconds'", r.Backoff(attempts)/time.Millisecond)))
+}
+
+// Backoff return the delay before the attempt following the given failed attempts
+func (r *Relay) Backoff(attempts int) time.Duration {
+	return util.Backoff(attempts, r.config.MinBackoff, r.config.MaxBackoff)
+}
@@ -0,0 +1,266 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// migrationFile match the files of a migration source
+var migrationFile = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+
+// compactComment match the comments without space after the slashes
+var compactComment = regexp.MustCompile(`^//[^\s/]`)
+
+type (
+	// Options of Generate
+	Options struct {
+		// Root is the project directory, holding go.mod
+		Root string
+		// MigrationDir is the migration source the tables are added to, relative to Root. It is the
+		// DefaultMigrationDir of the resource when empty
+		MigrationDir string
+		// Force overwrite the existing files
+		Force bool
+	}
+
+	// file is a generated file, relative to Root
+	file struct {
+		path     string
+		template string
+	}
+)
+
+var funcs = template.FuncMap{
+	// zero is the Go literal failing the required rule of the field
+	"zero": func(f *Field) string {
+		switch f.GoType {
+		case "string":
+			return `""`
+		case "time.Time":
+			return "time.Time{}"
+		}
+		return "0"
+	},
+}
+
+// Receiver is the receiver name of the model methods
+func (r *Resource) Receiver() string {
+	return r.Var[:1]
+}
+
+//ModulePath func return the module path of the go.mod of root
+func ModulePath(root string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", errors.New("scaffold: no module in go.mod")
+}
+
+// DefaultMigrationDir return the migration directory of the module name, relative to the project
+func DefaultMigrationDir(name string) string {
+	return "scripts/migration/" + name
+}
+
+//Generate func write the models, repository, service and controller of the resource with their tests, its
+//migration and its module, then register the module in application/app.go. It return the written files
+func Generate(r *Resource, options Options) ([]string, error) {
+	r.MigrationDir = filepath.ToSlash(options.MigrationDir)
+	if r.MigrationDir == "" {
+		r.MigrationDir = DefaultMigrationDir(r.Name)
+	}
+	version, err := nextMigration(filepath.Join(options.Root, filepath.FromSlash(r.MigrationDir)))
+	if err != nil {
+		return nil, err
+	}
+	r.Migration = version
+
+	server := filepath.Join("server", r.Dir)
+	migration := filepath.Join(filepath.FromSlash(r.MigrationDir), fmt.Sprintf("%d_%s", version, r.Table))
+	files := []file{
+		{filepath.Join(server, "models", r.Dir+".go"), modelsTemplate},
+		{filepath.Join(server, "models", r.Dir+"_test.go"), modelsTestTemplate},
+		{filepath.Join(server, "repository", "constants.go"), constantsTemplate},
+		{filepath.Join(server, "repository", r.Dir+"_repository.go"), repositoryTemplate},
+		{filepath.Join(server, "repository", r.Dir+"_repository_test.go"), repositoryTestTemplate},
+		{filepath.Join(server, "service", r.Dir+"_service.go"), serviceTemplate},
+		{filepath.Join(server, "service", r.Dir+"_service_test.go"), serviceTestTemplate},
+		{filepath.Join(server, "controller", r.Dir+"_controller.go"), controllerTemplate},
+		{filepath.Join(server, "controller", r.Dir+"_controller_test.go"), controllerTestTemplate},
+		{migration + ".up.sql", migrationUpTemplate},
+		{migration + ".down.sql", migrationDownTemplate},
+		{filepath.Join("application", r.Dir+".go"), moduleTemplate},
+	}
+
+	// nothing is written when a file exists, the resource is generated at once
+	if !options.Force {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(options.Root, f.path)); err == nil {
+				return nil, fmt.Errorf("scaffold: %s exists, use --force to overwrite it", f.path)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(options.Root, server)); err == nil {
+			return nil, fmt.Errorf("scaffold: %s exists, use --force to overwrite it", server)
+		}
+	}
+
+	contents := make([][]byte, len(files))
+	for i, f := range files {
+		if contents[i], err = render(f, r); err != nil {
+			return nil, err
+		}
+	}
+
+	written := make([]string, 0, len(files)+1)
+	for i, f := range files {
+		path := filepath.Join(options.Root, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, err
+		}
+		if err := ioutil.WriteFile(path, contents[i], 0644); err != nil {
+			return written, err
+		}
+		written = append(written, f.path)
+	}
+
+	app := filepath.Join("application", "app.go")
+	registered, err := register(filepath.Join(options.Root, app), "New"+r.Type+"Module()")
+	if registered {
+		written = append(written, app)
+	}
+	return written, err
+}
+
+// render the template of f, the Go files are formatted
+func render(f file, r *Resource) ([]byte, error) {
+	t, err := template.New(f.path).Funcs(funcs).Parse(strings.Replace(f.template, "¬", "`", -1))
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, r); err != nil {
+		return nil, err
+	}
+	if filepath.Ext(f.path) != ".go" {
+		return b.Bytes(), nil
+	}
+
+	content, err := formatSource(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %s: %w", f.path, err)
+	}
+	return content, nil
+}
+
+// formatSource gofmt src, keeping the "//Name func" comments the newer gofmt rewrite to "// Name func"
+func formatSource(src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := map[string]bool{}
+	for _, line := range strings.Split(string(src), "\n") {
+		if line = strings.TrimSpace(line); compactComment.MatchString(line) {
+			kept[line] = true
+		}
+	}
+	lines := strings.Split(string(formatted), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "// ") && kept["//"+trimmed[3:]] {
+			lines[i] = strings.Replace(line, "// ", "//", 1)
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// nextMigration return the version following the last migration of dir, 1 when dir does not exist yet
+func nextMigration(dir string) (int, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	last := 0
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		if version, _ := strconv.Atoi(match[1]); version > last {
+			last = version
+		}
+	}
+	return last + 1, nil
+}
+
+// register add constructor to the app.Register call of SetupApp, it is false when already registered
+func register(path, constructor string) (bool, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Contains(content, []byte(constructor)) {
+		return false, nil
+	}
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, path, content, 0)
+	if err != nil {
+		return false, err
+	}
+
+	var call *ast.CallExpr
+	ast.Inspect(node, func(n ast.Node) bool {
+		c, ok := n.(*ast.CallExpr)
+		if !ok || call != nil {
+			return call == nil
+		}
+		if s, ok := c.Fun.(*ast.SelectorExpr); ok && s.Sel.Name == "Register" {
+			call = c
+			return false
+		}
+		return true
+	})
+	if call == nil {
+		return false, fmt.Errorf("scaffold: no app.Register call in %s, register %s by hand", path, constructor)
+	}
+
+	// the module is appended after the last argument
+	offset := fset.Position(call.Rparen).Offset
+	if len(call.Args) > 0 {
+		offset = fset.Position(call.Args[len(call.Args)-1].End()).Offset
+	}
+	insert := constructor
+	if len(call.Args) > 0 {
+		insert = ", " + insert
+	}
+
+	updated := append(append(append([]byte{}, content[:offset]...), insert...), content[offset:]...)
+	if updated, err = formatSource(updated); err != nil {
+		return false, err
+	}
+	return true, ioutil.WriteFile(path, updated, 0644)
+}
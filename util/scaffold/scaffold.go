@@ -0,0 +1,303 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Field types of --fields, with their Go and SQL types
+var fieldTypes = map[string]fieldType{
+	"string": {goType: "string", sqlType: "VARCHAR (255) NOT NULL DEFAULT ''", validate: "max=255", sample: `"Sample %s"`},
+	"text":   {goType: "string", sqlType: "TEXT NOT NULL DEFAULT ''", sample: `"Sample %s"`},
+	"int":    {goType: "int", sqlType: "INTEGER NOT NULL DEFAULT 0", sample: "1"},
+	"int64":  {goType: "int64", sqlType: "BIGINT NOT NULL DEFAULT 0", sample: "1"},
+	"float":  {goType: "float64", sqlType: "DOUBLE PRECISION NOT NULL DEFAULT 0", sample: "1.5"},
+	"bool":   {goType: "bool", sqlType: "BOOLEAN NOT NULL DEFAULT FALSE", sample: "true"},
+	"time":   {goType: "time.Time", sqlType: "TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP", sample: "time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)"},
+	// decimals are kept as strings so no precision is lost, an empty decimal is not numeric
+	"decimal": {goType: "string", sqlType: "NUMERIC (12, 2) NOT NULL DEFAULT 0", validate: "numeric", sample: `"9.99"`, example: "9.99"},
+}
+
+// reservedColumns are generated for every resource
+var reservedColumns = map[string]bool{"id": true, "tenant_id": true, "updated_at": true, "created_at": true}
+
+// reservedNames would shadow the packages imported by the generated code
+var reservedNames = map[string]bool{
+	"models": true, "repository": true, "service": true, "controller": true, "context": true, "errors": true,
+	"fmt": true, "http": true, "sql": true, "strconv": true, "strings": true, "time": true, "validation": true,
+	"dbtrxn": true, "tenant": true, "echo": true, "sq": true, "pq": true, "init": true, "data": true, "ctx": true,
+	"err": true, "filter": true, "id": true, "list": true, "rows": true, "trxn": true, "builder": true,
+}
+
+var (
+	nameFormat   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*([_-][a-zA-Z0-9]+)*$`)
+	columnFormat = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+	// initialisms are upper cased in Go names
+	initialisms = map[string]bool{"id": true, "url": true, "uri": true, "api": true, "http": true, "json": true,
+		"xml": true, "ip": true, "uuid": true, "isbn": true, "sku": true, "html": true, "sql": true}
+)
+
+type (
+	fieldType struct {
+		goType   string
+		sqlType  string
+		validate string
+		// sample is the Go literal of the tests, %s is the field name
+		sample  string
+		example string
+	}
+
+	// Resource is the vertical slice to generate
+	Resource struct {
+		// Module is the go.mod module path of the project
+		Module string
+		// Name is the kebab case name, it is the route and the CLI command of the module
+		Name string
+		// Dir is the snake case directory under server/
+		Dir string
+		// Type is the exported Go name
+		Type string
+		// Var is the unexported Go name
+		Var string
+		// Human is the name of the resource in the comments and messages
+		Human string
+		// Table is the plural snake case table name
+		Table  string
+		Fields []*Field
+		// Migration is the version of the migration files
+		Migration int
+		// MigrationDir is the migration source of the module, relative to the project
+		MigrationDir string
+	}
+
+	// Field is a column of the resource
+	Field struct {
+		// Column is the snake case column and JSON name
+		Column   string
+		Type     string
+		Required bool
+		GoName   string
+		GoType   string
+		SQLType  string
+		Validate string
+		Example  string
+		// Sample is the Go literal of the field in the generated tests
+		Sample string
+	}
+)
+
+// NewResource parse the resource name and the NAME:TYPE[:required] fields separated by commas
+func NewResource(module, name, fields string) (*Resource, error) {
+	if !nameFormat.MatchString(name) {
+		return nil, fmt.Errorf("scaffold: invalid resource name %q, use letters, digits, - and _", name)
+	}
+
+	words := splitWords(name)
+	r := &Resource{
+		Module: module,
+		Name:   strings.Join(words, "-"),
+		Dir:    strings.Join(words, "_"),
+		Type:   goName(words),
+		Human:  strings.Join(words, " "),
+		Table:  strings.Join(words[:len(words)-1], "_"),
+	}
+	r.Var = words[0] + goName(words[1:])
+	if len(words) > 1 {
+		r.Table += "_"
+	}
+	r.Table += plural(words[len(words)-1])
+	if token.IsKeyword(r.Var) || reservedNames[r.Var] {
+		return nil, fmt.Errorf("scaffold: resource name %q is reserved", name)
+	}
+
+	seen := map[string]bool{}
+	for _, spec := range strings.Split(fields, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		field, err := newField(spec)
+		if err != nil {
+			return nil, err
+		}
+		if seen[field.Column] {
+			return nil, fmt.Errorf("scaffold: duplicate field %q", field.Column)
+		}
+		seen[field.Column] = true
+		r.Fields = append(r.Fields, field)
+	}
+	if len(r.Fields) == 0 {
+		return nil, errors.New("scaffold: a resource need at least one field")
+	}
+
+	return r, nil
+}
+
+// newField parse NAME:TYPE[:required]
+func newField(spec string) (*Field, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || (len(parts) == 3 && parts[2] != "required") {
+		return nil, fmt.Errorf("scaffold: invalid field %q, use NAME:TYPE or NAME:TYPE:required", spec)
+	}
+
+	column := strings.ToLower(strings.Replace(parts[0], "-", "_", -1))
+	if !columnFormat.MatchString(column) {
+		return nil, fmt.Errorf("scaffold: invalid field name %q", parts[0])
+	}
+	if reservedColumns[column] {
+		return nil, fmt.Errorf("scaffold: field %q is generated for every resource", column)
+	}
+	if column == "validate" || column == "normalize" {
+		return nil, fmt.Errorf("scaffold: field %q clash with a method of the model", column)
+	}
+	t, ok := fieldTypes[parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("scaffold: unknown type %q of field %q, use one of %s", parts[1], column, typeNames())
+	}
+
+	f := &Field{
+		Column:   column,
+		Type:     parts[1],
+		Required: len(parts) == 3,
+		GoName:   goName(strings.Split(column, "_")),
+		GoType:   t.goType,
+		SQLType:  t.sqlType,
+		Validate: t.validate,
+		Example:  t.example,
+		Sample:   t.sample,
+	}
+	if strings.Contains(f.Sample, "%s") {
+		f.Sample = fmt.Sprintf(f.Sample, strings.Replace(column, "_", " ", -1))
+	}
+	// required on a bool would only accept true
+	if f.Required && f.Type != "bool" && f.Type != "decimal" {
+		f.Validate = strings.Trim("required,"+f.Validate, ",")
+	}
+	return f, nil
+}
+
+// Filter is true when the list of the resource can be filtered on the field
+func (f *Field) Filter() bool {
+	return f.Type == "string" || f.Type == "text"
+}
+
+// Normalize is true when the field is trimmed before validation
+func (f *Field) Normalize() bool {
+	return f.GoType == "string" && f.Type != "decimal"
+}
+
+// ColumnConst is the name of the column constant of the repository
+func (r *Resource) ColumnConst(f *Field) string {
+	return r.Var + f.GoName + "Column"
+}
+
+// DefaultMigrationDir is true when the module is migrated from its scripts/migration/NAME directory
+func (r *Resource) DefaultMigrationDir() bool {
+	return r.MigrationDir == DefaultMigrationDir(r.Name)
+}
+
+// EnvName is the name of the module in the environment variables
+func (r *Resource) EnvName() string {
+	return strings.ToUpper(strings.Replace(r.Name, "-", "_", -1))
+}
+
+// HasType is true when a field has the Go type
+func (r *Resource) HasType(goType string) bool {
+	for _, f := range r.Fields {
+		if f.GoType == goType {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFilter is true when the list can be filtered
+func (r *Resource) HasFilter() bool {
+	for _, f := range r.Fields {
+		if f.Filter() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNormalize is true when a field is trimmed
+func (r *Resource) HasNormalize() bool {
+	for _, f := range r.Fields {
+		if f.Normalize() {
+			return true
+		}
+	}
+	return false
+}
+
+// Required are the fields rejected when empty
+func (r *Resource) Required() []*Field {
+	var fields []*Field
+	for _, f := range r.Fields {
+		if strings.HasPrefix(f.Validate, "required") || f.Type == "decimal" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// splitWords split snake, kebab and camel case names into lower case words
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, c := range runes {
+		switch {
+		case c == '_' || c == '-':
+			words, word = appendWord(words, word), nil
+			continue
+		case unicode.IsUpper(c) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			words, word = appendWord(words, word), nil
+		}
+		word = append(word, unicode.ToLower(c))
+	}
+	return appendWord(words, word)
+}
+
+func appendWord(words []string, word []rune) []string {
+	if len(word) == 0 {
+		return words
+	}
+	return append(words, string(word))
+}
+
+// goName join words in an exported Go name
+func goName(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		if initialisms[w] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// plural of an english noun, the irregular ones are not handled
+func plural(word string) string {
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsAny(word[len(word)-2:len(word)-1], "aeiou"):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	}
+	return word + "s"
+}
+
+func typeNames() string {
+	return "string, text, int, int64, float, decimal, bool, time"
+}
@@ -0,0 +1,1209 @@
+package scaffold
+
+// The templates write ¬ for the backquotes of the struct tags, a raw string cannot hold them
+
+const modelsTemplate = `package models
+
+import (
+{{- if .HasNormalize}}
+	"strings"
+{{- end}}
+	"time"
+
+	"{{.Module}}/util/validation"
+)
+
+// validate is shared by every model, validator.Validate caches struct metadata
+var validate = validation.New()
+
+// {{.Type}} represented database model
+type {{.Type}} struct {
+	ID int64 ¬json:"id" db:"id,readonly"¬
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} ¬json:"{{.Column}}" db:"{{.Column}}"{{if .Validate}} validate:"{{.Validate}}"{{end}}{{if .Example}} example:"{{.Example}}"{{end}}¬
+{{- end}}
+	UpdatedAt time.Time ¬json:"-" db:"updated_at,readonly"¬
+	CreatedAt time.Time ¬json:"-" db:"created_at,readonly"¬
+}
+
+// {{.Type}}Filter is the list query of {{.Human}}
+type {{.Type}}Filter struct {
+{{- range .Fields}}{{if .Filter}}
+	{{.GoName}} string ¬query:"{{.Column}}"¬
+{{- end}}{{end}}
+}
+
+// SuccessResponseList struct
+type SuccessResponseList struct {
+	Status  int64 ¬json:"status"¬
+	Message string ¬json:"message"¬
+	Data    []*{{.Type}} ¬json:"data"¬
+}
+
+// SuccessResponseObject struct
+type SuccessResponseObject struct {
+	Status  int64 ¬json:"status"¬
+	Message string ¬json:"message"¬
+	Data    *{{.Type}} ¬json:"data"¬
+}
+
+// SuccessResponse struct
+type SuccessResponse struct {
+	Status  int64 ¬json:"status"¬
+	Message string ¬json:"message"¬
+	Errors  validation.Errors ¬json:"errors,omitempty"¬
+}
+
+// Validate {{.Human}}
+func ({{.Receiver}} *{{.Type}}) Validate() error {
+	return validate.Struct({{.Receiver}})
+}
+
+// Normalize trim the text fields of the {{.Human}}
+func ({{.Receiver}} *{{.Type}}) Normalize() {
+{{- range .Fields}}{{if .Normalize}}
+	{{$.Receiver}}.{{.GoName}} = strings.TrimSpace({{$.Receiver}}.{{.GoName}})
+{{- end}}{{end}}
+}
+`
+
+const modelsTestTemplate = `package models
+
+import (
+	"testing"
+{{- if .HasType "time.Time"}}
+	"time"
+{{- end}}
+)
+
+// sample{{.Type}} is a valid {{.Human}}
+func sample{{.Type}}() *{{.Type}} {
+	return &{{.Type}}{
+{{- range .Fields}}
+		{{.GoName}}: {{.Sample}},
+{{- end}}
+	}
+}
+
+func Test{{.Type}}Validate(t *testing.T) {
+	if err := sample{{.Type}}().Validate(); err != nil {
+		t.Fatalf("valid {{.Human}}: %v", err)
+	}
+{{- range .Required}}
+
+	t.Run("{{.Column}} required", func(t *testing.T) {
+		{{$.Var}} := sample{{$.Type}}()
+		{{$.Var}}.{{.GoName}} = {{zero .}}
+		if err := {{$.Var}}.Validate(); err == nil {
+			t.Error("{{.Column}} is required")
+		}
+	})
+{{- end}}
+}
+
+func Test{{.Type}}Normalize(t *testing.T) {
+	{{.Var}} := sample{{.Type}}()
+{{- range .Fields}}{{if .Normalize}}
+	{{$.Var}}.{{.GoName}} = " " + {{$.Var}}.{{.GoName}} + " "
+{{- end}}{{end}}
+	{{.Var}}.Normalize()
+
+	if *{{.Var}} != *sample{{.Type}}() {
+		t.Errorf("got %+v, want %+v", {{.Var}}, sample{{.Type}}())
+	}
+}
+`
+
+const constantsTemplate = `package repository
+
+import (
+	"{{.Module}}/server/{{.Dir}}/models"
+	"{{.Module}}/util/dbmap"
+)
+
+// Table Name
+const (
+	{{.Var}}Table = "{{.Table}}"
+)
+
+// Table Column Names
+const (
+	idColumn        = "id"
+	tenantIDColumn  = "tenant_id"
+	updatedAtColumn = "updated_at"
+	createdAtColumn = "created_at"
+
+	// {{.Type}} Table Column Names
+{{- range .Fields}}
+	{{$.ColumnConst .}} = "{{.Column}}"
+{{- end}}
+)
+
+// postgres error codes
+const (
+	uniqueViolation = "23505"
+)
+
+// Table Columns, mapped from the db tags of the models
+var (
+	{{.Var}}Mapping = dbmap.Of(&models.{{.Type}}{})
+)
+`
+
+const repositoryTemplate = `package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"{{.Module}}/server/{{.Dir}}/models"
+	"{{.Module}}/util/dbrouter"
+	"{{.Module}}/util/dbtrxn"
+	"{{.Module}}/util/tenant"
+	"github.com/lib/pq"
+)
+
+// {{.Type}}Repository to get {{.Human}} data from database
+type {{.Type}}Repository interface {
+	List(ctx context.Context, filter *models.{{.Type}}Filter) ([]*models.{{.Type}}, error)
+	Find(ctx context.Context, id int64) (*models.{{.Type}}, error)
+	Insert(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error)
+	Update(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// Err{{.Type}}Conflict is returned when a unique constraint of the {{.Table}} table is violated
+var Err{{.Type}}Conflict = errors.New("{{.Human}} already exists")
+
+//Init{{.Type}}Repository struct
+type Init{{.Type}}Repository struct {
+	router *dbrouter.Router
+}
+
+// New{{.Type}}Repository return new instance of {{.Type}}Repository, reading from the replicas of router
+func New{{.Type}}Repository(router *dbrouter.Router) {{.Type}}Repository {
+	return &Init{{.Type}}Repository{
+		router: router,
+	}
+}
+
+//List func
+func (init *Init{{.Type}}Repository) List(ctx context.Context, filter *models.{{.Type}}Filter) (list []*models.{{.Type}}, err error) {
+	list = make([]*models.{{.Type}}, 0)
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return list, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Read(ctx))
+	if err != nil {
+		return list, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select({{.Var}}Mapping.Columns()...).
+		From({{.Var}}Table).
+		Where(sq.Eq{tenantIDColumn: tenantID}).
+		OrderBy("id ASC")
+{{- if .HasFilter}}
+
+	if filter != nil {
+{{- range .Fields}}{{if .Filter}}
+		if filter.{{.GoName}} != "" {
+			builder = builder.Where(sq.ILike{ {{- $.ColumnConst .}}: "%" + filter.{{.GoName}} + "%"})
+		}
+{{- end}}{{end}}
+	}
+{{- end}}
+
+	rows, err := builder.RunWith(trxn.DB).Query()
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var {{.Var}} *models.{{.Type}}
+		{{.Var}}, err = scan{{.Type}}(rows)
+		if err != nil {
+			return
+		}
+		list = append(list, {{.Var}})
+	}
+
+	err = rows.Err()
+	return list, err
+}
+
+//Find func
+func (init *Init{{.Type}}Repository) Find(ctx context.Context, id int64) ({{.Var}} *models.{{.Type}}, err error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return {{.Var}}, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Read(ctx))
+	if err != nil {
+		return {{.Var}}, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Select({{.Var}}Mapping.Columns()...).
+		From({{.Var}}Table).
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
+
+	rows, err := builder.RunWith(trxn.DB).Query()
+	if err != nil {
+		return {{.Var}}, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		{{.Var}}, err = scan{{.Type}}(rows)
+	}
+
+	return {{.Var}}, err
+}
+
+//Insert func
+func (init *Init{{.Type}}Repository) Insert(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return {{.Var}}, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return {{.Var}}, err
+	}
+
+	query := sq.Insert({{.Var}}Table).
+		Columns({{.Var}}Mapping.WritableColumns()...).
+		Columns(tenantIDColumn).
+		Values(append({{.Var}}Mapping.Values({{.Var}}), tenantID)...).
+		Suffix("RETURNING \"id\"").
+		RunWith(trxn.DB).
+		PlaceholderFormat(sq.Dollar)
+
+	err = query.QueryRow().Scan(&{{.Var}}.ID)
+	if err != nil {
+		trxn.SetError(err)
+		return {{.Var}}, translateError(err)
+	}
+
+	return {{.Var}}, err
+}
+
+//Update func
+func (init *Init{{.Type}}Repository) Update(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return {{.Var}}, err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return {{.Var}}, err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Update({{.Var}}Table).
+		SetMap({{.Var}}Mapping.SetMap({{.Var}})).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{idColumn: {{.Var}}.ID, tenantIDColumn: tenantID})
+
+	_, err = builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return {{.Var}}, translateError(err)
+	}
+
+	return {{.Var}}, err
+}
+
+//Delete func
+func (init *Init{{.Type}}Repository) Delete(ctx context.Context, id int64) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	trxn, err := dbtrxn.Use(ctx, init.router.Write(ctx))
+	if err != nil {
+		return err
+	}
+
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	builder := psql.Delete({{.Var}}Table).
+		Where(sq.Eq{idColumn: id, tenantIDColumn: tenantID})
+
+	_, err = builder.RunWith(trxn.DB).Exec()
+	if err != nil {
+		trxn.SetError(err)
+		return translateError(err)
+	}
+
+	return err
+}
+
+// scan{{.Type}} scan a row selected with the columns of {{.Var}}Mapping
+func scan{{.Type}}(rows *sql.Rows) (*models.{{.Type}}, error) {
+	{{.Var}} := new(models.{{.Type}})
+	if err := {{.Var}}Mapping.Scan(rows, {{.Var}}); err != nil {
+		return nil, err
+	}
+	return {{.Var}}, nil
+}
+
+// translateError map constraint violations to repository errors
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return Err{{.Type}}Conflict
+	}
+	return err
+}
+`
+
+const repositoryTestTemplate = `package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+{{- if .HasType "time.Time"}}
+	"time"
+{{- end}}
+
+	"{{.Module}}/server/{{.Dir}}/models"
+	"{{.Module}}/util/dbrouter"
+	"{{.Module}}/util/tenant"
+	"github.com/lib/pq"
+)
+
+// sample{{.Type}} is a valid {{.Human}}
+func sample{{.Type}}() *models.{{.Type}} {
+	return &models.{{.Type}}{
+{{- range .Fields}}
+		{{.GoName}}: {{.Sample}},
+{{- end}}
+	}
+}
+
+func Test{{.Type}}Mapping(t *testing.T) {
+	columns := []string{idColumn,{{range .Fields}} {{$.ColumnConst .}},{{end}} updatedAtColumn, createdAtColumn}
+	if got := {{.Var}}Mapping.Columns(); !reflect.DeepEqual(got, columns) {
+		t.Errorf("got columns %v, want %v", got, columns)
+	}
+	// the id and the timestamps are set by the database
+	writable := []string{ {{- range $i, $f := .Fields}}{{if $i}}, {{end}}{{$.ColumnConst $f}}{{end -}} }
+	if got := {{.Var}}Mapping.WritableColumns(); !reflect.DeepEqual(got, writable) {
+		t.Errorf("got writable columns %v, want %v", got, writable)
+	}
+
+	{{.Var}} := sample{{.Type}}()
+	setMap := {{.Var}}Mapping.SetMap({{.Var}})
+{{- range .Fields}}
+	if setMap[{{$.ColumnConst .}}] != {{$.Var}}.{{.GoName}} {
+		t.Errorf("got %v for {{.Column}}, want %v", setMap[{{$.ColumnConst .}}], {{$.Var}}.{{.GoName}})
+	}
+{{- end}}
+}
+
+func TestTranslateError(t *testing.T) {
+	if err := translateError(&pq.Error{Code: uniqueViolation}); err != Err{{.Type}}Conflict {
+		t.Errorf("got %v, want %v", err, Err{{.Type}}Conflict)
+	}
+	other := errors.New("connection refused")
+	if err := translateError(other); err != other {
+		t.Errorf("got %v, want %v", err, other)
+	}
+}
+
+func Test{{.Type}}RepositoryRequireTenant(t *testing.T) {
+	// the queries are never run without tenant
+	repository := New{{.Type}}Repository(nil)
+	ctx := context.Background()
+
+	if _, err := repository.List(ctx, &models.{{.Type}}Filter{}); err != tenant.ErrMissing {
+		t.Errorf("list: got %v", err)
+	}
+	if _, err := repository.Find(ctx, 1); err != tenant.ErrMissing {
+		t.Errorf("find: got %v", err)
+	}
+	if _, err := repository.Insert(ctx, sample{{.Type}}()); err != tenant.ErrMissing {
+		t.Errorf("insert: got %v", err)
+	}
+	if _, err := repository.Update(ctx, sample{{.Type}}()); err != tenant.ErrMissing {
+		t.Errorf("update: got %v", err)
+	}
+	if err := repository.Delete(ctx, 1); err != tenant.ErrMissing {
+		t.Errorf("delete: got %v", err)
+	}
+}
+
+// Test{{.Type}}RepositoryCRUD run against the migrated database of DB_TEST_URL, its sessions are scoped to the
+// tenant of their statements like the API with TENANT_RLS
+func Test{{.Type}}RepositoryCRUD(t *testing.T) {
+	url := os.Getenv("DB_TEST_URL")
+	if url == "" {
+		t.Skip("DB_TEST_URL is not set")
+	}
+	connection, err := sql.Open(tenant.DriverName, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Close()
+
+	repository := New{{.Type}}Repository(dbrouter.New(connection, nil, dbrouter.DefaultConfig))
+	ctx := tenant.With(context.Background(), "default")
+
+	{{.Var}}, err := repository.Insert(ctx, sample{{.Type}}())
+	if err != nil || {{.Var}}.ID == 0 {
+		t.Fatalf("insert: %+v, %v", {{.Var}}, err)
+	}
+	defer repository.Delete(ctx, {{.Var}}.ID)
+
+	found, err := repository.Find(ctx, {{.Var}}.ID)
+	if err != nil || found == nil || found.ID != {{.Var}}.ID {
+		t.Fatalf("find: %+v, %v", found, err)
+	}
+	// the rows of a tenant are not visible to the others
+	if other, err := repository.Find(tenant.With(context.Background(), "other"), {{.Var}}.ID); err != nil || other != nil {
+		t.Errorf("find in another tenant: %+v, %v", other, err)
+	}
+
+	if _, err := repository.Update(ctx, found); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repository.List(ctx, &models.{{.Type}}Filter{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	listed := false
+	for _, item := range list {
+		listed = listed || item.ID == {{.Var}}.ID
+	}
+	if !listed {
+		t.Errorf("inserted {{.Human}} %d not listed", {{.Var}}.ID)
+	}
+
+	if err := repository.Delete(ctx, {{.Var}}.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if found, err := repository.Find(ctx, {{.Var}}.ID); err != nil || found != nil {
+		t.Errorf("deleted {{.Human}} still found: %+v, %v", found, err)
+	}
+}
+`
+
+const serviceTemplate = `package service
+
+import (
+	"context"
+
+	"{{.Module}}/server/{{.Dir}}/models"
+	"{{.Module}}/server/{{.Dir}}/repository"
+	"{{.Module}}/util/dbtrxn"
+)
+
+// Err{{.Type}}Conflict is returned when a unique constraint of the {{.Human}} is violated
+var Err{{.Type}}Conflict = repository.Err{{.Type}}Conflict
+
+//{{.Type}}Service interface
+type {{.Type}}Service interface {
+	List{{.Type}}(ctx context.Context, filter *models.{{.Type}}Filter) ([]*models.{{.Type}}, error)
+	Get{{.Type}}(ctx context.Context, id int64) (*models.{{.Type}}, error)
+	Create{{.Type}}(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error)
+	Update{{.Type}}(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error)
+	Delete{{.Type}}(ctx context.Context, id int64) error
+}
+
+//Init{{.Type}}Service struct
+type Init{{.Type}}Service struct {
+	Repository *Init{{.Type}}RepositoryInterface
+}
+
+//Init{{.Type}}RepositoryInterface struct
+type Init{{.Type}}RepositoryInterface struct {
+	{{.Type}} repository.{{.Type}}Repository
+}
+
+// New{{.Type}}Service return new instance of {{.Type}}Service
+func New{{.Type}}Service({{.Var}}Repository repository.{{.Type}}Repository) {{.Type}}Service {
+	return &Init{{.Type}}Service{
+		Repository: &Init{{.Type}}RepositoryInterface{
+			{{.Type}}: {{.Var}}Repository,
+		},
+	}
+}
+
+//List{{.Type}} func
+func (init *Init{{.Type}}Service) List{{.Type}}(ctx context.Context, filter *models.{{.Type}}Filter) ([]*models.{{.Type}}, error) {
+	return init.Repository.{{.Type}}.List(ctx, filter)
+}
+
+//Get{{.Type}} func
+func (init *Init{{.Type}}Service) Get{{.Type}}(ctx context.Context, id int64) (*models.{{.Type}}, error) {
+	return init.Repository.{{.Type}}.Find(ctx, id)
+}
+
+//Create{{.Type}} func
+func (init *Init{{.Type}}Service) Create{{.Type}}(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	{{.Var}}, err := init.Repository.{{.Type}}.Insert(ctx, {{.Var}})
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return {{.Var}}, err
+}
+
+//Update{{.Type}} func
+func (init *Init{{.Type}}Service) Update{{.Type}}(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	{{.Var}}, err := init.Repository.{{.Type}}.Update(ctx, {{.Var}})
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return {{.Var}}, err
+}
+
+//Delete{{.Type}} func
+func (init *Init{{.Type}}Service) Delete{{.Type}}(ctx context.Context, id int64) error {
+	//start transaction
+	defer dbtrxn.Begin(&ctx)()
+
+	err := init.Repository.{{.Type}}.Delete(ctx, id)
+
+	//transaction commit or rollback if error
+	dbtrxn.Error(ctx)
+
+	return err
+}
+`
+
+const serviceTestTemplate = `package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"{{.Module}}/server/{{.Dir}}/models"
+	"{{.Module}}/util/dbtrxn"
+)
+
+// memory{{.Type}}Repository keep the {{.Human}} rows in memory and record whether the writes were transactional
+type memory{{.Type}}Repository struct {
+	rows          map[int64]*models.{{.Type}}
+	nextID        int64
+	err           error
+	transactional bool
+}
+
+func newMemory{{.Type}}Repository() *memory{{.Type}}Repository {
+	return &memory{{.Type}}Repository{rows: map[int64]*models.{{.Type}}{}}
+}
+
+func (r *memory{{.Type}}Repository) List(ctx context.Context, filter *models.{{.Type}}Filter) ([]*models.{{.Type}}, error) {
+	list := make([]*models.{{.Type}}, 0, len(r.rows))
+	for _, {{.Var}} := range r.rows {
+		list = append(list, {{.Var}})
+	}
+	return list, r.err
+}
+
+func (r *memory{{.Type}}Repository) Find(ctx context.Context, id int64) (*models.{{.Type}}, error) {
+	return r.rows[id], r.err
+}
+
+func (r *memory{{.Type}}Repository) Insert(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	r.transactional = dbtrxn.Retrieve(ctx) != nil
+	if r.err != nil {
+		return {{.Var}}, r.err
+	}
+	r.nextID++
+	{{.Var}}.ID = r.nextID
+	r.rows[{{.Var}}.ID] = {{.Var}}
+	return {{.Var}}, nil
+}
+
+func (r *memory{{.Type}}Repository) Update(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	r.transactional = dbtrxn.Retrieve(ctx) != nil
+	if r.err != nil {
+		return {{.Var}}, r.err
+	}
+	r.rows[{{.Var}}.ID] = {{.Var}}
+	return {{.Var}}, nil
+}
+
+func (r *memory{{.Type}}Repository) Delete(ctx context.Context, id int64) error {
+	r.transactional = dbtrxn.Retrieve(ctx) != nil
+	if r.err != nil {
+		return r.err
+	}
+	delete(r.rows, id)
+	return nil
+}
+
+func Test{{.Type}}ServiceCRUD(t *testing.T) {
+	ctx := context.Background()
+	repository := newMemory{{.Type}}Repository()
+	service := New{{.Type}}Service(repository)
+
+	{{.Var}}, err := service.Create{{.Type}}(ctx, &models.{{.Type}}{})
+	if err != nil || {{.Var}}.ID != 1 {
+		t.Fatalf("create: %+v, %v", {{.Var}}, err)
+	}
+	if !repository.transactional {
+		t.Error("create is not transactional")
+	}
+
+	found, err := service.Get{{.Type}}(ctx, {{.Var}}.ID)
+	if err != nil || found != {{.Var}} {
+		t.Fatalf("get: %+v, %v", found, err)
+	}
+
+	repository.transactional = false
+	if _, err := service.Update{{.Type}}(ctx, {{.Var}}); err != nil || !repository.transactional {
+		t.Fatalf("update: %v, transactional %v", err, repository.transactional)
+	}
+
+	list, err := service.List{{.Type}}(ctx, &models.{{.Type}}Filter{})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list: %d, %v", len(list), err)
+	}
+
+	repository.transactional = false
+	if err := service.Delete{{.Type}}(ctx, {{.Var}}.ID); err != nil || !repository.transactional {
+		t.Fatalf("delete: %v, transactional %v", err, repository.transactional)
+	}
+	if found, _ := service.Get{{.Type}}(ctx, {{.Var}}.ID); found != nil {
+		t.Errorf("deleted {{.Human}} still found: %+v", found)
+	}
+}
+
+func Test{{.Type}}ServiceConflict(t *testing.T) {
+	repository := newMemory{{.Type}}Repository()
+	repository.err = Err{{.Type}}Conflict
+	service := New{{.Type}}Service(repository)
+
+	_, err := service.Create{{.Type}}(context.Background(), &models.{{.Type}}{})
+	if !errors.Is(err, Err{{.Type}}Conflict) {
+		t.Errorf("got %v, want %v", err, Err{{.Type}}Conflict)
+	}
+}
+`
+
+const controllerTemplate = `package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"{{.Module}}/server/{{.Dir}}/models"
+	"{{.Module}}/server/{{.Dir}}/service"
+	"{{.Module}}/util/validation"
+	"github.com/labstack/echo/v4"
+)
+
+//Init{{.Type}}Controller struct
+type Init{{.Type}}Controller struct {
+	Service *Init{{.Type}}ServiceInterface
+}
+
+//Init{{.Type}}ServiceInterface struct
+type Init{{.Type}}ServiceInterface struct {
+	{{.Type}} service.{{.Type}}Service
+}
+
+//New{{.Type}}Routes func
+func New{{.Type}}Routes({{.Var}}Service service.{{.Type}}Service) func(g *echo.Group) {
+	{{.Var}}Server := &Init{{.Type}}Controller{
+		Service: &Init{{.Type}}ServiceInterface{
+			{{.Type}}: {{.Var}}Service,
+		},
+	}
+
+	return func(g *echo.Group) {
+		g.GET("/{{.Name}}", {{.Var}}Server.GetList{{.Type}})
+		g.GET("/{{.Name}}/:id", {{.Var}}Server.Get{{.Type}})
+		g.POST("/{{.Name}}", {{.Var}}Server.Create{{.Type}})
+		g.PUT("/{{.Name}}", {{.Var}}Server.Update{{.Type}})
+		g.DELETE("/{{.Name}}/:id", {{.Var}}Server.Delete{{.Type}})
+	}
+}
+
+// GetList{{.Type}} godoc
+// @Summary Get list of {{.Human}}
+// @Description Get list of {{.Human}} item
+// @Tags {{.Name}}
+// @Accept */*
+// @Produce json
+{{- range .Fields}}{{if .Filter}}
+// @Param {{.Column}} query string false "{{.GoName}} contains"
+{{- end}}{{end}}
+// @Success 200 {object} models.SuccessResponseList
+// @Failure 500 {object} models.SuccessResponseList
+// @Router /{{.Name}} [get]
+// GetList{{.Type}} func
+func (init *Init{{.Type}}Controller) GetList{{.Type}}(ctx echo.Context) error {
+	filter := new(models.{{.Type}}Filter)
+	err := (&echo.DefaultBinder{}).BindQueryParams(ctx, filter)
+	if err != nil {
+		data := &models.SuccessResponseList{
+			Status:  400,
+			Message: err.Error(),
+			Data:    make([]*models.{{.Type}}, 0),
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	list, err := init.Service.{{.Type}}.List{{.Type}}(ctx.Request().Context(), filter)
+	if err != nil {
+		data := &models.SuccessResponseList{
+			Status:  500,
+			Message: "failed",
+			Data:    make([]*models.{{.Type}}, 0),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponseList{
+		Status:  200,
+		Message: "success",
+		Data:    list,
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// Get{{.Type}} godoc
+// @Summary Get a {{.Human}}
+// @Description Get a {{.Human}} item
+// @Tags {{.Name}}
+// @Accept */*
+// @Produce json
+// @Param id path integer true "{{.Type}} ID"
+// @Success 200 {object} models.SuccessResponseObject
+// @Failure 404 {object} models.SuccessResponseObject
+// @Failure 500 {object} models.SuccessResponseObject
+// @Router /{{.Name}}/{id} [get]
+//Get{{.Type}} func
+func (init *Init{{.Type}}Controller) Get{{.Type}}(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	{{.Var}}, err := init.Service.{{.Type}}.Get{{.Type}}(ctx.Request().Context(), id)
+	if err != nil {
+		data := &models.SuccessResponseObject{
+			Status:  500,
+			Message: "failed",
+			Data:    new(models.{{.Type}}),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+	if {{.Var}} == nil {
+		data := &models.SuccessResponseObject{
+			Status:  404,
+			Message: "{{.Human}} not found",
+			Data:    new(models.{{.Type}}),
+		}
+
+		return ctx.JSON(http.StatusNotFound, data)
+	}
+
+	data := &models.SuccessResponseObject{
+		Status:  200,
+		Message: "success",
+		Data:    {{.Var}},
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// Create{{.Type}} godoc
+// @Summary Create a {{.Human}}
+// @Description Create a new {{.Human}} item
+// @Tags {{.Name}}
+// @Accept json
+// @Produce json
+// @Param {{.Var}} body models.{{.Type}} true "Param {{.Type}}"
+// @Param Idempotency-Key header string false "Key replaying the response of a retried request"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
+// @Router /{{.Name}} [post]
+//Create{{.Type}} func
+func (init *Init{{.Type}}Controller) Create{{.Type}}(ctx echo.Context) error {
+	var {{.Var}} *models.{{.Type}}
+	err := ctx.Bind(&{{.Var}})
+	if err == nil && {{.Var}} == nil {
+		err = errors.New("missing {{.Human}}")
+	}
+	if err == nil {
+		{{.Var}}.Normalize()
+		err = {{.Var}}.Validate()
+	}
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	{{.Var}}, err = init.Service.{{.Type}}.Create{{.Type}}(ctx.Request().Context(), {{.Var}})
+	if errors.Is(err, service.Err{{.Type}}Conflict) {
+		data := &models.SuccessResponse{
+			Status:  409,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusConflict, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  201,
+		Message: fmt.Sprintf("Create {{.Human}} success #%d", {{.Var}}.ID),
+	}
+
+	return ctx.JSON(http.StatusCreated, data)
+}
+
+// Update{{.Type}} godoc
+// @Summary Update a {{.Human}}
+// @Description Update a {{.Human}} item
+// @Tags {{.Name}}
+// @Accept json
+// @Produce json
+// @Param {{.Var}} body models.{{.Type}} true "Param {{.Type}}"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.SuccessResponse
+// @Failure 409 {object} models.SuccessResponse
+// @Failure 500 {object} models.SuccessResponse
+// @Router /{{.Name}} [put]
+//Update{{.Type}} func
+func (init *Init{{.Type}}Controller) Update{{.Type}}(ctx echo.Context) error {
+	var {{.Var}} *models.{{.Type}}
+	err := ctx.Bind(&{{.Var}})
+	if err == nil && {{.Var}} == nil {
+		err = errors.New("missing {{.Human}}")
+	}
+	if err == nil {
+		{{.Var}}.Normalize()
+		err = {{.Var}}.Validate()
+	}
+	if err != nil {
+		errs := validation.TranslateRequest(err, ctx.Request())
+		data := &models.SuccessResponse{
+			Status:  400,
+			Message: errs.Error(),
+			Errors:  errs,
+		}
+
+		return ctx.JSON(http.StatusBadRequest, data)
+	}
+
+	_, err = init.Service.{{.Type}}.Update{{.Type}}(ctx.Request().Context(), {{.Var}})
+	if errors.Is(err, service.Err{{.Type}}Conflict) {
+		data := &models.SuccessResponse{
+			Status:  409,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusConflict, data)
+	}
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: err.Error(),
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  200,
+		Message: "success",
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// Delete{{.Type}} godoc
+// @Summary Delete a {{.Human}}
+// @Description Delete a {{.Human}} item
+// @Tags {{.Name}}
+// @Accept */*
+// @Produce json
+// @Param id path integer true "{{.Type}} ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 500 {object} models.SuccessResponse
+// @Router /{{.Name}}/{id} [delete]
+//Delete{{.Type}} func
+func (init *Init{{.Type}}Controller) Delete{{.Type}}(ctx echo.Context) error {
+	idParse := ctx.Param("id")
+	id, _ := strconv.ParseInt(idParse, 10, 64)
+
+	err := init.Service.{{.Type}}.Delete{{.Type}}(ctx.Request().Context(), id)
+	if err != nil {
+		data := &models.SuccessResponse{
+			Status:  500,
+			Message: "failed",
+		}
+
+		return ctx.JSON(http.StatusInternalServerError, data)
+	}
+
+	data := &models.SuccessResponse{
+		Status:  200,
+		Message: "success",
+	}
+
+	return ctx.JSON(http.StatusOK, data)
+}
+`
+
+const controllerTestTemplate = `package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+{{- if .HasType "time.Time"}}
+	"time"
+{{- end}}
+
+	"{{.Module}}/server/{{.Dir}}/models"
+	"{{.Module}}/server/{{.Dir}}/service"
+	"github.com/labstack/echo/v4"
+)
+
+// stub{{.Type}}Service serve a single {{.Human}} of ID 1
+type stub{{.Type}}Service struct {
+	service.{{.Type}}Service
+	created *models.{{.Type}}
+	err     error
+}
+
+func (s *stub{{.Type}}Service) List{{.Type}}(ctx context.Context, filter *models.{{.Type}}Filter) ([]*models.{{.Type}}, error) {
+	return []*models.{{.Type}}{sample{{.Type}}()}, s.err
+}
+
+func (s *stub{{.Type}}Service) Get{{.Type}}(ctx context.Context, id int64) (*models.{{.Type}}, error) {
+	if id != 1 {
+		return nil, s.err
+	}
+	return sample{{.Type}}(), s.err
+}
+
+func (s *stub{{.Type}}Service) Create{{.Type}}(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	{{.Var}}.ID = 1
+	s.created = {{.Var}}
+	return {{.Var}}, s.err
+}
+
+func (s *stub{{.Type}}Service) Update{{.Type}}(ctx context.Context, {{.Var}} *models.{{.Type}}) (*models.{{.Type}}, error) {
+	return {{.Var}}, s.err
+}
+
+func (s *stub{{.Type}}Service) Delete{{.Type}}(ctx context.Context, id int64) error {
+	return s.err
+}
+
+// sample{{.Type}} is a valid {{.Human}}
+func sample{{.Type}}() *models.{{.Type}} {
+	return &models.{{.Type}}{
+		ID: 1,
+{{- range .Fields}}
+		{{.GoName}}: {{.Sample}},
+{{- end}}
+	}
+}
+
+// serve{{.Type}} run a request against the {{.Human}} routes
+func serve{{.Type}}(s service.{{.Type}}Service, method, target, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	New{{.Type}}Routes(s)(e.Group(""))
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test{{.Type}}Routes(t *testing.T) {
+	body, err := json.Marshal(sample{{.Type}}())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"list", http.MethodGet, "/{{.Name}}", "", http.StatusOK},
+		{"get", http.MethodGet, "/{{.Name}}/1", "", http.StatusOK},
+		{"get unknown", http.MethodGet, "/{{.Name}}/2", "", http.StatusNotFound},
+		{"create", http.MethodPost, "/{{.Name}}", string(body), http.StatusCreated},
+		{"create malformed", http.MethodPost, "/{{.Name}}", "{", http.StatusBadRequest},
+		{"create empty", http.MethodPost, "/{{.Name}}", "null", http.StatusBadRequest},
+		{"update", http.MethodPut, "/{{.Name}}", string(body), http.StatusOK},
+		{"delete", http.MethodDelete, "/{{.Name}}/1", "", http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve{{.Type}}(&stub{{.Type}}Service{}, test.method, test.target, test.body)
+			if rec.Code != test.status {
+				t.Errorf("got %d, want %d: %s", rec.Code, test.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test{{.Type}}Create(t *testing.T) {
+	body, _ := json.Marshal(sample{{.Type}}())
+	s := &stub{{.Type}}Service{}
+	serve{{.Type}}(s, http.MethodPost, "/{{.Name}}", string(body))
+
+	if s.created == nil || *s.created != *sample{{.Type}}() {
+		t.Errorf("got %+v, want %+v", s.created, sample{{.Type}}())
+	}
+}
+{{- if .Required}}
+
+func Test{{.Type}}CreateInvalid(t *testing.T) {
+	rec := serve{{.Type}}(&stub{{.Type}}Service{}, http.MethodPost, "/{{.Name}}", "{}")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+}
+{{- end}}
+
+func Test{{.Type}}Conflict(t *testing.T) {
+	body, _ := json.Marshal(sample{{.Type}}())
+	rec := serve{{.Type}}(&stub{{.Type}}Service{err: service.Err{{.Type}}Conflict}, http.MethodPost, "/{{.Name}}",
+		string(body))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("got %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body.String())
+	}
+}
+`
+
+const migrationUpTemplate = `BEGIN;
+
+CREATE TABLE {{.Table}} (
+ id serial PRIMARY KEY,
+ tenant_id VARCHAR (63) NOT NULL REFERENCES tenants (id),
+{{- range .Fields}}
+ {{.Column}} {{.SQLType}},
+{{- end}}
+ updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX {{.Table}}_tenant_id_idx ON {{.Table}} (tenant_id, id);
+
+ALTER TABLE {{.Table}} ENABLE ROW LEVEL SECURITY;
+ALTER TABLE {{.Table}} FORCE ROW LEVEL SECURITY;
+CREATE POLICY {{.Table}}_tenant_isolation ON {{.Table}}
+  USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+COMMIT;
+`
+
+const migrationDownTemplate = `BEGIN;
+
+DROP TABLE IF EXISTS {{.Table}};
+
+COMMIT;
+`
+
+const moduleTemplate = `package application
+
+import (
+	{{.Var}}Controller "{{.Module}}/server/{{.Dir}}/controller"
+	{{.Var}}Repository "{{.Module}}/server/{{.Dir}}/repository"
+	{{.Var}}Service "{{.Module}}/server/{{.Dir}}/service"
+	"{{.Module}}/util/apiversion"
+)
+
+//{{.Type}}Module struct serve the {{.Human}} resource
+type {{.Type}}Module struct {
+	service {{.Var}}Service.{{.Type}}Service
+}
+
+//New{{.Type}}Module func
+func New{{.Type}}Module() *{{.Type}}Module {
+	return &{{.Type}}Module{}
+}
+
+//Name func
+func (m *{{.Type}}Module) Name() string {
+	return "{{.Name}}"
+}
+
+{{if .DefaultMigrationDir -}}
+//Migrations func return DB_MIGRATION_{{.EnvName}}_SRC, or the {{.MigrationDir}} directory
+func (m *{{.Type}}Module) Migrations() string {
+	return migrationSource(m.Name())
+}
+{{- else -}}
+//Migrations func return the {{.MigrationDir}} directory
+func (m *{{.Type}}Module) Migrations() string {
+	return "file://{{.MigrationDir}}"
+}
+{{- end}}
+
+//Setup func
+func (m *{{.Type}}Module) Setup(deps *Deps) error {
+	m.service = {{.Var}}Service.New{{.Type}}Service({{.Var}}Repository.New{{.Type}}Repository(deps.Router))
+	return nil
+}
+
+//Routes func
+func (m *{{.Type}}Module) Routes(version string) []apiversion.Routes {
+	if version != "v1" {
+		return nil
+	}
+	return []apiversion.Routes{ {{- .Var}}Controller.New{{.Type}}Routes(m.service)}
+}
+`
@@ -0,0 +1,175 @@
+package servertls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+)
+
+// Reloader serve the certificate and the client CA bundle of the files of a Config, the files are loaded again
+// once they are rotated so the connections opened after a rotation use the new certificate
+type Reloader struct {
+	config Config
+	stop   chan bool
+	done   chan bool
+
+	sync.RWMutex
+	current  *tls.Config
+	modTimes []time.Time
+}
+
+// NewReloader return a Reloader of the files of config, the files must be valid
+func NewReloader(config Config) (*Reloader, error) {
+	r := &Reloader{
+		config: config,
+		stop:   make(chan bool),
+		done:   make(chan bool),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig return the configuration of the listener, every handshake use the last loaded files
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:   r.config.MinVersion,
+		CipherSuites: r.config.CipherSuites,
+		// the configurations of the handshakes keep HTTP/2
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.get().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.get(), nil
+		},
+	}
+}
+
+func (r *Reloader) get() *tls.Config {
+	r.RLock()
+	defer r.RUnlock()
+	return r.current
+}
+
+// Reload load the files, the previous files are kept on error
+func (r *Reloader) Reload() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	config := &tls.Config{
+		MinVersion:   r.config.MinVersion,
+		CipherSuites: r.config.CipherSuites,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.config.ClientAuth,
+	}
+	if r.config.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("servertls: no certificate in " + r.config.ClientCAFile)
+		}
+	}
+
+	r.Lock()
+	r.current = config
+	r.modTimes = modTimes
+	r.Unlock()
+	return nil
+}
+
+// stat return the modification times of the files
+func (r *Reloader) stat() ([]time.Time, error) {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+
+	modTimes := make([]time.Time, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// changed tell whether a file was modified since the last load
+func (r *Reloader) changed() bool {
+	modTimes, err := r.stat()
+	if err != nil {
+		// a rotation in progress, the next check load the new files
+		return false
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+	for i, modTime := range modTimes {
+		if !modTime.Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Start checking the files for a rotation in the background
+func (r *Reloader) Start() error {
+	go r.run()
+	return nil
+}
+
+// Stop checking the files
+func (r *Reloader) Stop() error {
+	r.stop <- true
+	<-r.done
+	return nil
+}
+
+func (r *Reloader) run() {
+	defer close(r.done)
+
+	if r.config.ReloadInterval <= 0 {
+		<-r.stop
+		return
+	}
+
+	logger := util.Log.WithField("context", "servertls")
+	ticker := time.NewTicker(r.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				logger.Errorf("reload certificate, the previous one is kept: %v", err)
+				continue
+			}
+			logger.Infof("certificate %s reloaded", r.config.CertFile)
+		}
+	}
+}
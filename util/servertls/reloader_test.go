@@ -0,0 +1,306 @@
+package servertls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/go-rest-api-boilerplate/util/auth"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// authority sign the certificates of the tests
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &authority{
+		cert: cert,
+		key:  key,
+		pool: pool,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue return the PEM certificate and key of a leaf named name
+func (a *authority) issue(t *testing.T, name string, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// files hold the certificate files of a Config in a temporary directory
+type files struct {
+	Config
+	root string
+	// rotations move the modification times forward, some file systems have a coarse resolution
+	rotations int
+}
+
+func newFiles(t *testing.T) *files {
+	root, err := ioutil.TempDir("", "servertls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig
+	config.CertFile = filepath.Join(root, "tls.crt")
+	config.KeyFile = filepath.Join(root, "tls.key")
+	return &files{Config: config, root: root}
+}
+
+func (f *files) write(t *testing.T, cert, key []byte) {
+	f.rotations++
+	modTime := time.Now().Add(time.Duration(f.rotations) * time.Second)
+	for name, data := range map[string][]byte{f.CertFile: cert, f.KeyFile: key} {
+		if err := ioutil.WriteFile(name, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// rotate write a server certificate of ca for localhost
+func (f *files) rotate(t *testing.T, ca *authority, number int64) {
+	cert, key := ca.issue(t, "localhost", number, x509.ExtKeyUsageServerAuth)
+	f.write(t, cert, key)
+}
+
+// serial return the serial number of the certificate served by a handshake with config
+func serial(t *testing.T, config *tls.Config, pool *x509.CertPool) int64 {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{RootCAs: pool, ServerName: "localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestReloader(t *testing.T) {
+	util.Log = logrus.New()
+
+	ca := newAuthority(t)
+	f := newFiles(t)
+	defer os.RemoveAll(f.root)
+
+	if _, err := NewReloader(f.Config); err == nil {
+		t.Fatal("got a reloader of missing files")
+	}
+
+	f.rotate(t, ca, 2)
+	reloader, err := NewReloader(f.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := reloader.TLSConfig()
+	if got := serial(t, config, ca.pool); got != 2 {
+		t.Fatalf("got certificate %d, want 2", got)
+	}
+	if reloader.changed() {
+		t.Error("got the loaded files changed")
+	}
+
+	// the handshakes after a rotation serve the new certificate
+	f.rotate(t, ca, 3)
+	if !reloader.changed() {
+		t.Fatal("got the rotated files unchanged")
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := serial(t, config, ca.pool); got != 3 {
+		t.Errorf("got certificate %d, want the rotated 3", got)
+	}
+
+	// a broken rotation keep the previous certificate
+	cert, _ := ca.issue(t, "localhost", 4, x509.ExtKeyUsageServerAuth)
+	_, key := ca.issue(t, "localhost", 5, x509.ExtKeyUsageServerAuth)
+	f.write(t, cert, key)
+	if err := reloader.Reload(); err == nil {
+		t.Error("got a mismatched key loaded")
+	}
+	if got := serial(t, config, ca.pool); got != 3 {
+		t.Errorf("got certificate %d, want the previous 3", got)
+	}
+}
+
+func TestReloaderStart(t *testing.T) {
+	util.Log = logrus.New()
+
+	ca := newAuthority(t)
+	f := newFiles(t)
+	defer os.RemoveAll(f.root)
+	f.ReloadInterval = 10 * time.Millisecond
+
+	f.rotate(t, ca, 2)
+	reloader, err := NewReloader(f.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader.Start()
+	defer reloader.Stop()
+
+	f.rotate(t, ca, 3)
+	config := reloader.TLSConfig()
+	for deadline := time.Now().Add(5 * time.Second); serial(t, config, ca.pool) != 3; {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated certificate was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloaderGRPC(t *testing.T) {
+	util.Log = logrus.New()
+
+	ca := newAuthority(t)
+	f := newFiles(t)
+	defer os.RemoveAll(f.root)
+	f.rotate(t, ca, 2)
+	f.ClientAuth = tls.VerifyClientCertIfGiven
+	f.ClientCAFile = filepath.Join(f.root, "ca.crt")
+	if err := ioutil.WriteFile(f.ClientCAFile, ca.pem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	reloader, err := NewReloader(f.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the actor of the calls is the principal of their client certificate
+	var mu sync.Mutex
+	var actors []string
+	record := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		mu.Lock()
+		actors = append(actors, util.SessionActor(ctx))
+		mu.Unlock()
+		return handler(ctx, req)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.TLSConfig())), util.UnaryInterceptors(),
+		grpc.ChainUnaryInterceptor(auth.UnaryInterceptor(auth.NewAuthenticator(auth.DefaultConfig)), record))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	check := func(creds credentials.TransportCredentials) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		return err
+	}
+
+	certPEM, keyPEM := ca.issue(t, "billing", 3, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = check(credentials.NewTLS(&tls.Config{RootCAs: ca.pool, ServerName: "localhost",
+		Certificates: []tls.Certificate{clientCert}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	if len(actors) != 1 || actors[0] != "CN=billing" {
+		t.Errorf("got actors %v, want the certificate subject", actors)
+	}
+	mu.Unlock()
+
+	// the calls without certificate nor token are rejected
+	err = check(credentials.NewTLS(&tls.Config{RootCAs: ca.pool, ServerName: "localhost"}))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("got %v, want Unauthenticated", err)
+	}
+	// the plaintext calls do not reach the server
+	if err := check(insecure.NewCredentials()); err == nil || status.Code(err) == codes.Unauthenticated {
+		t.Errorf("got %v, want the plaintext call refused", err)
+	}
+}
@@ -0,0 +1,137 @@
+package servertls
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrCipherSuites is returned when TLS_CIPHER_SUITES names an unknown or insecure suite
+var ErrCipherSuites = errors.New("servertls: unknown cipher suite")
+
+// Config of the TLS listener of the API, TLS is off when CertFile is empty
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// MinVersion default to TLS 1.2
+	MinVersion uint16
+	// CipherSuites of TLS 1.2, the Go defaults when empty. TLS 1.3 suites are not configurable
+	CipherSuites []uint16
+	// ClientAuth verify the client certificates against the ClientCAFile bundle
+	ClientAuth   tls.ClientAuthType
+	ClientCAFile string
+	// ReloadInterval is the period the files are checked for a rotation, the reload is off when 0
+	ReloadInterval time.Duration
+	// RedirectPort serve a redirection to HTTPS over plain HTTP, off when empty
+	RedirectPort string
+}
+
+// DefaultConfig accept TLS 1.2 and later and check the files for a rotation every minute
+var DefaultConfig = Config{
+	MinVersion:     tls.VersionTLS12,
+	ClientAuth:     tls.NoClientCert,
+	ReloadInterval: time.Minute,
+}
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuths = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// cipherSuites are the TLS 1.2 suites with forward secrecy and authenticated encryption
+var cipherSuites = map[string]uint16{
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256":       tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384":       tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256": tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256":   tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// ConfigFromEnv override DefaultConfig with TLS_CERT_FILE, TLS_KEY_FILE, TLS_MIN_VERSION, TLS_CIPHER_SUITES,
+// TLS_CLIENT_AUTH, TLS_CLIENT_CA_FILE, TLS_RELOAD_INTERVAL and TLS_REDIRECT_PORT
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	config.CertFile = os.Getenv("TLS_CERT_FILE")
+	config.KeyFile = os.Getenv("TLS_KEY_FILE")
+	config.ClientCAFile = os.Getenv("TLS_CLIENT_CA_FILE")
+	config.RedirectPort = os.Getenv("TLS_REDIRECT_PORT")
+
+	if v := os.Getenv("TLS_MIN_VERSION"); v != "" {
+		version, ok := versions[v]
+		if !ok {
+			return config, fmt.Errorf("servertls: unknown TLS_MIN_VERSION %q", v)
+		}
+		config.MinVersion = version
+	}
+	if v := os.Getenv("TLS_CIPHER_SUITES"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			suite, ok := cipherSuites[strings.TrimSpace(name)]
+			if !ok {
+				return config, fmt.Errorf("%w %q", ErrCipherSuites, strings.TrimSpace(name))
+			}
+			config.CipherSuites = append(config.CipherSuites, suite)
+		}
+	}
+	if v := os.Getenv("TLS_CLIENT_AUTH"); v != "" {
+		clientAuth, ok := clientAuths[v]
+		if !ok {
+			return config, fmt.Errorf("servertls: unknown TLS_CLIENT_AUTH %q", v)
+		}
+		config.ClientAuth = clientAuth
+	}
+	if v, err := time.ParseDuration(os.Getenv("TLS_RELOAD_INTERVAL")); err == nil && v >= 0 {
+		config.ReloadInterval = v
+	}
+
+	return config, config.validate()
+}
+
+// Enabled tell whether the API is served over TLS
+func (c Config) Enabled() bool {
+	return c.CertFile != ""
+}
+
+func (c Config) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.KeyFile == "" {
+		return errors.New("servertls: TLS_KEY_FILE is required with TLS_CERT_FILE")
+	}
+	if c.ClientAuth >= tls.VerifyClientCertIfGiven && c.ClientCAFile == "" {
+		return errors.New("servertls: TLS_CLIENT_CA_FILE is required to verify the client certificates")
+	}
+	return nil
+}
+
+// RedirectHandler redirect the plain HTTP requests to the same URL over HTTPS on httpsPort
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		// 308 keep the method and the body of the request
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
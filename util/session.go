@@ -0,0 +1,58 @@
+package util
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Session headers, HeaderActor is the actor the client claims and never authenticates it
+const (
+	HeaderRequestID = echo.HeaderXRequestID
+	HeaderActor     = "X-Actor"
+
+	// AnonymousActor is the actor of requests without identity
+	AnonymousActor = "anonymous"
+)
+
+//SessionMiddleware func, the request is anonymous until an authentication middleware sets its principal
+func SessionMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			session := NewSession(req.Header.Get(HeaderRequestID), AnonymousActor)
+			session.ClaimedActor = req.Header.Get(HeaderActor)
+
+			c.Response().Header().Set(HeaderRequestID, session.CID)
+			c.SetRequest(req.WithContext(context.WithValue(req.Context(), SessionKey, session)))
+
+			return next(c)
+		}
+	}
+}
+
+//NewSession func return the session of a request, a missing request id is generated and a missing actor is anonymous
+func NewSession(cid, actor string) *Session {
+	if cid == "" {
+		cid = newRequestID()
+	}
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	return &Session{
+		CID:    cid,
+		Actor:  actor,
+		Logger: Log.WithField("cid", cid).WithField("actor", actor),
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,335 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Sources of the tenant of a request. The header and subdomain sources are not verified, they are only
+// safe behind a trusted proxy setting them after checking the membership of the caller
+const (
+	SourceToken     = "token"
+	SourceHeader    = "header"
+	SourceSubdomain = "subdomain"
+)
+
+// DefaultHeader carry the tenant id of a request
+const DefaultHeader = "X-Tenant-ID"
+
+var (
+	// ErrConflict is returned when the sources of a request name different tenants
+	ErrConflict = errors.New("tenant: the request names several tenants")
+	// ErrUnknown is returned when the tenant does not exist or is disabled
+	ErrUnknown = errors.New("tenant: unknown or disabled tenant")
+)
+
+type (
+	// Config of the tenant resolution
+	Config struct {
+		// Sources are tried in order, the first tenant found wins and the others must agree
+		Sources []string
+		Header  string
+		// BaseDomain resolve "<tenant>.<BaseDomain>" hosts, the subdomain source is off when empty
+		BaseDomain string
+		// TokenSecret verify the HS256 bearer tokens, the token source is off when empty
+		TokenSecret []byte
+		TokenClaim  string
+		// Default is the tenant of the requests naming none, they are rejected when empty
+		Default string
+		// RowLevelSecurity connect the API with DriverName, its statements see the rows of their tenant only
+		RowLevelSecurity bool
+		// CacheTTL of the active tenants, a disabled tenant is rejected after at most CacheTTL
+		CacheTTL time.Duration
+	}
+
+	// Store tell whether a tenant exists and is active
+	Store interface {
+		Active(ctx context.Context, id string) (bool, error)
+	}
+
+	// Resolver resolve the tenant of the requests
+	Resolver struct {
+		config Config
+		store  Store
+
+		sync.Mutex
+		active map[string]time.Time
+	}
+
+	// errorResponse is the body of a rejected request
+	errorResponse struct {
+		Status  int64  `json:"status"`
+		Message string `json:"message"`
+	}
+)
+
+// DefaultConfig resolve the tenant from the signed token only, the header and subdomain sources are opted
+// in with TENANT_SOURCES
+var DefaultConfig = Config{
+	Sources:    []string{SourceToken},
+	Header:     DefaultHeader,
+	TokenClaim: "tenant_id",
+	CacheTTL:   30 * time.Second,
+}
+
+// ConfigFromEnv override DefaultConfig with TENANT_SOURCES, TENANT_HEADER, TENANT_BASE_DOMAIN,
+// TENANT_TOKEN_SECRET, TENANT_TOKEN_CLAIM, TENANT_DEFAULT, TENANT_RLS and TENANT_CACHE_TTL
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if v := os.Getenv("TENANT_SOURCES"); v != "" {
+		config.Sources = nil
+		for _, source := range strings.Split(v, ",") {
+			if source = strings.TrimSpace(source); source != "" {
+				config.Sources = append(config.Sources, source)
+			}
+		}
+	}
+	if v := os.Getenv("TENANT_HEADER"); v != "" {
+		config.Header = v
+	}
+	config.BaseDomain = strings.ToLower(strings.Trim(os.Getenv("TENANT_BASE_DOMAIN"), "."))
+	config.TokenSecret = []byte(os.Getenv("TENANT_TOKEN_SECRET"))
+	if v := os.Getenv("TENANT_TOKEN_CLAIM"); v != "" {
+		config.TokenClaim = v
+	}
+	config.Default = os.Getenv("TENANT_DEFAULT")
+	config.RowLevelSecurity, _ = strconv.ParseBool(os.Getenv("TENANT_RLS"))
+	if v, err := time.ParseDuration(os.Getenv("TENANT_CACHE_TTL")); err == nil && v >= 0 {
+		config.CacheTTL = v
+	}
+	return config
+}
+
+// NewResolver return a Resolver accepting the active tenants of store
+func NewResolver(config Config, store Store) *Resolver {
+	return &Resolver{
+		config: config,
+		store:  store,
+		active: make(map[string]time.Time),
+	}
+}
+
+// Resolve return the active tenant named by the header, authorization and host of a request
+func (r *Resolver) Resolve(ctx context.Context, header func(name string) string, host string) (string, error) {
+	var id string
+	for _, source := range r.config.Sources {
+		var candidate string
+		switch source {
+		case SourceToken:
+			if len(r.config.TokenSecret) == 0 {
+				continue
+			}
+			token := header("Authorization")
+			if !strings.HasPrefix(token, "Bearer ") {
+				continue
+			}
+			var err error
+			candidate, err = ParseToken(r.config.TokenSecret, r.config.TokenClaim, strings.TrimPrefix(token, "Bearer "))
+			if err != nil {
+				return "", err
+			}
+		case SourceHeader:
+			candidate = strings.TrimSpace(header(r.config.Header))
+		case SourceSubdomain:
+			candidate = r.subdomain(host)
+		}
+
+		if candidate == "" {
+			continue
+		}
+		if id != "" && candidate != id {
+			return "", ErrConflict
+		}
+		id = candidate
+	}
+
+	if id == "" {
+		id = r.config.Default
+	}
+	if id == "" {
+		return "", ErrMissing
+	}
+	if !Valid(id) {
+		return "", ErrInvalid
+	}
+
+	return id, r.check(ctx, id)
+}
+
+// subdomain return the tenant label of "<tenant>.<BaseDomain>"
+func (r *Resolver) subdomain(host string) string {
+	if r.config.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, "."+r.config.BaseDomain) {
+		return ""
+	}
+	label := strings.TrimSuffix(host, "."+r.config.BaseDomain)
+	if strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// check that the tenant is active, active tenants are cached for CacheTTL
+func (r *Resolver) check(ctx context.Context, id string) error {
+	r.Lock()
+	expiry, ok := r.active[id]
+	r.Unlock()
+	if ok && time.Now().Before(expiry) {
+		return nil
+	}
+
+	active, err := r.store.Active(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrUnknown
+	}
+
+	r.Lock()
+	r.active[id] = time.Now().Add(r.config.CacheTTL)
+	r.Unlock()
+	return nil
+}
+
+//Middleware func scope the request context to its tenant, the paths having one of the skipped prefixes
+//are not scoped
+func Middleware(resolver *Resolver, skip ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			for _, prefix := range skip {
+				if strings.HasPrefix(req.URL.Path, prefix) {
+					return next(c)
+				}
+			}
+
+			id, err := resolver.Resolve(req.Context(), req.Header.Get, req.Host)
+			if err != nil {
+				code := httpStatus(err)
+				message := err.Error()
+				if code == http.StatusInternalServerError {
+					util.SessionLogger(req.Context()).Errorf("resolve tenant: %v", err)
+					message = "failed"
+				}
+				return c.JSON(code, &errorResponse{Status: int64(code), Message: message})
+			}
+
+			c.SetRequest(req.WithContext(With(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+//UnaryInterceptor func scope the calls to the tenant of their metadata, the methods having one of the
+//skipped prefixes are not scoped
+func UnaryInterceptor(resolver *Resolver, skip ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skipped(info.FullMethod, skip) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := resolver.context(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+//StreamInterceptor func scope the streams to the tenant of their metadata
+func StreamInterceptor(resolver *Resolver, skip ...string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skipped(info.FullMethod, skip) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := resolver.context(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// tenantStream override the context of a server stream
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
+
+// context resolve the tenant of the incoming metadata of ctx
+func (r *Resolver) context(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := func(name string) string {
+		if values := md.Get(name); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	id, err := r.Resolve(ctx, header, header(":authority"))
+	if err != nil {
+		code := codes.Internal
+		switch httpStatus(err) {
+		case http.StatusInternalServerError:
+			util.SessionLogger(ctx).Errorf("resolve tenant: %v", err)
+			return ctx, status.Error(code, "failed")
+		case http.StatusBadRequest:
+			code = codes.InvalidArgument
+		case http.StatusUnauthorized:
+			code = codes.Unauthenticated
+		case http.StatusForbidden:
+			code = codes.PermissionDenied
+		}
+		return ctx, status.Error(code, err.Error())
+	}
+	return With(ctx, id), nil
+}
+
+func skipped(method string, skip []string) bool {
+	for _, prefix := range skip {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// httpStatus map a resolution error to its HTTP status
+func httpStatus(err error) int {
+	switch err {
+	case ErrMissing, ErrInvalid, ErrConflict:
+		return http.StatusBadRequest
+	case ErrInvalidToken:
+		return http.StatusUnauthorized
+	case ErrUnknown:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
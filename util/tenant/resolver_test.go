@@ -0,0 +1,227 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-rest-api-boilerplate/util"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var secret = []byte("test-secret")
+
+// stubStore know the active tenants and count the lookups
+type stubStore struct {
+	active  map[string]bool
+	err     error
+	lookups int
+}
+
+func (s *stubStore) Active(ctx context.Context, id string) (bool, error) {
+	s.lookups++
+	return s.active[id], s.err
+}
+
+func newStore() *stubStore {
+	return &stubStore{active: map[string]bool{"acme": true, "globex": true, "default": true}}
+}
+
+func bearer(t *testing.T, key []byte, id string) string {
+	token, err := SignToken(key, DefaultConfig.TokenClaim, id, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func TestResolve(t *testing.T) {
+	optIn := func(config Config) Config {
+		config.Sources = []string{SourceToken, SourceHeader, SourceSubdomain}
+		config.BaseDomain = "example.com"
+		return config
+	}
+	withDefault := func(config Config) Config {
+		config.Default = "default"
+		return config
+	}
+
+	tests := []struct {
+		name          string
+		config        func(Config) Config
+		authorization string
+		header        string
+		host          string
+		want          string
+		err           error
+	}{
+		{"token", nil, bearer(t, secret, "acme"), "", "", "acme", nil},
+		{"header ignored by default", nil, "", "acme", "acme.example.com", "", ErrMissing},
+		{"header not trusted over the token", nil, bearer(t, secret, "acme"), "globex", "", "acme", nil},
+		{"default", withDefault, "", "acme", "", "default", nil},
+		{"invalid token", withDefault, bearer(t, []byte("other"), "acme"), "", "", "", ErrInvalidToken},
+		{"disabled tenant", nil, bearer(t, secret, "initech"), "", "", "", ErrUnknown},
+		{"invalid id", nil, bearer(t, secret, "Acme"), "", "", "", ErrInvalid},
+		{"opted in header", optIn, "", "acme", "", "acme", nil},
+		{"opted in subdomain", optIn, "", "", "globex.example.com:8080", "globex", nil},
+		{"nested subdomain", optIn, "", "", "www.globex.example.com", "", ErrMissing},
+		{"other domain", optIn, "", "", "globex.example.org", "", ErrMissing},
+		{"agreeing sources", optIn, bearer(t, secret, "acme"), "acme", "acme.example.com", "acme", nil},
+		{"conflicting header", optIn, bearer(t, secret, "acme"), "globex", "", "", ErrConflict},
+		{"conflicting subdomain", optIn, "", "acme", "globex.example.com", "", ErrConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.TokenSecret = secret
+			if test.config != nil {
+				config = test.config(config)
+			}
+			header := func(name string) string {
+				switch name {
+				case "Authorization":
+					return test.authorization
+				case DefaultHeader:
+					return test.header
+				}
+				return ""
+			}
+
+			id, err := NewResolver(config, newStore()).Resolve(context.Background(), header, test.host)
+			if err != test.err {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if err == nil && id != test.want {
+				t.Errorf("got tenant %q, want %q", id, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	store := newStore()
+	config := DefaultConfig
+	config.Default = "acme"
+	config.CacheTTL = time.Hour
+	resolver := NewResolver(config, store)
+	header := func(string) string { return "" }
+
+	for i := 0; i < 3; i++ {
+		if _, err := resolver.Resolve(context.Background(), header, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.lookups != 1 {
+		t.Errorf("got %d lookups, want the active tenant cached", store.lookups)
+	}
+
+	// an inactive tenant is looked up again
+	store.active["acme"] = false
+	resolver = NewResolver(config, store)
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.Resolve(context.Background(), header, ""); err != ErrUnknown {
+			t.Fatalf("got %v, want ErrUnknown", err)
+		}
+	}
+	if store.lookups != 3 {
+		t.Errorf("got %d lookups, want the inactive tenant not cached", store.lookups)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	util.Log = logrus.New()
+
+	store := newStore()
+	config := DefaultConfig
+	config.TokenSecret = secret
+	e := echo.New()
+	e.Use(util.SessionMiddleware())
+	e.Use(Middleware(NewResolver(config, store), "/health"))
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, FromContext(c.Request().Context()))
+	}
+	e.GET("/books", handler)
+	e.GET("/health", handler)
+
+	serve := func(path, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+		req.Header.Set(DefaultHeader, "globex")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		code          int
+		body          string
+	}{
+		{"token", "/books", bearer(t, secret, "acme"), http.StatusOK, "acme"},
+		{"header only", "/books", "", http.StatusBadRequest, ""},
+		{"invalid token", "/books", "Bearer x.y.z", http.StatusUnauthorized, ""},
+		{"disabled tenant", "/books", bearer(t, secret, "initech"), http.StatusForbidden, ""},
+		{"skipped", "/health", "", http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(test.path, test.authorization)
+			if rec.Code != test.code {
+				t.Fatalf("got %d, want %d", rec.Code, test.code)
+			}
+			if test.code == http.StatusOK && rec.Body.String() != test.body {
+				t.Errorf("got tenant %q, want %q", rec.Body.String(), test.body)
+			}
+		})
+	}
+
+	store.err = errors.New("connection refused")
+	store.active = map[string]bool{}
+	if rec := serve("/books", bearer(t, secret, "globex")); rec.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, want a failed lookup hidden", rec.Code)
+	}
+}
+
+func TestContext(t *testing.T) {
+	util.Log = logrus.New()
+
+	config := DefaultConfig
+	config.TokenSecret = secret
+	resolver := NewResolver(config, newStore())
+
+	tests := []struct {
+		name          string
+		authorization string
+		code          codes.Code
+	}{
+		{"token", bearer(t, secret, "acme"), codes.OK},
+		{"missing", "", codes.InvalidArgument},
+		{"invalid token", "Bearer x.y.z", codes.Unauthenticated},
+		{"disabled tenant", bearer(t, secret, "initech"), codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			md := metadata.Pairs("x-tenant-id", "globex")
+			if test.authorization != "" {
+				md.Set("authorization", test.authorization)
+			}
+			ctx, err := resolver.context(metadata.NewIncomingContext(context.Background(), md))
+			if code := status.Code(err); code != test.code {
+				t.Fatalf("got %v, want %v", code, test.code)
+			}
+			if test.code == codes.OK && FromContext(ctx) != "acme" {
+				t.Errorf("got tenant %q, want acme", FromContext(ctx))
+			}
+		})
+	}
+}
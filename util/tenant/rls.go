@@ -0,0 +1,145 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Setting is the Postgres setting read by the row-level security policies
+const Setting = "app.tenant_id"
+
+// BypassRole see the rows of every tenant, the shared jobs connect with it
+const BypassRole = "tenant_bypass"
+
+// DriverName is the Postgres driver setting Setting to the tenant of the context of every statement. The
+// policies reject the rows of the other tenants, and every row when the context has no tenant
+const DriverName = "postgres-tenant"
+
+func init() {
+	sql.Register(DriverName, &rlsDriver{Driver: &pq.Driver{}})
+}
+
+type (
+	// rlsDriver open the connections of DriverName
+	rlsDriver struct {
+		driver.Driver
+	}
+
+	// rlsConn keep the tenant of its session, it is set again when a statement has another one. A
+	// transaction keep the tenant of the context it begins with
+	rlsConn struct {
+		driver.Conn
+		tenant string
+		scoped bool
+		inTx   bool
+	}
+
+	// rlsTx end the transaction of its connection
+	rlsTx struct {
+		driver.Tx
+		conn *rlsConn
+	}
+)
+
+var errUnsupported = errors.New("tenant: the connection does not support contexts")
+
+func (d *rlsDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &rlsConn{Conn: conn}, nil
+}
+
+// scope set the session to the tenant of ctx, outside of transactions
+func (c *rlsConn) scope(ctx context.Context) error {
+	id := FromContext(ctx)
+	if c.inTx || (c.scoped && c.tenant == id) {
+		return nil
+	}
+
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return errUnsupported
+	}
+	c.scoped = false
+	_, err := execer.ExecContext(ctx, "SELECT set_config($1, $2, false)", []driver.NamedValue{
+		{Ordinal: 1, Value: Setting},
+		{Ordinal: 2, Value: id},
+	})
+	if err != nil {
+		return err
+	}
+	c.tenant, c.scoped = id, true
+	return nil
+}
+
+func (c *rlsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, errUnsupported
+	}
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *rlsConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, errUnsupported
+	}
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+// PrepareContext scope the session of the statement to the tenant of ctx
+func (c *rlsConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *rlsConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	beginner, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		return nil, errUnsupported
+	}
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.inTx = true
+	return &rlsTx{Tx: tx, conn: c}, nil
+}
+
+func (c *rlsConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (tx *rlsTx) Commit() error {
+	tx.conn.inTx = false
+	return tx.Tx.Commit()
+}
+
+func (tx *rlsTx) Rollback() error {
+	tx.conn.inTx = false
+	return tx.Tx.Rollback()
+}
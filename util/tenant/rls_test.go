@@ -0,0 +1,149 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/go-rest-api-boilerplate/util/dbtrxn"
+)
+
+// recordingDriver record the statements of its connections, the scopes as "scope <tenant>"
+type recordingDriver struct {
+	statements []string
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) record(query string, args []driver.NamedValue) {
+	if query == "SELECT set_config($1, $2, false)" {
+		query = "scope " + args[1].Value.(string)
+	}
+	c.driver.statements = append(c.driver.statements, query)
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recordingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.record("BEGIN", nil)
+	return &recordingTx{conn: c}, nil
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	return &emptyRows{}, nil
+}
+
+type recordingTx struct {
+	conn *recordingConn
+}
+
+func (tx *recordingTx) Commit() error {
+	tx.conn.record("COMMIT", nil)
+	return nil
+}
+
+func (tx *recordingTx) Rollback() error {
+	tx.conn.record("ROLLBACK", nil)
+	return nil
+}
+
+type emptyRows struct{}
+
+func (r *emptyRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (r *emptyRows) Close() error {
+	return nil
+}
+
+func (r *emptyRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+func TestDriver(t *testing.T) {
+	recorder := &recordingDriver{}
+	sql.Register("tenant-recording", &rlsDriver{Driver: recorder})
+	db, err := sql.Open("tenant-recording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// a single session keep its tenant between the statements
+	db.SetMaxOpenConns(1)
+
+	acme := With(context.Background(), "acme")
+	query := func(ctx context.Context, query string) {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+
+	query(acme, "q1")
+	query(acme, "q2")
+	query(context.Background(), "q3")
+	if _, err := db.ExecContext(With(context.Background(), "beta"), "e1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction keep the tenant of its beginning, the statements without context do not reset it
+	tx, err := db.BeginTx(acme, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("e2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the statements outside of transactions run with the context of their handler
+	handler, err := dbtrxn.Use(With(context.Background(), "beta"), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := handler.DB.Query("q4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	want := []string{
+		"scope acme", "q1", "q2",
+		"scope ", "q3",
+		"scope beta", "e1",
+		"scope acme", "BEGIN", "e2", "COMMIT",
+		"scope beta", "q4",
+	}
+	if !reflect.DeepEqual(recorder.statements, want) {
+		t.Errorf("got statements %q, want %q", recorder.statements, want)
+	}
+}
@@ -0,0 +1,47 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+type key struct{}
+
+var (
+	// ErrMissing is returned when a tenant scoped operation runs without tenant
+	ErrMissing = errors.New("tenant: missing tenant")
+	// ErrInvalid is returned when a tenant id is not a lowercase DNS label
+	ErrInvalid = errors.New("tenant: invalid tenant id")
+)
+
+// idPattern keep the tenant ids usable as subdomains
+var idPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Valid report whether id is a valid tenant id
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// With return a context scoped to the tenant id
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext return the tenant of ctx, empty when ctx is not scoped
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+// Require return the tenant of ctx or ErrMissing, repositories never run unscoped queries
+func Require(ctx context.Context) (string, error) {
+	id := FromContext(ctx)
+	if id == "" {
+		return "", ErrMissing
+	}
+	return id, nil
+}
@@ -0,0 +1,89 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when a bearer token is malformed, expired or not signed with the secret
+var ErrInvalidToken = errors.New("tenant: invalid token")
+
+// tokenHeader is the only accepted JWT header, HS256
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SignToken return an HS256 JWT holding the tenant id in claim, it never expires when ttl is 0
+func SignToken(secret []byte, claim, id string, ttl time.Duration) (string, error) {
+	return SignClaims(secret, map[string]string{claim: id}, ttl)
+}
+
+// SignClaims return an HS256 JWT holding the string claims, such as the tenant and the subject of a client
+func SignClaims(secret []byte, values map[string]string, ttl time.Duration) (string, error) {
+	claims := map[string]interface{}{
+		"iat": time.Now().Unix(),
+	}
+	for claim, value := range values {
+		claims[claim] = value
+	}
+	if ttl > 0 {
+		claims["exp"] = time.Now().Add(ttl).Unix()
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + sign(secret, unsigned), nil
+}
+
+// ParseToken verify an HS256 JWT and return the string value of its claim
+func ParseToken(secret []byte, claim, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &alg); err != nil || alg.Alg != "HS256" {
+		return "", ErrInvalidToken
+	}
+
+	expected := sign(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	claims := make(map[string]interface{})
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", ErrInvalidToken
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() >= int64(exp) {
+		return "", ErrInvalidToken
+	}
+
+	id, _ := claims[claim].(string)
+	return id, nil
+}
+
+func sign(secret []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
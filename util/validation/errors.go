@@ -0,0 +1,102 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Errors are the failed rules of a request
+type Errors []*FieldError
+
+// messages of the body errors by language, {0} is the field and {1} the expected type
+var bodyMessages = map[string]map[string]string{
+	"syntax": {
+		English:    "the request body is not valid JSON",
+		Indonesian: "isi permintaan bukan JSON yang valid",
+	},
+	"type": {
+		English:    "{0} must be a {1}",
+		Indonesian: "{0} harus bertipe {1}",
+	},
+	"invalid": {
+		English:    "the request body is invalid",
+		Indonesian: "isi permintaan tidak valid",
+	},
+}
+
+// Error join the messages
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Translate return the field errors of err with their messages in lang: the rules of an *Error, and the JSON
+// syntax and type errors of a body bound by echo. Any other error is returned as an invalid body
+func Translate(err error, lang string) Errors {
+	var invalid *Error
+	if errors.As(err, &invalid) {
+		return invalid.Fields(lang)
+	}
+
+	// echo describe the body errors in its message, the decoding error is internal
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.Internal == nil {
+			err = errors.New(fmt.Sprint(httpErr.Message))
+		} else {
+			err = httpErr.Internal
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return Errors{{
+			Rule:    "syntax",
+			Param:   strconv.FormatInt(syntaxErr.Offset, 10),
+			Message: bodyMessage("syntax", lang),
+		}}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		typeName := typeErr.Type.Kind().String()
+		message := strings.Replace(bodyMessage("type", lang), "{0}", typeErr.Field, 1)
+		return Errors{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeName,
+			Message: strings.Replace(message, "{1}", typeName, 1),
+		}}
+	}
+
+	message := bodyMessage("invalid", lang)
+	if lang == DefaultLanguage {
+		// the error of a custom decoder, like a malformed date, is only described in English
+		message = err.Error()
+	}
+	return Errors{{
+		Rule:    "invalid",
+		Message: message,
+	}}
+}
+
+func bodyMessage(rule, lang string) string {
+	if message, ok := bodyMessages[rule][lang]; ok {
+		return message
+	}
+	return bodyMessages[rule][DefaultLanguage]
+}
+
+// TranslateRequest translate err in the language accepted by req
+func TranslateRequest(err error, req *http.Request) Errors {
+	return Translate(err, Language(req.Header.Get("Accept-Language")))
+}
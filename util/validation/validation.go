@@ -0,0 +1,233 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"golang.org/x/text/language"
+	validator "gopkg.in/go-playground/validator.v9"
+	enTranslations "gopkg.in/go-playground/validator.v9/translations/en"
+	idTranslations "gopkg.in/go-playground/validator.v9/translations/id"
+)
+
+// Languages of the messages, DefaultLanguage is used for the others
+const (
+	English    = "en"
+	Indonesian = "id"
+
+	DefaultLanguage = English
+)
+
+// matcher pick the supported language of an Accept-Language header, the first one is the default
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+
+// ruleMessages translate the rules of the model validators missing from the validator bundles,
+// {0} is the field and {1} the rule parameter
+var ruleMessages = map[string]map[string]string{
+	"required_without": {
+		English:    "{0} is required when {1} is empty",
+		Indonesian: "{0} wajib diisi jika {1} kosong",
+	},
+	"unique": {
+		Indonesian: "{0} harus berisi nilai yang unik",
+	},
+	"isbn10": {
+		English:    "{0} must be a valid ISBN-10",
+		Indonesian: "{0} harus berupa ISBN-10 yang valid",
+	},
+	"isbn13": {
+		English:    "{0} must be a valid ISBN-13",
+		Indonesian: "{0} harus berupa ISBN-13 yang valid",
+	},
+	"bcp47": {
+		English:    "{0} must be a BCP 47 language tag",
+		Indonesian: "{0} harus berupa kode bahasa BCP 47",
+	},
+	"eqisbn10": {
+		English:    "{0} must be the same book as isbn10",
+		Indonesian: "{0} harus merujuk buku yang sama dengan isbn10",
+	},
+	"httpurl": {
+		English:    "{0} must be an http or https URL",
+		Indonesian: "{0} harus berupa URL http atau https",
+	},
+	// fallback of the rules without message
+	"": {
+		English:    "{0} is invalid",
+		Indonesian: "{0} tidak valid",
+	},
+}
+
+type (
+	// FieldError is a failed rule of a request field, Field is the JSON path of the field
+	FieldError struct {
+		Field   string `json:"field" xml:"field"`
+		Rule    string `json:"rule" xml:"rule"`
+		Param   string `json:"param,omitempty" xml:"param,omitempty"`
+		Message string `json:"message" xml:"message"`
+	}
+
+	// Validator validate the models and translate their failed rules
+	Validator struct {
+		validate    *validator.Validate
+		translators map[string]ut.Translator
+	}
+
+	// Error is a failed validation, its messages are translated on demand by Translate
+	Error struct {
+		errors      validator.ValidationErrors
+		translators map[string]ut.Translator
+	}
+)
+
+// New return a Validator naming the fields after their JSON name, with the English and Indonesian messages
+// of the built-in rules
+func New() *Validator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonName)
+
+	// a translator holds the messages of a single validator
+	uni := ut.New(en.New(), en.New(), id.New())
+	v := &Validator{
+		validate:    validate,
+		translators: make(map[string]ut.Translator, 2),
+	}
+	v.translators[English], _ = uni.GetTranslator(English)
+	v.translators[Indonesian], _ = uni.GetTranslator(Indonesian)
+
+	if err := enTranslations.RegisterDefaultTranslations(validate, v.translators[English]); err != nil {
+		panic(err)
+	}
+	if err := idTranslations.RegisterDefaultTranslations(validate, v.translators[Indonesian]); err != nil {
+		panic(err)
+	}
+	for tag, messages := range ruleMessages {
+		if tag != "" {
+			v.registerMessages(tag, messages)
+		}
+	}
+
+	return v
+}
+
+// RegisterValidation add a rule, its messages are in ruleMessages
+func (v *Validator) RegisterValidation(tag string, fn validator.Func) {
+	if err := v.validate.RegisterValidation(tag, fn); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterStructValidation add a validation of the types, their errors are reported with the JSON name of the field
+func (v *Validator) RegisterStructValidation(fn validator.StructLevelFunc, types ...interface{}) {
+	v.validate.RegisterStructValidation(fn, types...)
+}
+
+// Struct validate s, the failed rules are returned as an *Error
+func (v *Validator) Struct(s interface{}) error {
+	err := v.validate.Struct(s)
+	if errs, ok := err.(validator.ValidationErrors); ok {
+		return &Error{errors: errs, translators: v.translators}
+	}
+	return err
+}
+
+// registerMessages translate tag, the built-in messages of the bundles are kept
+func (v *Validator) registerMessages(tag string, messages map[string]string) {
+	for lang, message := range messages {
+		translator := v.translators[lang]
+		if translator == nil {
+			continue
+		}
+		message := message
+		v.validate.RegisterTranslation(tag, translator, func(ut ut.Translator) error {
+			return ut.Add(tag, message, false)
+		}, translateRule)
+	}
+}
+
+// Error return the English messages
+func (e *Error) Error() string {
+	return Errors(e.Fields(DefaultLanguage)).Error()
+}
+
+// Fields return the failed rules with their messages in lang
+func (e *Error) Fields(lang string) []*FieldError {
+	translator, ok := e.translators[lang]
+	if !ok {
+		translator = e.translators[DefaultLanguage]
+	}
+
+	fields := make([]*FieldError, 0, len(e.errors))
+	for _, fe := range e.errors {
+		message := fe.Translate(translator)
+		if message == "" || message == untranslated(fe) {
+			message = translateFallback(translator, fe)
+		}
+		fields = append(fields, &FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message,
+		})
+	}
+	return fields
+}
+
+// Language return the supported language preferred by an Accept-Language header
+func Language(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+
+	tag, _, _ := matcher.Match(tags...)
+	base, _ := tag.Base()
+	return base.String()
+}
+
+func translateRule(ut ut.Translator, fe validator.FieldError) string {
+	message, err := ut.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return ""
+	}
+	return message
+}
+
+// untranslated is the message of a rule without translation
+func untranslated(fe validator.FieldError) string {
+	return fmt.Sprintf("Key: '%s' Error:Field validation for '%s' failed on the '%s' tag", fe.Namespace(), fe.Field(),
+		fe.Tag())
+}
+
+func translateFallback(translator ut.Translator, fe validator.FieldError) string {
+	message := ruleMessages[""][translator.Locale()]
+	if message == "" {
+		message = ruleMessages[""][DefaultLanguage]
+	}
+	return strings.Replace(message, "{0}", fe.Field(), 1)
+}
+
+// fieldPath return the JSON path of a field, like authors[0].name
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// jsonName name the fields after their JSON key
+func jsonName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
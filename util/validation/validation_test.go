@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+type (
+	author struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	request struct {
+		Title   string    `json:"title" validate:"required,max=5"`
+		Email   string    `json:"email" validate:"omitempty,email"`
+		Code    string    `json:"code" validate:"omitempty,even"`
+		Authors []*author `json:"authors" validate:"dive"`
+		Ignored string    `json:"-" validate:"required"`
+	}
+)
+
+// newValidator return a Validator with the even rule, it has no messages
+func newValidator() *Validator {
+	v := New()
+	v.RegisterValidation("even", func(fl validator.FieldLevel) bool {
+		return len(fl.Field().String())%2 == 0
+	})
+	return v
+}
+
+func TestStruct(t *testing.T) {
+	v := newValidator()
+	valid := &request{Title: "Go", Email: "gopher@example.com", Code: "ab", Ignored: "x"}
+	if err := v.Struct(valid); err != nil {
+		t.Fatalf("got %v validating a valid request", err)
+	}
+
+	tests := []struct {
+		name    string
+		request *request
+		en      FieldError
+		id      string
+	}{
+		{
+			"missing",
+			&request{Ignored: "x"},
+			FieldError{Field: "title", Rule: "required", Message: "title is a required field"},
+			"title wajib diisi",
+		},
+		{
+			"too long",
+			&request{Title: "Refactoring", Ignored: "x"},
+			FieldError{Field: "title", Rule: "max", Param: "5", Message: "title must be a maximum of 5 characters in length"},
+			"panjang maksimal title adalah 5 karakter",
+		},
+		{
+			"malformed",
+			&request{Title: "Go", Email: "gopher", Ignored: "x"},
+			FieldError{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			"email harus berupa alamat email yang valid",
+		},
+		{
+			"nested",
+			&request{Title: "Go", Authors: []*author{{Name: "Rob"}, {}}, Ignored: "x"},
+			FieldError{Field: "authors[1].name", Rule: "required", Message: "name is a required field"},
+			"name wajib diisi",
+		},
+		{
+			"without message",
+			&request{Title: "Go", Code: "abc", Ignored: "x"},
+			FieldError{Field: "code", Rule: "even", Message: "code is invalid"},
+			"code tidak valid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := v.Struct(test.request)
+			invalid, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("got %v, want an *Error", err)
+			}
+
+			fields := invalid.Fields(English)
+			if len(fields) != 1 || !reflect.DeepEqual(*fields[0], test.en) {
+				t.Fatalf("got %+v, want %+v", fields, test.en)
+			}
+			if err.Error() != test.en.Message {
+				t.Errorf("got error %q, want %q", err.Error(), test.en.Message)
+			}
+			if got := invalid.Fields(Indonesian)[0].Message; got != test.id {
+				t.Errorf("got Indonesian message %q, want %q", got, test.id)
+			}
+			// an unsupported language fall back to English
+			if got := invalid.Fields("fr")[0].Message; got != test.en.Message {
+				t.Errorf("got French message %q, want %q", got, test.en.Message)
+			}
+		})
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", English},
+		{"id-ID,id;q=0.9,en;q=0.8", Indonesian},
+		{"en;q=0.5,id;q=0.9", Indonesian},
+		{"fr-FR", English},
+		{"fr;q=0.9,id;q=0.8", Indonesian},
+		{"not a language;;", English},
+	}
+
+	for _, test := range tests {
+		if got := Language(test.header); got != test.want {
+			t.Errorf("Language(%q) = %q, want %q", test.header, got, test.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	e := echo.New()
+	bind := func(body string) error {
+		c := e.NewContext(httptest.NewRequest("POST", "/", strings.NewReader(body)), httptest.NewRecorder())
+		c.Request().Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.Bind(&request{})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		lang string
+		want FieldError
+	}{
+		{"syntax", bind(`{"title" 1}`), Indonesian, FieldError{Rule: "syntax", Param: "10",
+			Message: "isi permintaan bukan JSON yang valid"}},
+		{"type", bind(`{"title":1}`), English, FieldError{Field: "title", Rule: "type", Param: "string",
+			Message: "title must be a string"}},
+		{"type translated", bind(`{"title":1}`), Indonesian, FieldError{Field: "title", Rule: "type", Param: "string",
+			Message: "title harus bertipe string"}},
+		{"unsupported language", bind(`{"title" 1}`), "fr", FieldError{Rule: "syntax", Param: "10",
+			Message: "the request body is not valid JSON"}},
+		{"other", errors.New("published must be a date"), English, FieldError{Rule: "invalid",
+			Message: "published must be a date"}},
+		{"other translated", errors.New("published must be a date"), Indonesian, FieldError{Rule: "invalid",
+			Message: "isi permintaan tidak valid"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Translate(test.err, test.lang)
+			if len(got) != 1 || !reflect.DeepEqual(*got[0], test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+
+	// the rules of an *Error are translated in the language of the request
+	err := newValidator().Struct(&request{Ignored: "x"})
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Accept-Language", "id")
+	if got := TranslateRequest(err, req); len(got) != 1 || got[0].Message != "title wajib diisi" {
+		t.Errorf("got %+v, want the Indonesian message of the rule", got)
+	}
+}